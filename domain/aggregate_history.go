@@ -1,13 +1,71 @@
 package domain
 
-import "fmt"
+import (
+	"fmt"
+	"iter"
+	"time"
+)
 
 // History represents a sequence of events
 // that have occurred in an Aggregate.
 type History []Event
 
+// AsOf returns the leading events of the history that occurred at or before the given time.
+// It stops at the first event occurred after t, so that the returned history stays sequential.
+func (h History) AsOf(t time.Time) History {
+	for i, event := range h {
+		if event.Timestamp().After(t) {
+			return h[:i]
+		}
+	}
+	return h
+}
+
+// BetweenVersions returns the events with a version greater than from and lower than or equal to to,
+// i.e. the events that turn the aggregate at version from into the aggregate at version to.
+// A zero to means the latest version.
+func (h History) BetweenVersions(from, to AggregateVersion) History {
+	between := make(History, 0)
+	for _, event := range h {
+		version := event.AggregateRef().Version()
+		if version <= from || (to > 0 && version > to) {
+			continue
+		}
+		between = append(between, event)
+	}
+	return between
+}
+
+// BetweenTimes returns the events that turn the aggregate as of from into the aggregate as of to.
+// Both bounds are resolved as in AsOf. A zero to means the latest event.
+func (h History) BetweenTimes(from, to time.Time) History {
+	upper := h
+	if !to.IsZero() {
+		upper = h.AsOf(to)
+	}
+
+	lower := h.AsOf(from)
+	if len(lower) >= len(upper) {
+		return History{}
+	}
+	return append(History{}, upper[len(lower):]...)
+}
+
+// Events returns an iterator over the events of the history.
+// It allows a materialized history to be used where an event stream is expected.
+func (h History) Events() iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		for _, event := range h {
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
+
 // RestoreAggregateFromHistory restores the state of the Aggregate from the given History of events.
 //
+// The whole history is verified before any event is applied.
 // It records and commits the events if the Aggregate implements the EventCommitter interface.
 //
 // It returns an error if the events cannot be applied.
@@ -16,21 +74,38 @@ func RestoreAggregateFromHistory[ID comparable](agg EventSourcedAggregate[ID], e
 		return err
 	}
 
-	for _, event := range events {
-		err := agg.ApplyEvent(event)
+	return RestoreAggregateFromStream(agg, events.Events())
+}
+
+// RestoreAggregateFromStream restores the state of the Aggregate applying the events of the given stream
+// one at a time, so that the stream does not need to be held in memory.
+//
+// Each event is verified, as VerifyHistoryIntegrity does, right before being applied.
+// If the Aggregate implements EventRecorder and EventCommitter, each event is recorded and
+// committed once applied, so the aggregate version follows the applied events.
+//
+// It returns an error if the stream fails or an event cannot be verified or applied;
+// the events applied so far are kept in the aggregate.
+func RestoreAggregateFromStream[ID comparable](agg EventSourcedAggregate[ID], events iter.Seq2[Event, error]) error {
+	recorder, _ := agg.(EventRecorder)
+	committer, _ := agg.(EventCommitter)
+
+	for event, err := range VerifyHistoryStreamIntegrity(agg, events) {
 		if err != nil {
+			return err
+		}
+
+		if err := agg.ApplyEvent(event); err != nil {
 			return fmt.Errorf("could not apply event: %w", err)
 		}
-	}
 
-	if r, ok := agg.(EventRecorder); ok {
-		for _, event := range events {
-			r.RecordEvent(event)
+		if recorder != nil {
+			recorder.RecordEvent(event)
 		}
-	}
 
-	if c, ok := agg.(EventCommitter); ok {
-		c.CommitEvents()
+		if committer != nil {
+			committer.CommitEvents()
+		}
 	}
 
 	return nil
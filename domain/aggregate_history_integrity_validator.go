@@ -3,6 +3,7 @@ package domain
 import (
 	"errors"
 	"fmt"
+	"iter"
 )
 
 type HistoryIntegrityError struct {
@@ -54,46 +55,103 @@ func VerifyHistoryIntegrity[ID comparable](agg EventSourcedAggregate[ID], histor
 		return ErrEmptyEventHistory
 	}
 
-	aggregateID := agg.AggregateID()
-	aggregateName := agg.AggregateName()
-	baseVersion := UncommittedAggregateVersion(agg)
-
+	verifier := newHistoryVerifier(agg)
 	for i, evt := range history {
-		// Check for nil events
-		if evt == nil {
-			return NewHistoryIntegrityError(fmt.Sprintf("event at index %d is nil", i)).
-				WithDetails(i, nil, evt, "NIL_EVENT")
+		if err := verifier.verify(i, evt); err != nil {
+			return err
 		}
+	}
 
-		eventRef := evt.AggregateRef()
-		if eventRef == nil {
-			return NewHistoryIntegrityError(fmt.Sprintf("event at index %d has nil aggregate reference", i)).
-				WithDetails(i, nil, eventRef, "NIL_EVENT_REF")
-		}
+	return nil
+}
 
-		// Verify aggregate ID matches
-		if eventRef.ID() != aggregateID {
-			return NewHistoryIntegrityError(fmt.Sprintf("event at index %d has different aggregate ID: got %v, want %v",
-				i, eventRef.ID(), aggregateID)).
-				WithDetails(i, aggregateID, eventRef.ID(), "ID_MISMATCH")
+// VerifyHistoryStreamIntegrity wraps the given event stream verifying each event
+// as VerifyHistoryIntegrity does, before it is yielded.
+//
+// The iteration stops after yielding the first error, whether it comes from the stream or
+// from a failed check. An empty stream yields ErrEmptyEventHistory.
+func VerifyHistoryStreamIntegrity[ID comparable](agg EventSourcedAggregate[ID], events iter.Seq2[Event, error]) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		if agg == nil {
+			yield(nil, ErrNilAggregate)
+			return
 		}
 
-		// Verify aggregate type matches
-		if eventRef.Name() != aggregateName {
-			return NewHistoryIntegrityError(fmt.Sprintf("event at index %d has different aggregate type: got %q, want %q",
-				i, eventRef.Name(), aggregateName)).
-				WithDetails(i, aggregateName, eventRef.Name(), "TYPE_MISMATCH")
+		verifier := newHistoryVerifier(agg)
+		i := 0
+		for evt, err := range events {
+			if err == nil {
+				err = verifier.verify(i, evt)
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if !yield(evt, nil) {
+				return
+			}
+			i++
 		}
 
-		// Verify version is sequential
-		expectedVersion := baseVersion + AggregateVersion(i) + 1
-		actualVersion := eventRef.Version()
-		if actualVersion != expectedVersion {
-			return NewHistoryIntegrityError(fmt.Sprintf("event at index %d has unexpected version: got %d, want %d",
-				i, actualVersion, expectedVersion)).
-				WithDetails(i, expectedVersion, actualVersion, "VERSION_MISMATCH")
+		if i == 0 {
+			yield(nil, ErrEmptyEventHistory)
 		}
 	}
+}
+
+// historyVerifier checks the events of a history, one at a time, against the aggregate
+// state captured before the history is applied.
+type historyVerifier[ID comparable] struct {
+	aggregateID   ID
+	aggregateName string
+	baseVersion   AggregateVersion
+}
+
+func newHistoryVerifier[ID comparable](agg EventSourcedAggregate[ID]) historyVerifier[ID] {
+	return historyVerifier[ID]{
+		aggregateID:   agg.AggregateID(),
+		aggregateName: agg.AggregateName(),
+		baseVersion:   UncommittedAggregateVersion(agg),
+	}
+}
+
+// verify checks the event at index i of the history.
+func (v historyVerifier[ID]) verify(i int, evt Event) error {
+	// Check for nil events
+	if evt == nil {
+		return NewHistoryIntegrityError(fmt.Sprintf("event at index %d is nil", i)).
+			WithDetails(i, nil, evt, "NIL_EVENT")
+	}
+
+	eventRef := evt.AggregateRef()
+	if eventRef == nil {
+		return NewHistoryIntegrityError(fmt.Sprintf("event at index %d has nil aggregate reference", i)).
+			WithDetails(i, nil, eventRef, "NIL_EVENT_REF")
+	}
+
+	// Verify aggregate ID matches
+	if eventRef.ID() != v.aggregateID {
+		return NewHistoryIntegrityError(fmt.Sprintf("event at index %d has different aggregate ID: got %v, want %v",
+			i, eventRef.ID(), v.aggregateID)).
+			WithDetails(i, v.aggregateID, eventRef.ID(), "ID_MISMATCH")
+	}
+
+	// Verify aggregate type matches
+	if eventRef.Name() != v.aggregateName {
+		return NewHistoryIntegrityError(fmt.Sprintf("event at index %d has different aggregate type: got %q, want %q",
+			i, eventRef.Name(), v.aggregateName)).
+			WithDetails(i, v.aggregateName, eventRef.Name(), "TYPE_MISMATCH")
+	}
+
+	// Verify version is sequential
+	expectedVersion := v.baseVersion + AggregateVersion(i) + 1
+	actualVersion := eventRef.Version()
+	if actualVersion != expectedVersion {
+		return NewHistoryIntegrityError(fmt.Sprintf("event at index %d has unexpected version: got %d, want %d",
+			i, actualVersion, expectedVersion)).
+			WithDetails(i, expectedVersion, actualVersion, "VERSION_MISMATCH")
+	}
 
 	return nil
 }
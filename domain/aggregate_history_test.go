@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/xfrr/go-cqrsify/domain"
@@ -44,3 +45,30 @@ func randomStr() string {
 	_, _ = rand.Read(b)
 	return hex.EncodeToString(b)
 }
+
+func TestHistory_AsOf(t *testing.T) {
+	day := time.Date(2026, time.March, 3, 0, 0, 0, 0, time.UTC)
+	agg := domain.NewAggregate("agg-1", "agg-test")
+	for _, ts := range []time.Time{day, day.Add(2 * time.Hour), day.Add(time.Hour)} {
+		agg.RecordEvent(domain.NewEvent("test", domain.CreateEventAggregateRef(agg), domain.WithEventTimestamp(ts)))
+	}
+	history := domain.History(agg.AggregateEvents())
+
+	t.Run("should keep the history sequential", func(t *testing.T) {
+		require.Len(t, history.AsOf(day.Add(90*time.Minute)), 1)
+		require.Len(t, history.AsOf(day.Add(2*time.Hour)), 3)
+		require.Empty(t, history.AsOf(day.Add(-time.Second)))
+	})
+
+	t.Run("should diff the histories as of both times", func(t *testing.T) {
+		diff := history.BetweenTimes(day, day.Add(2*time.Hour))
+		require.Len(t, diff, 2)
+		require.Equal(t, domain.AggregateVersion(2), diff[0].AggregateRef().Version())
+	})
+
+	t.Run("should diff the versions", func(t *testing.T) {
+		diff := history.BetweenVersions(1, 2)
+		require.Len(t, diff, 1)
+		require.Equal(t, domain.AggregateVersion(2), diff[0].AggregateRef().Version())
+	})
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrAggregateClosed is the sentinel error matched by AggregateClosedError.
+	ErrAggregateClosed = errors.New("aggregate closed")
+	// ErrAggregateNotClosed is returned when archiving the stream of an aggregate that is not closed.
+	ErrAggregateNotClosed = errors.New("aggregate not closed")
+)
+
+// ClosableAggregate represents an aggregate with a lifecycle: it is closed once one of its
+// terminal events has been applied, e.g. OrderCancelled, and no longer accepts new events.
+type ClosableAggregate interface {
+	// AggregateClosed reports whether the aggregate is closed.
+	AggregateClosed() bool
+}
+
+// AggregateCloser is implemented by aggregates that can be closed without applying
+// a terminal event, e.g. when restored from a snapshot.
+type AggregateCloser interface {
+	// CloseAggregate closes the aggregate.
+	CloseAggregate()
+}
+
+// AggregateClosedError is returned by NextEvent when an event is recorded on a closed aggregate.
+type AggregateClosedError[ID comparable] struct {
+	ID   ID
+	Name string
+	// EventName is the name of the rejected event.
+	EventName string
+}
+
+func (e AggregateClosedError[ID]) Error() string {
+	return fmt.Sprintf("aggregate closed (id: %v, name: %s): event %s rejected", e.ID, e.Name, e.EventName)
+}
+
+// Is reports whether the target is ErrAggregateClosed.
+func (e AggregateClosedError[ID]) Is(target error) bool {
+	return target == ErrAggregateClosed
+}
+
+// NewAggregateClosedError creates an AggregateClosedError for the aggregate rejecting the given event.
+func NewAggregateClosedError[ID comparable](id ID, name, eventName string) AggregateClosedError[ID] {
+	return AggregateClosedError[ID]{ID: id, Name: name, EventName: eventName}
+}
+
+// IsAggregateClosed reports whether the given aggregate implements ClosableAggregate and is closed.
+func IsAggregateClosed(agg any) bool {
+	c, ok := agg.(ClosableAggregate)
+	return ok && c.AggregateClosed()
+}
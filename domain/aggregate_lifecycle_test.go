@@ -0,0 +1,176 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xfrr/go-cqrsify/domain"
+	"github.com/xfrr/go-cqrsify/domain/filestore"
+	"github.com/xfrr/go-cqrsify/domain/inmemory"
+)
+
+const counterClosedEventName = "counter.closed"
+
+func newClosableCounterAggregate(id string) *counterAggregate {
+	agg := newCounterAggregate(id)
+	agg.DeclareTerminalEvents(counterClosedEventName)
+	return agg
+}
+
+func (a *counterAggregate) close(t *testing.T) {
+	require.NoError(t, domain.NextEvent(a, domain.NewEvent(counterClosedEventName, domain.CreateEventAggregateRef(a))))
+}
+
+func TestAggregateLifecycle(t *testing.T) {
+	t.Run("should close the aggregate once a terminal event is applied", func(t *testing.T) {
+		agg := newClosableCounterAggregate("counter-1")
+		agg.increment(t)
+		assert.False(t, domain.IsAggregateClosed(agg))
+
+		agg.close(t)
+		assert.True(t, domain.IsAggregateClosed(agg))
+	})
+
+	t.Run("should reject the events recorded on a closed aggregate", func(t *testing.T) {
+		agg := newClosableCounterAggregate("counter-1")
+		agg.close(t)
+
+		err := domain.NextEvent(agg, domain.NewEvent("counter.incremented", domain.CreateEventAggregateRef(agg)))
+		require.ErrorIs(t, err, domain.ErrAggregateClosed)
+
+		var closedErr domain.AggregateClosedError[string]
+		require.ErrorAs(t, err, &closedErr)
+		assert.Equal(t, "counter-1", closedErr.ID)
+		assert.Equal(t, "counter.incremented", closedErr.EventName)
+		assert.Len(t, agg.AggregateEvents(), 1)
+		assert.Zero(t, agg.count)
+	})
+
+	t.Run("should soft delete a closed aggregate", func(t *testing.T) {
+		agg := newClosableCounterAggregate("counter-1")
+		agg.close(t)
+
+		require.NoError(t, domain.SoftDelete(agg))
+		assert.Len(t, agg.AggregateEvents(), 2)
+	})
+
+	t.Run("should be closed when restored from its history", func(t *testing.T) {
+		closed := newClosableCounterAggregate("counter-1")
+		closed.increment(t)
+		closed.close(t)
+
+		agg := newClosableCounterAggregate("counter-1")
+		require.NoError(t, domain.RestoreAggregateFromHistory(agg, closed.AggregateEvents()))
+		assert.True(t, domain.IsAggregateClosed(agg))
+	})
+
+	t.Run("should be closed when restored from a snapshot", func(t *testing.T) {
+		closed := newClosableCounterAggregate("counter-1")
+		closed.close(t)
+		closed.CommitEvents()
+
+		snapshot, err := domain.TakeSnapshot(closed)
+		require.NoError(t, err)
+		assert.True(t, snapshot.Closed)
+
+		agg := newClosableCounterAggregate("counter-1")
+		require.NoError(t, domain.RestoreAggregateFromSnapshot(agg, snapshot))
+		assert.True(t, domain.IsAggregateClosed(agg))
+	})
+}
+
+func TestEventSourceRepository_Archive(t *testing.T) {
+	ctx := context.Background()
+
+	open := func(t *testing.T) *filestore.EventStore[string] {
+		store, err := filestore.Open(filestore.Config[string]{Dir: t.TempDir()})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = store.Close() })
+		return store
+	}
+
+	store, archive := open(t), open(t)
+	repo := domain.NewTypedEventSourcedRepository(store, newClosableCounterAggregate,
+		domain.WithArchive(domain.EventStore[string](archive)),
+		domain.WithSnapshots[string](inmemory.NewSnapshotStore[string](), domain.SnapshotEveryNEvents(1)),
+	)
+
+	agg := newClosableCounterAggregate("counter-1")
+	agg.increment(t)
+	require.NoError(t, repo.Save(ctx, agg))
+
+	t.Run("should reject archiving an open aggregate", func(t *testing.T) {
+		require.ErrorIs(t, repo.Archive(ctx, agg), domain.ErrAggregateNotClosed)
+	})
+
+	agg.close(t)
+	require.NoError(t, repo.Save(ctx, agg))
+
+	t.Run("should move the stream of a closed aggregate to the archive", func(t *testing.T) {
+		require.NoError(t, repo.Archive(ctx, agg))
+
+		inStore, err := domain.StreamExists(ctx, store, "counter-1")
+		require.NoError(t, err)
+		assert.False(t, inStore)
+
+		archived, err := archive.RetrieveMany(ctx, "counter-1")
+		require.NoError(t, err)
+		assert.Len(t, archived, 2)
+
+		exists, err := repo.Exists(ctx, agg)
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("should load an archived aggregate closed", func(t *testing.T) {
+		loaded, err := repo.Get(ctx, "counter-1")
+		require.NoError(t, err)
+		assert.Equal(t, 1, loaded.count)
+		assert.Equal(t, domain.AggregateVersion(2), loaded.AggregateVersion())
+		assert.True(t, domain.IsAggregateClosed(loaded))
+	})
+
+	t.Run("should reject appending to an archived stream", func(t *testing.T) {
+		recreated := newClosableCounterAggregate("counter-1")
+		recreated.increment(t)
+		require.ErrorIs(t, repo.Save(ctx, recreated), domain.ErrAggregateClosed)
+
+		inStore, err := domain.StreamExists(ctx, store, "counter-1")
+		require.NoError(t, err)
+		assert.False(t, inStore)
+	})
+
+	t.Run("should not archive an archived stream twice", func(t *testing.T) {
+		require.NoError(t, repo.Archive(ctx, agg))
+
+		archived, err := archive.RetrieveMany(ctx, "counter-1")
+		require.NoError(t, err)
+		assert.Len(t, archived, 2)
+	})
+}
+
+func TestStreamExists(t *testing.T) {
+	ctx := context.Background()
+	store, err := filestore.Open(filestore.Config[string]{Dir: t.TempDir()})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	repo := domain.NewEventSourceRepository[string](store, domain.WithSnapshots[string](inmemory.NewSnapshotStore[string](), domain.SnapshotEveryNEvents(1)))
+
+	agg := newCounterAggregate("counter-1")
+	exists, err := repo.Exists(ctx, agg)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	agg.increment(t)
+	agg.increment(t)
+	require.NoError(t, repo.Save(ctx, agg))
+	require.NoError(t, repo.Truncate(ctx, agg, 2))
+
+	exists, err = repo.Exists(ctx, agg)
+	require.NoError(t, err)
+	assert.True(t, exists, "a truncated stream still exists")
+}
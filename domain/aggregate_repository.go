@@ -2,6 +2,9 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 )
 
 // Repository is the interface that wraps the basic methods for managing the
@@ -32,7 +35,8 @@ type VersionedRepository[T VersionedAggregate[ID], ID comparable] interface {
 type SearchableRepository[T Aggregate[ID], ID comparable] interface {
 	Repository[T, ID]
 
-	// Search loads all the aggregates from the repository that match the given options.
+	// Search loads all the aggregates from the repository that match the given options,
+	// sorted and paginated as requested by the options.
 	Search(context.Context, *SearchCriteriaOptions) ([]T, error)
 }
 
@@ -47,14 +51,29 @@ type EventSourcedRepository[T EventSourcedAggregate[ID], ID comparable] interfac
 	Save(context.Context, T) error
 	// LoadVersion loads the aggregate with the given id and version from the repository.
 	LoadVersion(context.Context, T, AggregateVersion) error
+	// LoadAsOf loads the aggregate with the given id as it was at the given time,
+	// applying the events whose timestamp is not after it.
+	LoadAsOf(context.Context, T, time.Time) error
 	// ExistsVersion checks if the aggregate with the given id and version exists in the repository.
 	ExistsVersion(context.Context, T, AggregateVersion) (bool, error)
 }
 
+// EventHistoryDiffer is the interface that wraps the methods returning the events
+// recorded by an event-sourced aggregate between two points of its history.
+type EventHistoryDiffer[T EventSourcedAggregate[ID], ID comparable] interface {
+	// DiffVersions returns the events that turn the aggregate at version from into the aggregate at version to.
+	// A zero to means the latest version.
+	DiffVersions(ctx context.Context, agg T, from, to AggregateVersion) (History, error)
+	// DiffTimes returns the events that turn the aggregate as of from into the aggregate as of to.
+	// A zero to means the latest event.
+	DiffTimes(ctx context.Context, agg T, from, to time.Time) (History, error)
+}
+
 type SearchableEventSourcedRepository[T EventSourcedAggregate[ID], ID comparable] interface {
 	EventSourcedRepository[T, ID]
 
-	// Search loads all the aggregates from the repository that match the given options.
+	// Search loads all the aggregates from the repository that match the given options,
+	// sorted and paginated as requested by the options.
 	Search(context.Context, *SearchCriteriaOptions) ([]T, error)
 }
 
@@ -70,3 +89,34 @@ func (e NotFoundError[ID]) Error() string {
 func NewNotFoundError[ID comparable](id ID) NotFoundError[ID] {
 	return NotFoundError[ID]{ID: id}
 }
+
+// ErrConcurrencyConflict is the sentinel error matched by ConcurrencyConflictError.
+var ErrConcurrencyConflict = errors.New("aggregate concurrency conflict")
+
+// ConcurrencyConflictError is returned when an aggregate is saved
+// with an expected version that differs from the stored version.
+type ConcurrencyConflictError struct {
+	AggregateID     any
+	ExpectedVersion AggregateVersion
+	ActualVersion   AggregateVersion
+}
+
+func (e ConcurrencyConflictError) Error() string {
+	return fmt.Sprintf("aggregate concurrency conflict (id: %v, expected version: %d, actual version: %d)",
+		e.AggregateID, e.ExpectedVersion, e.ActualVersion)
+}
+
+// Is reports whether the target is ErrConcurrencyConflict.
+func (e ConcurrencyConflictError) Is(target error) bool {
+	return target == ErrConcurrencyConflict
+}
+
+// NewConcurrencyConflictError creates a ConcurrencyConflictError for the aggregate with the given ID,
+// whose stream was expected at version expected but is at version actual.
+func NewConcurrencyConflictError(id any, expected, actual AggregateVersion) ConcurrencyConflictError {
+	return ConcurrencyConflictError{
+		AggregateID:     id,
+		ExpectedVersion: expected,
+		ActualVersion:   actual,
+	}
+}
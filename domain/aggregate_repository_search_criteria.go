@@ -1,11 +1,74 @@
 package domain
 
-import "slices"
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/xfrr/go-cqrsify/pkg/criteria"
+)
+
+// ErrSearchCursorNotFound is returned when the item identified by the search cursor
+// is not part of the search results, e.g. because it has been deleted.
+var ErrSearchCursorNotFound = errors.New("search cursor not found")
+
+// SearchSortField represents the field the search results are sorted by.
+type SearchSortField int
+
+const (
+	// SearchSortByPosition sorts the events in commit order
+	// and the aggregates in repository order. It is the default.
+	SearchSortByPosition SearchSortField = iota
+	// SearchSortByTimestamp sorts the events by timestamp.
+	// Aggregates are kept in repository order.
+	SearchSortByTimestamp
+	// SearchSortByVersion sorts the events and aggregates by aggregate version.
+	SearchSortByVersion
+	// SearchSortByAggregateID sorts the events and aggregates by aggregate ID.
+	SearchSortByAggregateID
+)
+
+// SearchCursor identifies the last item of a page of search results.
+// It is an opaque token that can be handed to clients to fetch the next page.
+type SearchCursor string
+
+// NewSearchCursor creates a cursor pointing to the given event.
+func NewSearchCursor(event Event) SearchCursor {
+	ref := event.AggregateRef()
+	return SearchCursor(fmt.Sprintf("%v@%d", ref.ID(), ref.Version()))
+}
+
+// NewAggregateSearchCursor creates a cursor pointing to the given aggregate.
+func NewAggregateSearchCursor[ID comparable](agg Aggregate[ID]) SearchCursor {
+	return SearchCursor(fmt.Sprint(agg.AggregateID()))
+}
+
+// EventCriteria is a pkg/criteria composite selecting stored events.
+// It can be combined with the criteria.NewAndCriteria, criteria.NewOrCriteria and
+// criteria.NewNotCriteria composites and passed to WithSearchCriteria.
+type EventCriteria = criteria.Criteria[*StoredEvent]
 
 type SearchCriteriaOptions struct {
 	aggregateIDs      []string
 	aggregateNames    []string
 	aggregateVersions []int
+
+	eventNames    []string
+	fromTimestamp time.Time
+	toTimestamp   time.Time
+	minVersion    AggregateVersion
+	maxVersion    AggregateVersion
+	metadata      map[string]string
+	criteria      EventCriteria
+
+	sortField SearchSortField
+	sortDesc  bool
+	limit     int
+	offset    int
+	after     SearchCursor
 }
 
 func (sc *SearchCriteriaOptions) AggregateIDs() []string {
@@ -20,10 +83,79 @@ func (sc *SearchCriteriaOptions) AggregateVersions() []int {
 	return sc.aggregateVersions
 }
 
+// EventNames returns the event names filter.
+func (sc *SearchCriteriaOptions) EventNames() []string {
+	return sc.eventNames
+}
+
+// TimestampRange returns the event timestamp range filter. Zero bounds are open.
+func (sc *SearchCriteriaOptions) TimestampRange() (from, to time.Time) {
+	return sc.fromTimestamp, sc.toTimestamp
+}
+
+// VersionRange returns the aggregate version range filter. Zero bounds are open.
+func (sc *SearchCriteriaOptions) VersionRange() (minVersion, maxVersion AggregateVersion) {
+	return sc.minVersion, sc.maxVersion
+}
+
+// Metadata returns a copy of the event metadata filter.
+func (sc *SearchCriteriaOptions) Metadata() map[string]string {
+	return maps.Clone(sc.metadata)
+}
+
+// Criteria returns the custom event criteria, nil if not set.
+func (sc *SearchCriteriaOptions) Criteria() EventCriteria {
+	return sc.criteria
+}
+
+// Sort returns the field the results are sorted by and whether the order is descending.
+func (sc *SearchCriteriaOptions) Sort() (field SearchSortField, desc bool) {
+	return sc.sortField, sc.sortDesc
+}
+
+// Limit returns the maximum number of results. Zero means no limit.
+func (sc *SearchCriteriaOptions) Limit() int {
+	return sc.limit
+}
+
+// Offset returns the number of results to skip.
+func (sc *SearchCriteriaOptions) Offset() int {
+	return sc.offset
+}
+
+// After returns the cursor the results start after, empty if not set.
+func (sc *SearchCriteriaOptions) After() SearchCursor {
+	return sc.after
+}
+
+// IsEmpty reports whether the criteria has no filters.
+// Sorting and pagination are not filters.
 func (sc *SearchCriteriaOptions) IsEmpty() bool {
 	return len(sc.aggregateIDs) == 0 &&
 		len(sc.aggregateNames) == 0 &&
-		len(sc.aggregateVersions) == 0
+		len(sc.aggregateVersions) == 0 &&
+		len(sc.eventNames) == 0 &&
+		sc.fromTimestamp.IsZero() && sc.toTimestamp.IsZero() &&
+		sc.minVersion == 0 && sc.maxVersion == 0 &&
+		len(sc.metadata) == 0 &&
+		sc.criteria == nil
+}
+
+// IsPaginated reports whether the criteria sorts or paginates the results.
+func (sc *SearchCriteriaOptions) IsPaginated() bool {
+	return sc.sortField != SearchSortByPosition || sc.sortDesc ||
+		sc.limit > 0 || sc.offset > 0 || sc.after != ""
+}
+
+// Filters returns a copy of the criteria without sorting and pagination.
+func (sc *SearchCriteriaOptions) Filters() *SearchCriteriaOptions {
+	filters := *sc
+	filters.sortField = SearchSortByPosition
+	filters.sortDesc = false
+	filters.limit = 0
+	filters.offset = 0
+	filters.after = ""
+	return &filters
 }
 
 // WithSearchAggregateIDs returns a search option that sets the aggregate ids to the search criteria.
@@ -44,13 +176,131 @@ func (sc *SearchCriteriaOptions) WithSearchAggregateVersions(versions ...int) *S
 	return sc
 }
 
+// WithSearchEventNames sets the event names to the search criteria.
+func (sc *SearchCriteriaOptions) WithSearchEventNames(names ...string) *SearchCriteriaOptions {
+	sc.eventNames = names
+	return sc
+}
+
+// WithSearchTimestampRange selects the events occurred at or after from and at or before to.
+// A zero bound is open.
+func (sc *SearchCriteriaOptions) WithSearchTimestampRange(from, to time.Time) *SearchCriteriaOptions {
+	sc.fromTimestamp = from
+	sc.toTimestamp = to
+	return sc
+}
+
+// WithSearchVersionRange selects the events, or aggregates, with a version
+// between minVersion and maxVersion (inclusive). A zero bound is open.
+func (sc *SearchCriteriaOptions) WithSearchVersionRange(minVersion, maxVersion AggregateVersion) *SearchCriteriaOptions {
+	sc.minVersion = minVersion
+	sc.maxVersion = maxVersion
+	return sc
+}
+
+// WithSearchMetadata selects the events whose metadata holds the given value for the given key.
+// It can be called multiple times; all the pairs must match.
+func (sc *SearchCriteriaOptions) WithSearchMetadata(key, value string) *SearchCriteriaOptions {
+	if sc.metadata == nil {
+		sc.metadata = make(map[string]string)
+	}
+	sc.metadata[key] = value
+	return sc
+}
+
+// WithSearchCriteria adds a custom event criteria, built from pkg/criteria composites,
+// that the events must meet besides the other filters.
+// It can be called multiple times; all the criteria must be met.
+func (sc *SearchCriteriaOptions) WithSearchCriteria(c EventCriteria) *SearchCriteriaOptions {
+	if sc.criteria == nil {
+		sc.criteria = c
+	} else {
+		sc.criteria = criteria.NewAndCriteria(sc.criteria, c)
+	}
+	return sc
+}
+
+// WithSearchSort sorts the results by the given field, in descending order if desc is true.
+// Ties are broken by aggregate ID and version, so the order is stable across pages.
+func (sc *SearchCriteriaOptions) WithSearchSort(field SearchSortField, desc bool) *SearchCriteriaOptions {
+	sc.sortField = field
+	sc.sortDesc = desc
+	return sc
+}
+
+// WithSearchLimit limits the number of results. Zero means no limit.
+func (sc *SearchCriteriaOptions) WithSearchLimit(limit int) *SearchCriteriaOptions {
+	sc.limit = limit
+	return sc
+}
+
+// WithSearchOffset skips the given number of results.
+func (sc *SearchCriteriaOptions) WithSearchOffset(offset int) *SearchCriteriaOptions {
+	sc.offset = offset
+	return sc
+}
+
+// WithSearchAfter returns the results following the item identified by the given cursor,
+// usually the last item of the previous page. See NewSearchCursor and NewAggregateSearchCursor.
+func (sc *SearchCriteriaOptions) WithSearchAfter(cursor SearchCursor) *SearchCriteriaOptions {
+	sc.after = cursor
+	return sc
+}
+
+// EventCriteria returns the event filters of the search criteria as a single pkg/criteria composite.
+// It returns nil if the search criteria has no filters.
+func (sc *SearchCriteriaOptions) EventCriteria() EventCriteria {
+	var filters []EventCriteria
+	if len(sc.aggregateIDs) > 0 {
+		filters = append(filters, AggregateIDCriteria(sc.aggregateIDs...))
+	}
+	if len(sc.aggregateNames) > 0 {
+		filters = append(filters, AggregateNameCriteria(sc.aggregateNames...))
+	}
+	if len(sc.aggregateVersions) > 0 {
+		filters = append(filters, criteria.NewPredicateCriteria(func(se *StoredEvent) bool {
+			return slices.Contains(sc.aggregateVersions, int(se.Event.AggregateRef().Version()))
+		}))
+	}
+	if len(sc.eventNames) > 0 {
+		filters = append(filters, EventNameCriteria(sc.eventNames...))
+	}
+	if !sc.fromTimestamp.IsZero() || !sc.toTimestamp.IsZero() {
+		filters = append(filters, EventTimestampCriteria(sc.fromTimestamp, sc.toTimestamp))
+	}
+	if sc.minVersion > 0 || sc.maxVersion > 0 {
+		filters = append(filters, AggregateVersionCriteria(sc.minVersion, sc.maxVersion))
+	}
+	for key, value := range sc.metadata {
+		filters = append(filters, EventMetadataCriteria(key, value))
+	}
+	if sc.criteria != nil {
+		filters = append(filters, sc.criteria)
+	}
+
+	builder := criteria.NewCriteriaBuilder[*StoredEvent]()
+	for _, filter := range filters {
+		builder.And(filter)
+	}
+	return builder.Build()
+}
+
 // Matches checks if the given aggregate matches the search criteria.
+//
+// Deprecated: use MatchesAggregate, which supports any aggregate ID type.
 func (sc *SearchCriteriaOptions) Matches(agg Aggregate[string]) bool {
-	if sc.IsEmpty() {
+	return MatchesAggregate(sc, agg)
+}
+
+// MatchesAggregate checks if the given aggregate matches the aggregate filters of the search criteria:
+// IDs, names, versions and version range. The aggregate ID is compared in its fmt.Sprint form.
+// The event filters are ignored.
+func MatchesAggregate[ID comparable](sc *SearchCriteriaOptions, agg Aggregate[ID]) bool {
+	if sc == nil {
 		return true
 	}
 
-	if len(sc.aggregateIDs) > 0 && !contains(sc.aggregateIDs, agg.AggregateID()) {
+	if len(sc.aggregateIDs) > 0 && !contains(sc.aggregateIDs, fmt.Sprint(agg.AggregateID())) {
 		return false
 	}
 
@@ -58,8 +308,12 @@ func (sc *SearchCriteriaOptions) Matches(agg Aggregate[string]) bool {
 		return false
 	}
 
-	if versionedAggregate, ok := agg.(VersionedAggregate[string]); ok {
-		if len(sc.aggregateVersions) > 0 && !contains(sc.aggregateVersions, int(versionedAggregate.AggregateVersion())) {
+	if versionedAggregate, ok := agg.(VersionedAggregate[ID]); ok {
+		version := versionedAggregate.AggregateVersion()
+		if len(sc.aggregateVersions) > 0 && !contains(sc.aggregateVersions, int(version)) {
+			return false
+		}
+		if !inVersionRange(version, sc.minVersion, sc.maxVersion) {
 			return false
 		}
 	}
@@ -67,8 +321,110 @@ func (sc *SearchCriteriaOptions) Matches(agg Aggregate[string]) bool {
 	return true
 }
 
-func contains[T comparable](slice []T, item T) bool {
-	return slices.Contains(slice, item)
+// SearchStoredEvents applies the search criteria to the given events, which must be in commit order:
+// the events are filtered, sorted and paginated.
+// It helps implementing EventSearcher on top of stores that cannot apply every criteria natively.
+func SearchStoredEvents(events []StoredEvent, sc *SearchCriteriaOptions) ([]StoredEvent, error) {
+	if sc == nil {
+		return events, nil
+	}
+
+	candidates := make([]*StoredEvent, len(events))
+	for i := range events {
+		candidates[i] = &events[i]
+	}
+
+	if c := sc.EventCriteria(); c != nil {
+		// composites such as criteria.OrCriteria do not keep the order of the events
+		matched := make(map[*StoredEvent]bool)
+		for _, se := range c.MeetsCriteria(candidates) {
+			matched[se] = true
+		}
+		candidates = slices.DeleteFunc(candidates, func(se *StoredEvent) bool { return !matched[se] })
+	}
+
+	sortStoredEvents(candidates, sc.sortField, sc.sortDesc)
+
+	candidates, err := paginate(candidates, sc, func(se *StoredEvent) SearchCursor {
+		return NewSearchCursor(se.Event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]StoredEvent, len(candidates))
+	for i, se := range candidates {
+		result[i] = *se
+	}
+	return result, nil
+}
+
+// SearchAggregates sorts and paginates the given aggregates, which must be in repository order,
+// according to the search criteria. The aggregates are expected to be already filtered.
+func SearchAggregates[T Aggregate[ID], ID comparable](aggs []T, sc *SearchCriteriaOptions) ([]T, error) {
+	if sc == nil {
+		return aggs, nil
+	}
+
+	switch sc.sortField {
+	case SearchSortByVersion:
+		slices.SortStableFunc(aggs, func(a, b T) int {
+			return compareAggregates(aggregateVersion[ID](a), aggregateVersion[ID](b), a, b)
+		})
+	case SearchSortByAggregateID:
+		slices.SortStableFunc(aggs, func(a, b T) int {
+			return compareAggregates(0, 0, a, b)
+		})
+	}
+	if sc.sortDesc {
+		slices.Reverse(aggs)
+	}
+
+	return paginate(aggs, sc, func(agg T) SearchCursor {
+		return NewAggregateSearchCursor[ID](agg)
+	})
+}
+
+// SearchEventSourcedAggregates groups the given matching events, in commit order, by aggregate,
+// creates each aggregate with newAggregate and restores it with restore. The aggregates are then
+// sorted and paginated according to the search criteria. Soft-deleted aggregates are skipped.
+// It helps implementing SearchableEventSourcedRepository.
+func SearchEventSourcedAggregates[T EventSourcedAggregate[ID], ID comparable](
+	events []Event,
+	sc *SearchCriteriaOptions,
+	newAggregate func(id ID, name string) T,
+	restore func(agg T) error,
+) ([]T, error) {
+	seen := make(map[ID]bool)
+	aggs := make([]T, 0)
+	for _, event := range events {
+		ref := event.AggregateRef()
+		id, ok := ref.ID().(ID)
+		if !ok {
+			return nil, fmt.Errorf("invalid aggregate ID type: %T", ref.ID())
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		agg := newAggregate(id, ref.Name())
+		err := restore(agg)
+		if errors.Is(err, ErrStreamDeleted) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not restore aggregate: %w", err)
+		}
+		aggs = append(aggs, agg)
+	}
+
+	return SearchAggregates(aggs, sc)
+}
+
+// newBaseAggregate creates a bare BaseAggregate, as an EventSourcedAggregate, for the search results.
+func newBaseAggregate[ID comparable](id ID, name string) EventSourcedAggregate[ID] {
+	return NewAggregate(id, name)
 }
 
 func SearchCriteria() *SearchCriteriaOptions {
@@ -78,3 +434,129 @@ func SearchCriteria() *SearchCriteriaOptions {
 		aggregateVersions: make([]int, 0),
 	}
 }
+
+// AggregateIDCriteria selects the events of the aggregates with the given IDs, compared in their fmt.Sprint form.
+func AggregateIDCriteria(ids ...string) EventCriteria {
+	return criteria.NewPredicateCriteria(func(se *StoredEvent) bool {
+		return slices.Contains(ids, fmt.Sprint(se.Event.AggregateRef().ID()))
+	})
+}
+
+// AggregateNameCriteria selects the events of the aggregates with the given names.
+func AggregateNameCriteria(names ...string) EventCriteria {
+	return criteria.NewPredicateCriteria(func(se *StoredEvent) bool {
+		return slices.Contains(names, se.Event.AggregateRef().Name())
+	})
+}
+
+// AggregateVersionCriteria selects the events with a version between minVersion and maxVersion (inclusive).
+// A zero bound is open.
+func AggregateVersionCriteria(minVersion, maxVersion AggregateVersion) EventCriteria {
+	return criteria.NewPredicateCriteria(func(se *StoredEvent) bool {
+		return inVersionRange(se.Event.AggregateRef().Version(), minVersion, maxVersion)
+	})
+}
+
+// EventNameCriteria selects the events with the given names.
+func EventNameCriteria(names ...string) EventCriteria {
+	return criteria.NewPredicateCriteria(func(se *StoredEvent) bool {
+		return slices.Contains(names, se.Event.Name())
+	})
+}
+
+// EventTimestampCriteria selects the events occurred at or after from and at or before to.
+// A zero bound is open.
+func EventTimestampCriteria(from, to time.Time) EventCriteria {
+	return criteria.NewPredicateCriteria(func(se *StoredEvent) bool {
+		ts := se.Event.Timestamp()
+		if !from.IsZero() && ts.Before(from) {
+			return false
+		}
+		return to.IsZero() || !ts.After(to)
+	})
+}
+
+// EventMetadataCriteria selects the events whose metadata holds the given value for the given key.
+func EventMetadataCriteria(key, value string) EventCriteria {
+	return criteria.NewPredicateCriteria(func(se *StoredEvent) bool {
+		v, ok := EventMetadata(se.Event)[key]
+		return ok && v == value
+	})
+}
+
+func contains[T comparable](slice []T, item T) bool {
+	return slices.Contains(slice, item)
+}
+
+func inVersionRange(version, minVersion, maxVersion AggregateVersion) bool {
+	if minVersion > 0 && version < minVersion {
+		return false
+	}
+	return maxVersion <= 0 || version <= maxVersion
+}
+
+func sortStoredEvents(events []*StoredEvent, field SearchSortField, desc bool) {
+	if field == SearchSortByPosition {
+		if desc {
+			slices.Reverse(events)
+		}
+		return
+	}
+
+	slices.SortStableFunc(events, func(a, b *StoredEvent) int {
+		refA, refB := a.Event.AggregateRef(), b.Event.AggregateRef()
+
+		var c int
+		switch field {
+		case SearchSortByTimestamp:
+			c = a.Event.Timestamp().Compare(b.Event.Timestamp())
+		case SearchSortByVersion:
+			c = cmp.Compare(refA.Version(), refB.Version())
+		}
+		if c == 0 {
+			c = cmp.Compare(fmt.Sprint(refA.ID()), fmt.Sprint(refB.ID()))
+		}
+		if c == 0 {
+			c = cmp.Compare(refA.Version(), refB.Version())
+		}
+		if desc {
+			return -c
+		}
+		return c
+	})
+}
+
+// paginate applies the cursor, offset and limit of the search criteria to the sorted items.
+func paginate[T any](items []T, sc *SearchCriteriaOptions, cursorOf func(T) SearchCursor) ([]T, error) {
+	if sc.after != "" {
+		i := slices.IndexFunc(items, func(item T) bool { return cursorOf(item) == sc.after })
+		if i < 0 {
+			return nil, ErrSearchCursorNotFound
+		}
+		items = items[i+1:]
+	}
+
+	if sc.offset > 0 {
+		items = items[min(sc.offset, len(items)):]
+	}
+
+	if sc.limit > 0 && len(items) > sc.limit {
+		items = items[:sc.limit]
+	}
+
+	return items, nil
+}
+
+func aggregateVersion[ID comparable](agg Aggregate[ID]) AggregateVersion {
+	if va, ok := agg.(VersionedAggregate[ID]); ok {
+		return va.AggregateVersion()
+	}
+	return 0
+}
+
+func compareAggregates[ID comparable](versionA, versionB AggregateVersion, a, b Aggregate[ID]) int {
+	if c := cmp.Compare(versionA, versionB); c != 0 {
+		return c
+	}
+	return cmp.Compare(fmt.Sprint(a.AggregateID()), fmt.Sprint(b.AggregateID()))
+}
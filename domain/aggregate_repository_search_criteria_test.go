@@ -1,10 +1,14 @@
 package domain_test
 
 import (
+	"fmt"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/xfrr/go-cqrsify/domain"
+	"github.com/xfrr/go-cqrsify/pkg/criteria"
 )
 
 func Test_SearchCriteria_AggregateIDs(t *testing.T) {
@@ -21,3 +25,177 @@ func Test_SearchCriteria_AggregateVersions(t *testing.T) {
 	sut := domain.SearchCriteria().WithSearchAggregateVersions(1, 2, 3)
 	require.ElementsMatch(t, sut.AggregateVersions(), []int{1, 2, 3})
 }
+
+func newSearchedEvents(t *testing.T) []domain.StoredEvent {
+	t.Helper()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	specs := []struct {
+		id, name, event string
+		version         int
+		offset          time.Duration
+		tenant          string
+	}{
+		{"order-1", "order", "order.placed", 1, 0, "acme"},
+		{"order-2", "order", "order.placed", 1, time.Hour, "globex"},
+		{"order-1", "order", "order.paid", 2, 2 * time.Hour, "acme"},
+		{"customer-1", "customer", "customer.registered", 1, 3 * time.Hour, "acme"},
+	}
+
+	events := make([]domain.StoredEvent, len(specs))
+	for i, spec := range specs {
+		events[i] = domain.StoredEvent{
+			Position: domain.GlobalPosition(i + 1),
+			Event: domain.NewEvent(spec.event,
+				domain.NewEventAggregateReference(spec.id, spec.name, domain.AggregateVersion(spec.version)),
+				domain.WithEventTimestamp(base.Add(spec.offset)),
+				domain.WithEventMetadataKeyValue("tenant", spec.tenant),
+			),
+		}
+	}
+	return events
+}
+
+func searchedNames(events []domain.StoredEvent) []string {
+	names := make([]string, len(events))
+	for i, se := range events {
+		names[i] = fmt.Sprintf("%v/%s", se.Event.AggregateRef().ID(), se.Event.Name())
+	}
+	return names
+}
+
+func TestSearchStoredEvents(t *testing.T) {
+	events := newSearchedEvents(t)
+	base := events[0].Event.Timestamp()
+
+	tests := map[string]struct {
+		criteria *domain.SearchCriteriaOptions
+		want     []string
+	}{
+		"empty criteria": {
+			criteria: domain.SearchCriteria(),
+			want:     []string{"order-1/order.placed", "order-2/order.placed", "order-1/order.paid", "customer-1/customer.registered"},
+		},
+		"event names": {
+			criteria: domain.SearchCriteria().WithSearchEventNames("order.paid", "customer.registered"),
+			want:     []string{"order-1/order.paid", "customer-1/customer.registered"},
+		},
+		"timestamp range": {
+			criteria: domain.SearchCriteria().WithSearchTimestampRange(base.Add(time.Hour), base.Add(2*time.Hour)),
+			want:     []string{"order-2/order.placed", "order-1/order.paid"},
+		},
+		"open timestamp range": {
+			criteria: domain.SearchCriteria().WithSearchTimestampRange(base.Add(2*time.Hour), time.Time{}),
+			want:     []string{"order-1/order.paid", "customer-1/customer.registered"},
+		},
+		"version range": {
+			criteria: domain.SearchCriteria().WithSearchVersionRange(2, 2),
+			want:     []string{"order-1/order.paid"},
+		},
+		"metadata": {
+			criteria: domain.SearchCriteria().WithSearchMetadata("tenant", "acme").WithSearchAggregateNames("order"),
+			want:     []string{"order-1/order.placed", "order-1/order.paid"},
+		},
+		"criteria composites": {
+			criteria: domain.SearchCriteria().WithSearchCriteria(criteria.NewOrCriteria(
+				domain.EventNameCriteria("customer.registered"),
+				criteria.NewNotCriteria(domain.EventMetadataCriteria("tenant", "acme")),
+			)),
+			want: []string{"order-2/order.placed", "customer-1/customer.registered"},
+		},
+		"sort by timestamp descending": {
+			criteria: domain.SearchCriteria().WithSearchSort(domain.SearchSortByTimestamp, true).WithSearchLimit(2),
+			want:     []string{"customer-1/customer.registered", "order-1/order.paid"},
+		},
+		"sort by aggregate ID": {
+			criteria: domain.SearchCriteria().WithSearchSort(domain.SearchSortByAggregateID, false),
+			want:     []string{"customer-1/customer.registered", "order-1/order.placed", "order-1/order.paid", "order-2/order.placed"},
+		},
+		"limit and offset": {
+			criteria: domain.SearchCriteria().WithSearchOffset(1).WithSearchLimit(2),
+			want:     []string{"order-2/order.placed", "order-1/order.paid"},
+		},
+		"offset out of range": {
+			criteria: domain.SearchCriteria().WithSearchOffset(10),
+			want:     []string{},
+		},
+		"cursor": {
+			criteria: domain.SearchCriteria().WithSearchAfter(domain.NewSearchCursor(events[1].Event)),
+			want:     []string{"order-1/order.paid", "customer-1/customer.registered"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			result, err := domain.SearchStoredEvents(events, tt.criteria)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, searchedNames(result))
+		})
+	}
+
+	t.Run("should fail on unknown cursors", func(t *testing.T) {
+		_, err := domain.SearchStoredEvents(events, domain.SearchCriteria().WithSearchAfter("unknown@1"))
+		require.ErrorIs(t, err, domain.ErrSearchCursorNotFound)
+	})
+}
+
+func TestSearchCriteriaOptions_Filters(t *testing.T) {
+	sut := domain.SearchCriteria().
+		WithSearchEventNames("order.placed").
+		WithSearchSort(domain.SearchSortByVersion, true).
+		WithSearchLimit(10).
+		WithSearchOffset(5).
+		WithSearchAfter("order-1@1")
+	require.True(t, sut.IsPaginated())
+	require.False(t, sut.IsEmpty())
+
+	filters := sut.Filters()
+	require.False(t, filters.IsPaginated())
+	require.Equal(t, []string{"order.placed"}, filters.EventNames())
+	require.Equal(t, 10, sut.Limit(), "the original criteria is left untouched")
+}
+
+func TestMatchesAggregate(t *testing.T) {
+	agg := domain.NewAggregate(42, "counter")
+	require.NoError(t, domain.NextEvent(agg, domain.NewEvent("counter.incremented", domain.CreateEventAggregateRef(agg))))
+	agg.CommitEvents()
+
+	require.True(t, domain.MatchesAggregate(domain.SearchCriteria(), agg))
+	require.True(t, domain.MatchesAggregate(domain.SearchCriteria().WithSearchAggregateIDs("42"), agg))
+	require.False(t, domain.MatchesAggregate(domain.SearchCriteria().WithSearchAggregateIDs("7"), agg))
+	require.True(t, domain.MatchesAggregate(domain.SearchCriteria().WithSearchAggregateVersions(1), agg))
+	require.False(t, domain.MatchesAggregate(domain.SearchCriteria().WithSearchVersionRange(2, 0), agg))
+	require.False(t, domain.MatchesAggregate(domain.SearchCriteria().WithSearchAggregateNames("order"), agg))
+}
+
+func TestSearchAggregates(t *testing.T) {
+	aggs := make([]*domain.BaseAggregate[string], 0)
+	for i, id := range []string{"c", "a", "b"} {
+		agg := domain.NewAggregate(id, "test")
+		for range i + 1 {
+			require.NoError(t, domain.NextEvent(agg, domain.NewEvent("test.event", domain.CreateEventAggregateRef(agg))))
+		}
+		agg.CommitEvents()
+		aggs = append(aggs, agg)
+	}
+
+	ids := func(aggs []*domain.BaseAggregate[string]) []string {
+		result := make([]string, len(aggs))
+		for i, agg := range aggs {
+			result[i] = agg.AggregateID()
+		}
+		return result
+	}
+
+	result, err := domain.SearchAggregates(slices.Clone(aggs), domain.SearchCriteria().WithSearchSort(domain.SearchSortByAggregateID, false))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, ids(result))
+
+	result, err = domain.SearchAggregates(slices.Clone(aggs), domain.SearchCriteria().WithSearchSort(domain.SearchSortByVersion, true).WithSearchLimit(2))
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "a"}, ids(result))
+
+	result, err = domain.SearchAggregates(slices.Clone(aggs), domain.SearchCriteria().WithSearchAfter(domain.NewAggregateSearchCursor(aggs[0])))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, ids(result))
+}
@@ -3,7 +3,11 @@ package domain
 import "github.com/xfrr/go-cqrsify/pkg/multierror"
 
 var (
-	_ EventCommitter = (*BaseAggregate[any])(nil)
+	_ EventCommitter           = (*BaseAggregate[any])(nil)
+	_ AggregateVersionRestorer = (*BaseAggregate[any])(nil)
+	_ EventGuarder             = (*BaseAggregate[any])(nil)
+	_ ClosableAggregate        = (*BaseAggregate[any])(nil)
+	_ AggregateCloser          = (*BaseAggregate[any])(nil)
 )
 
 // BaseAggregate implements the core functionality of an Aggregate.
@@ -15,6 +19,10 @@ type BaseAggregate[ID comparable] struct {
 
 	events   []Event
 	handlers map[string][]func(Event) error
+	guards   []EventGuard
+
+	terminal map[string]struct{}
+	closed   bool
 }
 
 // AggregateID returns the aggregate's ID.
@@ -61,6 +69,12 @@ func (agb *BaseAggregate[ID]) ClearEvents() {
 	agb.events = agb.events[:0]
 }
 
+// RestoreAggregateVersion sets the aggregate version.
+// It implements the AggregateVersionRestorer interface.
+func (agb *BaseAggregate[ID]) RestoreAggregateVersion(version AggregateVersion) {
+	agb.version = version
+}
+
 // HandleEvent registers a handler for the given event name.
 // The handler is called when the event is applied to the aggregate.
 func (agb *BaseAggregate[ID]) HandleEvent(name string, handler func(event Event) error) {
@@ -75,7 +89,55 @@ func (agb *BaseAggregate[ID]) HandleEvent(name string, handler func(event Event)
 	agb.handlers[name] = append(agb.handlers[name], handler)
 }
 
+// AddEventGuard registers a guard checking the events before they are recorded by NextEvent.
+func (agb *BaseAggregate[ID]) AddEventGuard(guard EventGuard) {
+	agb.guards = append(agb.guards, guard)
+}
+
+// GuardEvent checks the given event with the registered guards, in registration order,
+// and returns the first rejection.
+// It implements the EventGuarder interface.
+func (agb *BaseAggregate[ID]) GuardEvent(event Event) error {
+	for _, guard := range agb.guards {
+		if err := guard(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeclareTerminalEvents declares the events closing the aggregate once applied.
+// A closed aggregate rejects the events recorded with NextEvent.
+func (agb *BaseAggregate[ID]) DeclareTerminalEvents(names ...string) {
+	if agb.terminal == nil {
+		agb.terminal = make(map[string]struct{}, len(names))
+	}
+
+	for _, name := range names {
+		agb.terminal[name] = struct{}{}
+	}
+}
+
+// IsTerminalEvent reports whether the given event name has been declared as terminal.
+func (agb *BaseAggregate[ID]) IsTerminalEvent(name string) bool {
+	_, ok := agb.terminal[name]
+	return ok
+}
+
+// AggregateClosed reports whether a terminal event has been applied to the aggregate.
+// It implements the ClosableAggregate interface.
+func (agb *BaseAggregate[ID]) AggregateClosed() bool {
+	return agb.closed
+}
+
+// CloseAggregate closes the aggregate.
+// It implements the AggregateCloser interface.
+func (agb *BaseAggregate[ID]) CloseAggregate() {
+	agb.closed = true
+}
+
 // ApplyEvent calls the handlers for the given event (event) name.
+// The aggregate is closed once a terminal event is applied successfully.
 func (agb *BaseAggregate[ID]) ApplyEvent(ev Event) error {
 	if agb.handlers == nil {
 		agb.handlers = make(map[string][]func(Event) error)
@@ -90,7 +152,14 @@ func (agb *BaseAggregate[ID]) ApplyEvent(ev Event) error {
 		}
 	}
 
-	return multiErr.ErrorOrNil()
+	if err := multiErr.ErrorOrNil(); err != nil {
+		return err
+	}
+
+	if agb.IsTerminalEvent(ev.Name()) {
+		agb.closed = true
+	}
+	return nil
 }
 
 // Any returns a copy of the aggregate with an arbitrary ID type.
@@ -101,6 +170,9 @@ func (agb *BaseAggregate[ID]) Any() *BaseAggregate[any] {
 		version:  agb.version,
 		events:   agb.events,
 		handlers: agb.handlers,
+		guards:   agb.guards,
+		terminal: agb.terminal,
+		closed:   agb.closed,
 	}
 }
 
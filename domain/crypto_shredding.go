@@ -0,0 +1,269 @@
+package domain
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ShreddedValue replaces the personal data fields whose encryption key has been deleted.
+const ShreddedValue = "[shredded]"
+
+var (
+	// ErrKeyNotFound is returned by a KeyStore when the data subject has no key.
+	ErrKeyNotFound = errors.New("encryption key not found")
+	// ErrKeyShredded is returned by a KeyStore when the key of the data subject has been deleted.
+	ErrKeyShredded = errors.New("encryption key shredded")
+	// ErrInvalidCiphertext is returned when a personal data field cannot be decrypted.
+	ErrInvalidCiphertext = errors.New("invalid personal data ciphertext")
+)
+
+// KeyStore manages the encryption keys of the data subjects, e.g. customers or users,
+// whose personal data is stored in the events.
+//
+// Deleting the key of a subject crypto-shreds its personal data: the events are kept
+// in the append-only store, but their personal data fields can no longer be decrypted.
+type KeyStore interface {
+	// CreateKey returns the key of the given subject, creating it if it does not exist.
+	// It returns ErrKeyShredded if the key of the subject has been deleted.
+	CreateKey(ctx context.Context, subjectID string) ([]byte, error)
+	// Key returns the key of the given subject.
+	// It returns ErrKeyNotFound or ErrKeyShredded if the key is not available.
+	Key(ctx context.Context, subjectID string) ([]byte, error)
+	// DeleteKey deletes the key of the given subject.
+	// The key can not be created again.
+	DeleteKey(ctx context.Context, subjectID string) error
+}
+
+// FieldCipher encrypts and decrypts the personal data fields of an event.
+type FieldCipher interface {
+	// Encrypt encrypts the given field value.
+	Encrypt(plaintext string) (string, error)
+	// Decrypt decrypts the given field value.
+	// It returns ShreddedValue if the key of the data subject has been deleted.
+	Decrypt(ciphertext string) (string, error)
+}
+
+// PersonalDataEvent represents an event carrying personal data of a single data subject.
+type PersonalDataEvent interface {
+	Event
+	// DataSubjectID returns the ID of the subject owning the personal data.
+	DataSubjectID() string
+	// EncryptPersonalData returns a copy of the event with its personal data fields encrypted.
+	EncryptPersonalData(FieldCipher) (Event, error)
+	// DecryptPersonalData returns a copy of the event with its personal data fields decrypted.
+	DecryptPersonalData(FieldCipher) (Event, error)
+}
+
+// PersonalDataAggregate is implemented by aggregates whose state holds personal data of a single data subject.
+// With WithCryptoShredding, their snapshot state is encrypted with the key of the subject,
+// so that shredding the subject invalidates their snapshots as well.
+type PersonalDataAggregate interface {
+	// DataSubjectID returns the ID of the subject owning the personal data.
+	DataSubjectID() string
+}
+
+// CryptoShredder encrypts the personal data of the events with the key of their data subject.
+type CryptoShredder struct {
+	keys KeyStore
+}
+
+// NewCryptoShredder creates a new CryptoShredder backed by the given KeyStore.
+func NewCryptoShredder(keys KeyStore) *CryptoShredder {
+	return &CryptoShredder{keys: keys}
+}
+
+// EncryptEvents encrypts the personal data of the given events.
+// Events not implementing PersonalDataEvent are returned as is.
+func (s *CryptoShredder) EncryptEvents(ctx context.Context, events []Event) ([]Event, error) {
+	return s.transform(events, func(event PersonalDataEvent) (Event, error) {
+		key, err := s.keys.CreateKey(ctx, event.DataSubjectID())
+		if err != nil {
+			return nil, fmt.Errorf("could not create encryption key: %w", err)
+		}
+
+		c, err := newAESFieldCipher(key)
+		if err != nil {
+			return nil, err
+		}
+
+		return event.EncryptPersonalData(c)
+	})
+}
+
+// DecryptEvents decrypts the personal data of the given events.
+// The personal data fields of the subjects whose key has been deleted are replaced by ShreddedValue.
+func (s *CryptoShredder) DecryptEvents(ctx context.Context, events []Event) ([]Event, error) {
+	return s.transform(events, func(event PersonalDataEvent) (Event, error) {
+		key, err := s.keys.Key(ctx, event.DataSubjectID())
+		if errors.Is(err, ErrKeyShredded) || errors.Is(err, ErrKeyNotFound) {
+			return event.DecryptPersonalData(shreddedFieldCipher{})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve encryption key: %w", err)
+		}
+
+		c, err := newAESFieldCipher(key)
+		if err != nil {
+			return nil, err
+		}
+
+		return event.DecryptPersonalData(c)
+	})
+}
+
+// EncryptSnapshot encrypts the state of the given snapshot with the key of the given subject.
+func (s *CryptoShredder) EncryptSnapshot(ctx context.Context, snapshot Snapshot, subjectID string) (Snapshot, error) {
+	key, err := s.keys.CreateKey(ctx, subjectID)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("could not create encryption key: %w", err)
+	}
+
+	c, err := newAESFieldCipher(key)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	state, err := c.seal(snapshot.State)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snapshot.State = state
+	snapshot.DataSubjectID = subjectID
+	return snapshot, nil
+}
+
+// DecryptSnapshot decrypts the state of the given snapshot, if encrypted.
+// It returns ErrKeyShredded or ErrKeyNotFound if the key of its data subject is not available.
+func (s *CryptoShredder) DecryptSnapshot(ctx context.Context, snapshot Snapshot) (Snapshot, error) {
+	if snapshot.DataSubjectID == "" {
+		return snapshot, nil
+	}
+
+	key, err := s.keys.Key(ctx, snapshot.DataSubjectID)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("could not retrieve encryption key: %w", err)
+	}
+
+	c, err := newAESFieldCipher(key)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	state, err := c.open(snapshot.State)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snapshot.State = state
+	snapshot.DataSubjectID = ""
+	return snapshot, nil
+}
+
+// Shred deletes the key of the given subject, making its personal data unreadable.
+// The snapshots encrypted with the key (see PersonalDataAggregate) can no longer be restored either,
+// so the repositories replay the events of the aggregates instead.
+func (s *CryptoShredder) Shred(ctx context.Context, subjectID string) error {
+	return s.keys.DeleteKey(ctx, subjectID)
+}
+
+func (s *CryptoShredder) transform(events []Event, fn func(PersonalDataEvent) (Event, error)) ([]Event, error) {
+	result := make([]Event, len(events))
+	for i, event := range events {
+		pde, ok := event.(PersonalDataEvent)
+		if !ok {
+			result[i] = event
+			continue
+		}
+
+		transformed, err := fn(pde)
+		if err != nil {
+			return nil, fmt.Errorf("could not transform personal data of event %s: %w", event.Name(), err)
+		}
+		result[i] = transformed
+	}
+
+	return result, nil
+}
+
+// aesFieldCipher is a FieldCipher using AES-GCM.
+// The ciphertext is the base64 encoding of the nonce followed by the sealed value.
+type aesFieldCipher struct {
+	aead cipher.AEAD
+}
+
+func newAESFieldCipher(key []byte) (aesFieldCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return aesFieldCipher{}, fmt.Errorf("invalid encryption key: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return aesFieldCipher{}, err
+	}
+
+	return aesFieldCipher{aead: aead}, nil
+}
+
+func (c aesFieldCipher) Encrypt(plaintext string) (string, error) {
+	sealed, err := c.seal([]byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c aesFieldCipher) Decrypt(ciphertext string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+
+	plaintext, err := c.open(sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// seal encrypts the given bytes, returning the nonce followed by the sealed value.
+func (c aesFieldCipher) seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts the given nonce and sealed value.
+func (c aesFieldCipher) open(sealed []byte) ([]byte, error) {
+	if len(sealed) < c.aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	nonce, sealed := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	return plaintext, nil
+}
+
+// shreddedFieldCipher is the FieldCipher used once the key of the data subject has been deleted.
+type shreddedFieldCipher struct{}
+
+func (shreddedFieldCipher) Encrypt(_ string) (string, error) {
+	return "", ErrKeyShredded
+}
+
+func (shreddedFieldCipher) Decrypt(_ string) (string, error) {
+	return ShreddedValue, nil
+}
@@ -0,0 +1,193 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xfrr/go-cqrsify/domain"
+	"github.com/xfrr/go-cqrsify/domain/inmemory"
+)
+
+type customerRegistered struct {
+	domain.BaseEvent
+
+	CustomerID string
+	Email      string
+	Country    string
+}
+
+func (e customerRegistered) DataSubjectID() string {
+	return e.CustomerID
+}
+
+func (e customerRegistered) EncryptPersonalData(c domain.FieldCipher) (domain.Event, error) {
+	email, err := c.Encrypt(e.Email)
+	if err != nil {
+		return nil, err
+	}
+	e.Email = email
+	return e, nil
+}
+
+func (e customerRegistered) DecryptPersonalData(c domain.FieldCipher) (domain.Event, error) {
+	email, err := c.Decrypt(e.Email)
+	if err != nil {
+		return nil, err
+	}
+	e.Email = email
+	return e, nil
+}
+
+type customerAggregate struct {
+	*domain.BaseAggregate[string]
+
+	email string
+}
+
+func newCustomerAggregate(id string) *customerAggregate {
+	agg := &customerAggregate{BaseAggregate: domain.NewAggregate(id, "customer")}
+	agg.HandleEvent("customer.registered", func(event domain.Event) error {
+		agg.email = event.(customerRegistered).Email
+		return nil
+	})
+	return agg
+}
+
+func (a *customerAggregate) DataSubjectID() string {
+	return a.AggregateID()
+}
+
+func (a *customerAggregate) SnapshotState() ([]byte, error) {
+	return []byte(a.email), nil
+}
+
+func (a *customerAggregate) RestoreSnapshotState(state []byte) error {
+	a.email = string(state)
+	return nil
+}
+
+func TestCryptoShredding_Snapshots(t *testing.T) {
+	ctx := context.Background()
+	keys := inmemory.NewKeyStore()
+	snapshots := inmemory.NewSnapshotStore[string]()
+	sut := domain.NewEventSourceRepository(&eventStoreMock{},
+		domain.WithCryptoShredding[string](keys),
+		domain.WithSnapshots[string](snapshots, domain.SnapshotEveryNEvents(1)),
+	)
+
+	agg := newCustomerAggregate("customer-1")
+	require.NoError(t, domain.NextEvent(agg, customerRegistered{
+		BaseEvent:  domain.NewEvent("customer.registered", domain.CreateEventAggregateRef(agg)),
+		CustomerID: "customer-1",
+		Email:      "jane@example.com",
+	}))
+	require.NoError(t, sut.Save(ctx, agg))
+
+	t.Run("should encrypt the snapshot state", func(t *testing.T) {
+		snapshot, err := snapshots.LatestSnapshot(ctx, "customer-1")
+		require.NoError(t, err)
+		require.Equal(t, "customer-1", snapshot.DataSubjectID)
+		require.NotContains(t, string(snapshot.State), "jane@example.com")
+
+		loaded := newCustomerAggregate("customer-1")
+		require.NoError(t, sut.Load(ctx, loaded))
+		require.Equal(t, "jane@example.com", loaded.email)
+	})
+
+	t.Run("should not restore the snapshot of a shredded subject", func(t *testing.T) {
+		require.NoError(t, domain.NewCryptoShredder(keys).Shred(ctx, "customer-1"))
+
+		loaded := newCustomerAggregate("customer-1")
+		require.NoError(t, sut.Load(ctx, loaded))
+		require.Equal(t, domain.ShreddedValue, loaded.email)
+		require.Equal(t, domain.AggregateVersion(1), loaded.AggregateVersion())
+	})
+}
+
+func TestCryptoShredding(t *testing.T) {
+	ctx := context.Background()
+	store := &eventStoreMock{}
+	keys := inmemory.NewKeyStore()
+	sut := domain.NewEventSourceRepository(store, domain.WithCryptoShredding[string](keys))
+
+	agg := newCustomerAggregate("customer-1")
+	require.NoError(t, domain.NextEvent(agg, customerRegistered{
+		BaseEvent:  domain.NewEvent("customer.registered", domain.CreateEventAggregateRef(agg)),
+		CustomerID: "customer-1",
+		Email:      "jane@example.com",
+		Country:    "ES",
+	}))
+	require.NoError(t, sut.Save(ctx, agg))
+	require.Equal(t, "jane@example.com", agg.email)
+
+	t.Run("should store the personal data encrypted", func(t *testing.T) {
+		require.Len(t, store.events, 1)
+		stored := store.events[0].(customerRegistered)
+		require.NotEqual(t, "jane@example.com", stored.Email)
+		require.Equal(t, "ES", stored.Country)
+	})
+
+	t.Run("should decrypt the personal data on load", func(t *testing.T) {
+		loaded := newCustomerAggregate("customer-1")
+		require.NoError(t, sut.Load(ctx, loaded))
+		require.Equal(t, "jane@example.com", loaded.email)
+	})
+
+	t.Run("should shred the personal data when the key is deleted", func(t *testing.T) {
+		require.NoError(t, keys.DeleteKey(ctx, "customer-1"))
+
+		loaded := newCustomerAggregate("customer-1")
+		require.NoError(t, sut.Load(ctx, loaded))
+		require.Equal(t, domain.ShreddedValue, loaded.email)
+		require.Equal(t, domain.AggregateVersion(1), loaded.AggregateVersion())
+	})
+
+	t.Run("should not encrypt new personal data of a shredded subject", func(t *testing.T) {
+		agg := newCustomerAggregate("customer-1")
+		agg.RestoreAggregateVersion(1)
+		require.NoError(t, domain.NextEvent(agg, customerRegistered{
+			BaseEvent:  domain.NewEvent("customer.registered", domain.CreateEventAggregateRef(agg)),
+			CustomerID: "customer-1",
+			Email:      "jane@example.com",
+		}))
+
+		err := sut.Save(ctx, agg)
+		require.ErrorIs(t, err, domain.ErrKeyShredded)
+		require.Len(t, store.events, 1)
+	})
+}
+
+func TestCryptoShreddingEventStore_ReadAll(t *testing.T) {
+	ctx := context.Background()
+	store := &eventStoreMock{}
+	keys := inmemory.NewKeyStore()
+	sut := domain.NewCryptoShreddingEventStore[string](store, domain.NewCryptoShredder(keys))
+
+	agg := newCustomerAggregate("customer-1")
+	require.NoError(t, domain.NextEvent(agg, customerRegistered{
+		BaseEvent:  domain.NewEvent("customer.registered", domain.CreateEventAggregateRef(agg)),
+		CustomerID: "customer-1",
+		Email:      "jane@example.com",
+		Country:    "ES",
+	}))
+	require.NoError(t, sut.Save(ctx, agg.AggregateEvents()))
+
+	t.Run("should decrypt the personal data of the global stream", func(t *testing.T) {
+		stored, err := sut.ReadAll(ctx, 1, 0)
+		require.NoError(t, err)
+		require.Len(t, stored, 1)
+		require.Equal(t, domain.GlobalPosition(1), stored[0].Position)
+		require.Equal(t, "jane@example.com", stored[0].Event.(customerRegistered).Email)
+	})
+
+	t.Run("should shred the personal data of the global stream when the key is deleted", func(t *testing.T) {
+		require.NoError(t, keys.DeleteKey(ctx, "customer-1"))
+
+		stored, err := sut.ReadAll(ctx, 1, 0)
+		require.NoError(t, err)
+		require.Len(t, stored, 1)
+		require.Equal(t, domain.ShreddedValue, stored[0].Event.(customerRegistered).Email)
+		require.Equal(t, "ES", stored[0].Event.(customerRegistered).Country)
+	})
+}
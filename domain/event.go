@@ -2,6 +2,7 @@ package domain
 
 import (
 	"errors"
+	"maps"
 	"time"
 )
 
@@ -20,11 +21,21 @@ type Event interface {
 	AggregateRef() *EventAggregateReference
 }
 
+// RevisionedEvent represents an event carrying its schema revision.
+// Events not implementing this interface are considered to be at revision 1.
+type RevisionedEvent interface {
+	Event
+	// Revision returns the schema revision of the event.
+	Revision() int
+}
+
 // BaseEvent is the default implementation of the Event interface.
 type BaseEvent struct {
 	name         string
+	revision     int
 	timestamp    time.Time
 	aggregateRef *EventAggregateReference
+	metadata     map[string]string
 }
 
 func (e BaseEvent) Name() string {
@@ -39,9 +50,22 @@ func (e BaseEvent) AggregateRef() *EventAggregateReference {
 	return e.aggregateRef
 }
 
+// Metadata returns a copy of the event metadata.
+func (e BaseEvent) Metadata() map[string]string {
+	return maps.Clone(e.metadata)
+}
+
+func (e BaseEvent) Revision() int {
+	if e.revision < 1 {
+		return 1
+	}
+	return e.revision
+}
+
 func NewEvent(name string, aggref *EventAggregateReference, opts ...EventOption) BaseEvent {
 	event := &BaseEvent{
 		name:         name,
+		revision:     1,
 		aggregateRef: aggref,
 		timestamp:    time.Now(),
 	}
@@ -52,3 +76,34 @@ func NewEvent(name string, aggref *EventAggregateReference, opts ...EventOption)
 
 	return *event
 }
+
+// EventRevision returns the schema revision of the given event.
+// It returns 1 if the event does not implement RevisionedEvent.
+func EventRevision(event Event) int {
+	if re, ok := event.(RevisionedEvent); ok {
+		return re.Revision()
+	}
+	return 1
+}
+
+// StampMetadata adds the given metadata to the event, keeping the keys the event already has.
+// The metadata map is copied, so the copies of the event are left untouched.
+func (e *BaseEvent) StampMetadata(metadata map[string]string) {
+	stamped := maps.Clone(e.metadata)
+	if stamped == nil {
+		stamped = make(map[string]string, len(metadata))
+	}
+	for key, value := range metadata {
+		if _, ok := stamped[key]; !ok {
+			stamped[key] = value
+		}
+	}
+	e.metadata = stamped
+}
+
+// RestoreBaseEvent replaces the event base with the given one.
+// It is used by the EventRegistry to restore the name, timestamp, revision
+// and aggregate reference of decoded events embedding BaseEvent.
+func (e *BaseEvent) RestoreBaseEvent(base BaseEvent) {
+	*e = base
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+)
+
+var (
+	_ EventPayloadCodec = JSONEventCodec{}
+	_ EventPayloadCodec = BinaryEventCodec{}
+)
+
+// EventPayloadCodec marshals and unmarshals event payloads.
+type EventPayloadCodec interface {
+	// Name returns the name of the codec, e.g. "json".
+	Name() string
+	// Marshal encodes the given payload.
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes the data into the payload pointed to by v.
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONEventCodec encodes event payloads as JSON.
+type JSONEventCodec struct{}
+
+func (JSONEventCodec) Name() string { return "json" }
+
+func (JSONEventCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONEventCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// BinaryEventCodec encodes event payloads implementing
+// encoding.BinaryMarshaler and encoding.BinaryUnmarshaler,
+// e.g. payloads generated by protobuf or msgpack tooling.
+type BinaryEventCodec struct{}
+
+func (BinaryEventCodec) Name() string { return "binary" }
+
+func (BinaryEventCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("binary codec: %T does not implement encoding.BinaryMarshaler", v)
+	}
+	return m.MarshalBinary()
+}
+
+func (BinaryEventCodec) Unmarshal(data []byte, v any) error {
+	u, ok := v.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return fmt.Errorf("binary codec: %T does not implement encoding.BinaryUnmarshaler", v)
+	}
+	return u.UnmarshalBinary(data)
+}
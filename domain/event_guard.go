@@ -0,0 +1,11 @@
+package domain
+
+// EventGuard checks an event before it is recorded by an aggregate.
+// A non-nil error rejects the event. See NextEvent.
+type EventGuard func(event Event) error
+
+// EventGuarder represents an aggregate checking the events before they are recorded.
+// NextEvent does not apply nor record the events rejected by GuardEvent.
+type EventGuarder interface {
+	GuardEvent(event Event) error
+}
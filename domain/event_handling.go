@@ -20,6 +20,12 @@ type EventRecorder interface {
 // NextEvent applies the given event to the aggregate,
 // increments the event's version, and appends it to the aggregate's
 // uncommitted list of events (if the aggregate implements EventRecorder).
+//
+// If the aggregate implements EventGuarder, the event is checked first
+// and neither applied nor recorded when rejected.
+//
+// A closed aggregate (see ClosableAggregate) rejects the events with an AggregateClosedError,
+// except the stream tombstone recorded by SoftDelete.
 func NextEvent[T comparable](
 	agg EventSourcedAggregate[T],
 	event Event,
@@ -28,6 +34,16 @@ func NextEvent[T comparable](
 		return ErrNilAggregate
 	}
 
+	if IsAggregateClosed(agg) && !IsStreamDeletedEvent(event) {
+		return NewAggregateClosedError(agg.AggregateID(), agg.AggregateName(), event.Name())
+	}
+
+	if g, ok := agg.(EventGuarder); ok {
+		if err := g.GuardEvent(event); err != nil {
+			return err
+		}
+	}
+
 	agg.ApplyEvent(event)
 	if r, ok := agg.(EventRecorder); ok {
 		r.RecordEvent(event)
@@ -54,4 +54,23 @@ func TestNextEvent(t *testing.T) {
 		require.Len(t, agg.AggregateEvents(), 2)
 		require.Equal(t, 2, handlerCalls)
 	})
+
+	t.Run("should not apply nor record an event rejected by a guard", func(t *testing.T) {
+		errRejected := errors.New("rejected")
+		agg.AddEventGuard(func(event domain.Event) error {
+			if event.Name() == "rejected" {
+				return errRejected
+			}
+			return nil
+		})
+
+		err := domain.NextEvent(agg, domain.NewEvent("rejected", domain.CreateEventAggregateRef(agg)))
+		require.ErrorIs(t, err, errRejected)
+		require.Len(t, agg.AggregateEvents(), 2)
+
+		err = domain.NextEvent(agg, domain.NewEvent(eventName, domain.CreateEventAggregateRef(agg)))
+		require.NoError(t, err)
+		require.Len(t, agg.AggregateEvents(), 3)
+		require.Equal(t, 3, handlerCalls)
+	})
 }
@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"context"
+	"maps"
+	"reflect"
+)
+
+// Well-known event metadata keys.
+const (
+	// EventMetadataCausationID is the ID of the message that caused the event.
+	EventMetadataCausationID = "causation_id"
+	// EventMetadataCorrelationID is the ID shared by every message and event of the same flow.
+	EventMetadataCorrelationID = "correlation_id"
+	// EventMetadataActor identifies who triggered the event.
+	EventMetadataActor = "actor"
+)
+
+// MetadataEvent represents an event carrying metadata.
+type MetadataEvent interface {
+	Event
+	// Metadata returns the metadata of the event.
+	Metadata() map[string]string
+}
+
+// EventMetadata returns a copy of the metadata of the given event.
+// It returns nil if the event does not implement MetadataEvent.
+func EventMetadata(event Event) map[string]string {
+	if me, ok := event.(MetadataEvent); ok {
+		return maps.Clone(me.Metadata())
+	}
+	return nil
+}
+
+// EventCausationID returns the causation ID of the given event, if any.
+func EventCausationID(event Event) string {
+	return eventMetadataValue(event, EventMetadataCausationID)
+}
+
+// EventCorrelationID returns the correlation ID of the given event, if any.
+func EventCorrelationID(event Event) string {
+	return eventMetadataValue(event, EventMetadataCorrelationID)
+}
+
+// EventActor returns the actor of the given event, if any.
+func EventActor(event Event) string {
+	return eventMetadataValue(event, EventMetadataActor)
+}
+
+func eventMetadataValue(event Event, key string) string {
+	if me, ok := event.(MetadataEvent); ok {
+		return me.Metadata()[key]
+	}
+	return ""
+}
+
+// MetadataStamper is implemented by the events whose metadata can be completed after their creation.
+// Every type embedding BaseEvent implements it through its pointer.
+type MetadataStamper interface {
+	// StampMetadata adds the given metadata to the event, keeping the keys the event already has.
+	StampMetadata(metadata map[string]string)
+}
+
+// StampEventMetadata returns a copy of the event carrying the given metadata, keeping the keys
+// the event already has. Events that cannot be stamped (see MetadataStamper) are returned as is.
+func StampEventMetadata(event Event, metadata map[string]string) Event {
+	if event == nil || len(metadata) == 0 {
+		return event
+	}
+
+	if stamper, ok := event.(MetadataStamper); ok {
+		stamper.StampMetadata(metadata)
+		return event
+	}
+
+	// events are usually values: stamp a copy through its pointer
+	ptr := reflect.New(reflect.TypeOf(event))
+	ptr.Elem().Set(reflect.ValueOf(event))
+	stamper, ok := ptr.Interface().(MetadataStamper)
+	if !ok {
+		return event
+	}
+	stamper.StampMetadata(metadata)
+
+	stamped, ok := ptr.Elem().Interface().(Event)
+	if !ok {
+		return event
+	}
+	return stamped
+}
+
+// StampEventsMetadataFromContext returns copies of the given events stamped with the
+// event metadata carried by ctx (see ContextWithEventMetadata).
+// The events are returned as is if ctx carries no event metadata.
+func StampEventsMetadataFromContext(ctx context.Context, events []Event) []Event {
+	metadata := EventMetadataFromContext(ctx)
+	if len(metadata) == 0 {
+		return events
+	}
+
+	stamped := make([]Event, len(events))
+	for i, event := range events {
+		stamped[i] = StampEventMetadata(event, metadata)
+	}
+	return stamped
+}
+
+type contextKeyEventMetadata struct{}
+
+// ContextWithEventMetadata returns a copy of ctx carrying the given event metadata,
+// merged over the metadata already present in ctx.
+// Events created with WithEventMetadataFromContext inherit it, and the repositories
+// stamp it on the events they save with ctx.
+func ContextWithEventMetadata(ctx context.Context, metadata map[string]string) context.Context {
+	merged := maps.Clone(EventMetadataFromContext(ctx))
+	if merged == nil {
+		merged = make(map[string]string, len(metadata))
+	}
+	maps.Copy(merged, metadata)
+	return context.WithValue(ctx, contextKeyEventMetadata{}, merged)
+}
+
+// EventMetadataFromContext returns the event metadata carried by ctx, if any.
+func EventMetadataFromContext(ctx context.Context) map[string]string {
+	metadata, _ := ctx.Value(contextKeyEventMetadata{}).(map[string]string)
+	return metadata
+}
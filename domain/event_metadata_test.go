@@ -0,0 +1,84 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+func TestEventMetadata(t *testing.T) {
+	ref := domain.NewEventAggregateReference("order-1", "order", 1)
+
+	t.Run("should set the well-known metadata", func(t *testing.T) {
+		event := domain.NewEvent("order.placed", ref,
+			domain.WithCausationID("cmd-1"),
+			domain.WithCorrelationID("flow-1"),
+			domain.WithActor("alice"),
+			domain.WithEventMetadataKeyValue("tenant", "acme"),
+		)
+
+		assert.Equal(t, "cmd-1", domain.EventCausationID(event))
+		assert.Equal(t, "flow-1", domain.EventCorrelationID(event))
+		assert.Equal(t, "alice", domain.EventActor(event))
+		assert.Equal(t, map[string]string{
+			domain.EventMetadataCausationID:   "cmd-1",
+			domain.EventMetadataCorrelationID: "flow-1",
+			domain.EventMetadataActor:         "alice",
+			"tenant":                          "acme",
+		}, domain.EventMetadata(event))
+	})
+
+	t.Run("should not expose the internal metadata", func(t *testing.T) {
+		event := domain.NewEvent("order.placed", ref, domain.WithActor("alice"))
+		event.Metadata()[domain.EventMetadataActor] = "mallory"
+		assert.Equal(t, "alice", domain.EventActor(event))
+	})
+
+	t.Run("should have no metadata by default", func(t *testing.T) {
+		event := domain.NewEvent("order.placed", ref)
+		assert.Empty(t, domain.EventMetadata(event))
+		assert.Empty(t, domain.EventCausationID(event))
+	})
+
+	t.Run("should inherit the metadata from the context", func(t *testing.T) {
+		ctx := domain.ContextWithEventMetadata(context.Background(), map[string]string{
+			domain.EventMetadataCausationID: "cmd-1",
+		})
+		ctx = domain.ContextWithEventMetadata(ctx, map[string]string{
+			domain.EventMetadataActor: "alice",
+		})
+
+		event := domain.NewEvent("order.placed", ref,
+			domain.WithEventMetadataFromContext(ctx),
+			domain.WithActor("bob"),
+		)
+		assert.Equal(t, "cmd-1", domain.EventCausationID(event))
+		assert.Equal(t, "bob", domain.EventActor(event))
+	})
+
+	t.Run("should stamp the metadata of the context on the events", func(t *testing.T) {
+		ctx := domain.ContextWithEventMetadata(context.Background(), map[string]string{
+			domain.EventMetadataCausationID: "cmd-1",
+			domain.EventMetadataActor:       "alice",
+		})
+
+		original := orderPlaced{BaseEvent: domain.NewEvent("order.placed", ref, domain.WithActor("bob")), Amount: 42}
+		events := domain.StampEventsMetadataFromContext(ctx, []domain.Event{original, domain.NewEvent("order.noted", ref)})
+
+		placed, ok := events[0].(orderPlaced)
+		assert.True(t, ok, "stamped event type = %T, want orderPlaced", events[0])
+		assert.Equal(t, 42, placed.Amount)
+		assert.Equal(t, "cmd-1", domain.EventCausationID(placed))
+		assert.Equal(t, "bob", domain.EventActor(placed), "the event metadata must win")
+		assert.Empty(t, domain.EventCausationID(original), "the original event must be left untouched")
+		assert.Equal(t, "alice", domain.EventActor(events[1]))
+	})
+
+	t.Run("should keep the metadata when upcasting", func(t *testing.T) {
+		event := domain.NewEvent("order.placed", ref, domain.WithCorrelationID("flow-1"))
+		upcasted := domain.UpcastBaseEvent(event, 2)
+		assert.Equal(t, "flow-1", domain.EventCorrelationID(upcasted))
+	})
+}
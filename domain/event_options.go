@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type EventOption func(*BaseEvent)
 
@@ -10,3 +13,50 @@ func WithEventTimestamp(t time.Time) EventOption {
 		e.timestamp = t
 	}
 }
+
+// WithEventRevision sets the schema revision of the event.
+func WithEventRevision(revision int) EventOption {
+	return func(e *BaseEvent) {
+		e.revision = revision
+	}
+}
+
+// WithEventMetadata merges the given metadata into the event metadata.
+func WithEventMetadata(metadata map[string]string) EventOption {
+	return func(e *BaseEvent) {
+		for key, value := range metadata {
+			WithEventMetadataKeyValue(key, value)(e)
+		}
+	}
+}
+
+// WithEventMetadataKeyValue sets a key-value pair in the event metadata.
+func WithEventMetadataKeyValue(key, value string) EventOption {
+	return func(e *BaseEvent) {
+		if e.metadata == nil {
+			e.metadata = make(map[string]string)
+		}
+		e.metadata[key] = value
+	}
+}
+
+// WithEventMetadataFromContext merges the event metadata carried by ctx into the event metadata.
+// See ContextWithEventMetadata.
+func WithEventMetadataFromContext(ctx context.Context) EventOption {
+	return WithEventMetadata(EventMetadataFromContext(ctx))
+}
+
+// WithCausationID sets the ID of the message that caused the event.
+func WithCausationID(id string) EventOption {
+	return WithEventMetadataKeyValue(EventMetadataCausationID, id)
+}
+
+// WithCorrelationID sets the correlation ID of the event.
+func WithCorrelationID(id string) EventOption {
+	return WithEventMetadataKeyValue(EventMetadataCorrelationID, id)
+}
+
+// WithActor sets the actor who triggered the event.
+func WithActor(actor string) EventOption {
+	return WithEventMetadataKeyValue(EventMetadataActor, actor)
+}
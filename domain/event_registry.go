@@ -0,0 +1,263 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrEventAlreadyRegistered = errors.New("event already registered")
+	ErrEventNotRestorable     = errors.New("event type must embed domain.BaseEvent or implement domain.BaseEventRestorer")
+	ErrInvalidEventRevision   = errors.New("event registration revision cannot be negative")
+)
+
+// EventNotRegisteredError is returned when an event has no registration in the EventRegistry.
+type EventNotRegisteredError struct {
+	EventName string
+}
+
+func (e EventNotRegisteredError) Error() string {
+	return fmt.Sprintf("event %s is not registered", e.EventName)
+}
+
+// BaseEventRestorer is implemented by events whose BaseEvent can be restored
+// after their payload has been decoded. Every type embedding BaseEvent implements it.
+type BaseEventRestorer interface {
+	RestoreBaseEvent(BaseEvent)
+}
+
+// EventEncoder encodes the payload of an event using the given codec.
+type EventEncoder func(codec EventPayloadCodec, event Event) ([]byte, error)
+
+// EventDecoder rehydrates an event from its base and the encoded payload.
+type EventDecoder func(codec EventPayloadCodec, base BaseEvent, payload []byte) (Event, error)
+
+type eventRegistration struct {
+	revision int
+	codec    EventPayloadCodec
+	encode   EventEncoder
+	decode   EventDecoder
+}
+
+type eventRegistrationKey struct {
+	name     string
+	revision int
+}
+
+// EventRegistration configures an event registration.
+type EventRegistration func(*eventRegistration)
+
+// WithEventCodec overrides the registry codec for the registered event.
+func WithEventCodec(codec EventPayloadCodec) EventRegistration {
+	return func(r *eventRegistration) {
+		r.codec = codec
+	}
+}
+
+// WithEventRegistrationRevision restricts the registration to the events stored at the given
+// schema revision, so that older revisions are decoded into their own type and handed to the
+// upcasters in their original shape. Registrations without revision, or with a zero revision,
+// handle every revision that has no registration of its own.
+func WithEventRegistrationRevision(revision int) EventRegistration {
+	return func(r *eventRegistration) {
+		r.revision = revision
+	}
+}
+
+// EventRegistry maps event names to Go types so that persistent stores can
+// marshal event payloads and rehydrate the concrete event types.
+//
+// The registry handles the payload only: name, timestamp, revision and the
+// EventAggregateReference are persisted by the stores and passed back as the
+// BaseEvent when decoding, so they are kept intact.
+//
+// Registrations are keyed by event name and revision: events are encoded and decoded
+// with the registration of their revision, falling back to the registration without revision.
+type EventRegistry struct {
+	mu            sync.RWMutex
+	codec         EventPayloadCodec
+	registrations map[eventRegistrationKey]eventRegistration
+}
+
+// NewEventRegistry creates a new EventRegistry using the given default codec.
+// If codec is nil, JSONEventCodec is used.
+func NewEventRegistry(codec EventPayloadCodec) *EventRegistry {
+	if codec == nil {
+		codec = JSONEventCodec{}
+	}
+
+	return &EventRegistry{
+		codec:         codec,
+		registrations: make(map[eventRegistrationKey]eventRegistration),
+	}
+}
+
+// Register registers the encoder and decoder for the given event name.
+func (r *EventRegistry) Register(eventName string, encode EventEncoder, decode EventDecoder, opts ...EventRegistration) error {
+	if eventName == "" {
+		return ErrEventNameEmpty
+	}
+
+	reg := eventRegistration{codec: r.codec, encode: encode, decode: decode}
+	for _, opt := range opts {
+		opt(&reg)
+	}
+
+	if reg.revision < 0 {
+		return fmt.Errorf("%w: %s (revision %d)", ErrInvalidEventRevision, eventName, reg.revision)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := eventRegistrationKey{name: eventName, revision: reg.revision}
+	if _, ok := r.registrations[key]; ok {
+		if reg.revision > 0 {
+			return fmt.Errorf("%w: %s (revision %d)", ErrEventAlreadyRegistered, eventName, reg.revision)
+		}
+		return fmt.Errorf("%w: %s", ErrEventAlreadyRegistered, eventName)
+	}
+
+	r.registrations[key] = reg
+	return nil
+}
+
+// IsRegistered reports whether the given event name is registered, at any revision.
+func (r *EventRegistry) IsRegistered(eventName string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for key := range r.registrations {
+		if key.name == eventName {
+			return true
+		}
+	}
+	return false
+}
+
+// Codec returns the name of the codec used for the given event name.
+func (r *EventRegistry) Codec(eventName string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if reg, ok := r.registrations[eventRegistrationKey{name: eventName}]; ok {
+		return reg.codec.Name()
+	}
+	return r.codec.Name()
+}
+
+// lookup returns the registration of the given event name and revision,
+// falling back to the registration without revision.
+func (r *EventRegistry) lookup(eventName string, revision int) (eventRegistration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if reg, ok := r.registrations[eventRegistrationKey{name: eventName, revision: revision}]; ok {
+		return reg, true
+	}
+	reg, ok := r.registrations[eventRegistrationKey{name: eventName}]
+	return reg, ok
+}
+
+// Encode encodes the payload of the given event.
+// Plain BaseEvent values have no payload and are encoded as nil.
+func (r *EventRegistry) Encode(event Event) ([]byte, error) {
+	reg, ok := r.lookup(event.Name(), EventRevision(event))
+
+	if !ok {
+		if _, isBase := event.(BaseEvent); isBase {
+			return nil, nil
+		}
+		return nil, EventNotRegisteredError{EventName: event.Name()}
+	}
+
+	payload, err := reg.encode(reg.codec, event)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode event %s: %w", event.Name(), err)
+	}
+	return payload, nil
+}
+
+// Decode rehydrates the concrete event registered for base.Name() and base.Revision() from the payload.
+// Unregistered events without payload are returned as the given BaseEvent.
+func (r *EventRegistry) Decode(base BaseEvent, payload []byte) (Event, error) {
+	reg, ok := r.lookup(base.Name(), EventRevision(base))
+
+	if !ok {
+		if len(payload) == 0 {
+			return base, nil
+		}
+		return nil, EventNotRegisteredError{EventName: base.Name()}
+	}
+
+	event, err := reg.decode(reg.codec, base, payload)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode event %s: %w", base.Name(), err)
+	}
+	return event, nil
+}
+
+// RegisterEvent registers the event type E under the given name.
+// E is marshaled as a whole by the codec and its BaseEvent is restored after decoding,
+// so E must embed BaseEvent or implement BaseEventRestorer through its pointer.
+// Register the previous shapes of an event with WithEventRegistrationRevision so that
+// their stored payloads are not decoded into the current E before being upcasted.
+func RegisterEvent[E Event](r *EventRegistry, eventName string, opts ...EventRegistration) error {
+	if _, ok := any(new(E)).(BaseEventRestorer); !ok {
+		return fmt.Errorf("%w: %T", ErrEventNotRestorable, *new(E))
+	}
+
+	encode := func(codec EventPayloadCodec, event Event) ([]byte, error) {
+		typed, ok := event.(E)
+		if !ok {
+			return nil, InvalidEventTypeError{Event: event, Expected: *new(E)}
+		}
+		return codec.Marshal(&typed)
+	}
+
+	decode := func(codec EventPayloadCodec, base BaseEvent, payload []byte) (Event, error) {
+		event := new(E)
+		if len(payload) > 0 {
+			if err := codec.Unmarshal(payload, event); err != nil {
+				return nil, err
+			}
+		}
+		any(event).(BaseEventRestorer).RestoreBaseEvent(base)
+		return *event, nil
+	}
+
+	return r.Register(eventName, encode, decode, opts...)
+}
+
+// RegisterEventPayload registers the event type E under the given name using
+// an explicit payload type P, mirroring the messaging JSON serializer helpers.
+// It is useful for events that do not embed BaseEvent.
+func RegisterEventPayload[E Event, P any](
+	r *EventRegistry,
+	eventName string,
+	toPayload func(E) P,
+	fromPayload func(BaseEvent, P) (E, error),
+	opts ...EventRegistration,
+) error {
+	encode := func(codec EventPayloadCodec, event Event) ([]byte, error) {
+		typed, ok := event.(E)
+		if !ok {
+			return nil, InvalidEventTypeError{Event: event, Expected: *new(E)}
+		}
+		payload := toPayload(typed)
+		return codec.Marshal(&payload)
+	}
+
+	decode := func(codec EventPayloadCodec, base BaseEvent, data []byte) (Event, error) {
+		var payload P
+		if len(data) > 0 {
+			if err := codec.Unmarshal(data, &payload); err != nil {
+				return nil, err
+			}
+		}
+		return fromPayload(base, payload)
+	}
+
+	return r.Register(eventName, encode, decode, opts...)
+}
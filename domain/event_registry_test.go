@@ -0,0 +1,233 @@
+package domain_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+type orderPlaced struct {
+	domain.BaseEvent
+	OrderID string `json:"orderId"`
+	Amount  int    `json:"amount"`
+}
+
+type orderCancelled struct {
+	ref    *domain.EventAggregateReference
+	at     time.Time
+	reason string
+}
+
+func (e orderCancelled) Name() string                                  { return "order.cancelled" }
+func (e orderCancelled) Timestamp() time.Time                          { return e.at }
+func (e orderCancelled) AggregateRef() *domain.EventAggregateReference { return e.ref }
+
+type orderPaid struct {
+	domain.BaseEvent
+	Amount uint64
+}
+
+func (e *orderPaid) MarshalBinary() ([]byte, error) {
+	return binary.BigEndian.AppendUint64(nil, e.Amount), nil
+}
+
+func (e *orderPaid) UnmarshalBinary(data []byte) error {
+	if len(data) != 8 {
+		return errors.New("invalid orderPaid payload")
+	}
+	e.Amount = binary.BigEndian.Uint64(data)
+	return nil
+}
+
+func newOrderRegistry(t *testing.T) *domain.EventRegistry {
+	t.Helper()
+
+	registry := domain.NewEventRegistry(nil)
+	require.NoError(t, domain.RegisterEvent[orderPlaced](registry, "order.placed"))
+	require.NoError(t, domain.RegisterEvent[orderPaid](registry, "order.paid", domain.WithEventCodec(domain.BinaryEventCodec{})))
+	require.NoError(t, domain.RegisterEventPayload(registry, "order.cancelled",
+		func(e orderCancelled) string { return e.reason },
+		func(base domain.BaseEvent, reason string) (orderCancelled, error) {
+			return orderCancelled{ref: base.AggregateRef(), at: base.Timestamp(), reason: reason}, nil
+		},
+	))
+	return registry
+}
+
+func storedBase(event domain.Event) domain.BaseEvent {
+	return domain.NewEvent(
+		event.Name(),
+		event.AggregateRef(),
+		domain.WithEventTimestamp(event.Timestamp()),
+		domain.WithEventRevision(domain.EventRevision(event)),
+	)
+}
+
+func TestEventRegistry_RoundTrip(t *testing.T) {
+	registry := newOrderRegistry(t)
+	ref := domain.NewEventAggregateReference("order-1", "order", 3)
+
+	t.Run("should rehydrate events embedding BaseEvent", func(t *testing.T) {
+		event := orderPlaced{
+			BaseEvent: domain.NewEvent("order.placed", ref, domain.WithEventRevision(2)),
+			OrderID:   "order-1",
+			Amount:    42,
+		}
+
+		payload, err := registry.Encode(event)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"orderId":"order-1","amount":42}`, string(payload))
+
+		decoded, err := registry.Decode(storedBase(event), payload)
+		require.NoError(t, err)
+
+		placed, ok := decoded.(orderPlaced)
+		require.True(t, ok, "decoded event type = %T, want orderPlaced", decoded)
+		assert.Equal(t, event.OrderID, placed.OrderID)
+		assert.Equal(t, event.Amount, placed.Amount)
+		assert.Equal(t, 2, placed.Revision())
+		assert.Same(t, ref, placed.AggregateRef())
+		assert.True(t, event.Timestamp().Equal(placed.Timestamp()))
+	})
+
+	t.Run("should use the codec configured for the event", func(t *testing.T) {
+		event := orderPaid{BaseEvent: domain.NewEvent("order.paid", ref), Amount: 1000}
+
+		payload, err := registry.Encode(event)
+		require.NoError(t, err)
+		assert.Len(t, payload, 8)
+		assert.Equal(t, "binary", registry.Codec("order.paid"))
+		assert.Equal(t, "json", registry.Codec("order.placed"))
+
+		decoded, err := registry.Decode(storedBase(event), payload)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1000), decoded.(orderPaid).Amount)
+		assert.Equal(t, "order.paid", decoded.Name())
+	})
+
+	t.Run("should rehydrate events with explicit payloads", func(t *testing.T) {
+		event := orderCancelled{ref: ref, at: time.Now(), reason: "out of stock"}
+
+		payload, err := registry.Encode(event)
+		require.NoError(t, err)
+		assert.JSONEq(t, `"out of stock"`, string(payload))
+
+		decoded, err := registry.Decode(storedBase(event), payload)
+		require.NoError(t, err)
+		assert.Equal(t, "out of stock", decoded.(orderCancelled).reason)
+		assert.Same(t, ref, decoded.AggregateRef())
+	})
+
+	t.Run("should pass through unregistered base events", func(t *testing.T) {
+		event := domain.NewEvent("order.noted", ref)
+
+		payload, err := registry.Encode(event)
+		require.NoError(t, err)
+		assert.Nil(t, payload)
+
+		decoded, err := registry.Decode(event, payload)
+		require.NoError(t, err)
+		assert.Equal(t, event, decoded)
+	})
+}
+
+type orderPlacedV1 struct {
+	domain.BaseEvent
+	OrderID string `json:"orderId"`
+	Total   int    `json:"total"`
+}
+
+func TestEventRegistry_Revisions(t *testing.T) {
+	registry := newOrderRegistry(t)
+	require.NoError(t, domain.RegisterEvent[orderPlacedV1](registry, "order.placed", domain.WithEventRegistrationRevision(1)))
+
+	upcasters := domain.NewUpcasterRegistry()
+	require.NoError(t, upcasters.Register("order.placed", 1, func(event domain.Event) (domain.Event, error) {
+		v1 := event.(orderPlacedV1)
+		return orderPlaced{
+			BaseEvent: domain.UpcastBaseEvent(v1, 2),
+			OrderID:   v1.OrderID,
+			Amount:    v1.Total,
+		}, nil
+	}))
+
+	ref := domain.NewEventAggregateReference("order-1", "order", 1)
+
+	t.Run("should decode older revisions into their own type", func(t *testing.T) {
+		base := domain.NewEvent("order.placed", ref, domain.WithEventRevision(1))
+		decoded, err := registry.Decode(base, []byte(`{"orderId":"order-1","total":42}`))
+		require.NoError(t, err)
+
+		v1, ok := decoded.(orderPlacedV1)
+		require.True(t, ok, "decoded event type = %T, want orderPlacedV1", decoded)
+		assert.Equal(t, 42, v1.Total)
+
+		upcasted, err := upcasters.Upcast(decoded)
+		require.NoError(t, err)
+		placed, ok := upcasted.(orderPlaced)
+		require.True(t, ok, "upcasted event type = %T, want orderPlaced", upcasted)
+		assert.Equal(t, 42, placed.Amount)
+		assert.Equal(t, 2, placed.Revision())
+	})
+
+	t.Run("should fall back to the registration without revision", func(t *testing.T) {
+		base := domain.NewEvent("order.placed", ref, domain.WithEventRevision(2))
+		decoded, err := registry.Decode(base, []byte(`{"orderId":"order-1","amount":42}`))
+		require.NoError(t, err)
+		assert.Equal(t, 42, decoded.(orderPlaced).Amount)
+	})
+
+	t.Run("should encode events with the registration of their revision", func(t *testing.T) {
+		payload, err := registry.Encode(orderPlacedV1{
+			BaseEvent: domain.NewEvent("order.placed", ref, domain.WithEventRevision(1)),
+			OrderID:   "order-1",
+			Total:     42,
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"orderId":"order-1","total":42}`, string(payload))
+	})
+
+	t.Run("should reject duplicated revisions", func(t *testing.T) {
+		err := domain.RegisterEvent[orderPlacedV1](registry, "order.placed", domain.WithEventRegistrationRevision(1))
+		require.ErrorIs(t, err, domain.ErrEventAlreadyRegistered)
+
+		err = domain.RegisterEvent[orderPlacedV1](registry, "order.placed", domain.WithEventRegistrationRevision(-1))
+		require.ErrorIs(t, err, domain.ErrInvalidEventRevision)
+	})
+}
+
+func TestEventRegistry_Errors(t *testing.T) {
+	registry := newOrderRegistry(t)
+	ref := domain.NewEventAggregateReference("order-1", "order", 1)
+
+	t.Run("should reject duplicated registrations", func(t *testing.T) {
+		err := domain.RegisterEvent[orderPlaced](registry, "order.placed")
+		require.ErrorIs(t, err, domain.ErrEventAlreadyRegistered)
+	})
+
+	t.Run("should reject types without a restorable BaseEvent", func(t *testing.T) {
+		err := domain.RegisterEvent[orderCancelled](registry, "order.other")
+		require.ErrorIs(t, err, domain.ErrEventNotRestorable)
+	})
+
+	t.Run("should reject unregistered events with payload", func(t *testing.T) {
+		_, err := registry.Encode(orderPlaced{BaseEvent: domain.NewEvent("order.unknown", ref)})
+		var notRegisteredErr domain.EventNotRegisteredError
+		require.ErrorAs(t, err, &notRegisteredErr)
+		assert.Equal(t, "order.unknown", notRegisteredErr.EventName)
+
+		_, err = registry.Decode(domain.NewEvent("order.unknown", ref), []byte(`{}`))
+		require.ErrorAs(t, err, &notRegisteredErr)
+	})
+
+	t.Run("should reject events of a different type", func(t *testing.T) {
+		_, err := registry.Encode(domain.NewEvent("order.placed", ref))
+		var invalidTypeErr domain.InvalidEventTypeError
+		require.ErrorAs(t, err, &invalidTypeErr)
+	})
+}
@@ -2,34 +2,52 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 )
 
-var _ EventSourcedRepository[EventSourcedAggregate[any], any] = (*EventSourceRepository[any])(nil)
+var (
+	_ EventSourcedRepository[EventSourcedAggregate[any], any] = (*EventSourceRepository[any])(nil)
+	_ EventHistoryDiffer[EventSourcedAggregate[any], any]     = (*EventSourceRepository[any])(nil)
+)
 
 // EventSourceRepository represents a repository that provides access to an EventStore.
 type EventSourceRepository[ID comparable] struct {
 	eventStore EventStore[ID]
+
+	snapshotStore        SnapshotStore[ID]
+	snapshotPolicy       SnapshotPolicy
+	snapshotErrorHandler func(ctx context.Context, aggregateID ID, err error)
+
+	shredder        *CryptoShredder
+	tenantIsolation bool
 }
 
 // NewEventSourceRepository creates a new EventSourceRepository with the given EventStore.
-func NewEventSourceRepository[ID comparable](eventStore EventStore[ID]) *EventSourceRepository[ID] {
-	return &EventSourceRepository[ID]{
+func NewEventSourceRepository[ID comparable](
+	eventStore EventStore[ID],
+	opts ...EventSourceRepositoryOption[ID],
+) *EventSourceRepository[ID] {
+	repo := &EventSourceRepository[ID]{
 		eventStore: eventStore,
 	}
+
+	for _, opt := range opts {
+		opt(repo)
+	}
+
+	return repo
 }
 
+// Exists reports whether the aggregate has any event in the event store.
+// The events are not read if the event store implements StreamExistenceChecker.
 func (e *EventSourceRepository[ID]) Exists(ctx context.Context, agg EventSourcedAggregate[ID]) (bool, error) {
-	events, err := e.eventStore.RetrieveMany(
-		ctx,
-		agg.AggregateID(),
-		RetrieveEventsFromVersion(1),
-		RetrieveEventsToVersion(1),
-	)
+	exists, err := StreamExists(ctx, e.eventStore, agg.AggregateID())
 	if err != nil {
-		return false, fmt.Errorf("could not retrieve events: %w", err)
+		return false, fmt.Errorf("could not check aggregate stream: %w", err)
 	}
-	return len(events) > 0, nil
+	return exists, nil
 }
 
 func (e *EventSourceRepository[ID]) ExistsVersion(
@@ -49,17 +67,35 @@ func (e *EventSourceRepository[ID]) ExistsVersion(
 	return len(events) > 0, nil
 }
 
+// Load loads the aggregate from the event store.
+//
+// If snapshots are enabled and the aggregate implements Snapshotter, the latest snapshot
+// is restored first and only the events recorded after it are replayed.
+// A StreamDeletedError is returned if the stream, or the snapshot, records a soft-deletion.
 func (e *EventSourceRepository[ID]) Load(ctx context.Context, agg EventSourcedAggregate[ID]) error {
-	events, err := e.eventStore.RetrieveMany(ctx, agg.AggregateID())
+	restored, err := e.restoreSnapshot(ctx, agg)
 	if err != nil {
-		return fmt.Errorf("could not retrieve events: %w", err)
+		return err
 	}
 
-	if len(events) == 0 {
+	var opts []RetrieveEventsOption
+	if restored {
+		opts = append(opts, RetrieveEventsFromVersion(int(agg.AggregateVersion())+1))
+	}
+
+	last, err := e.restoreFromStream(ctx, agg, nil, opts...)
+	if err != nil {
+		return err
+	}
+
+	if last == nil {
+		if restored {
+			return nil
+		}
 		return NewNotFoundError(agg.AggregateID())
 	}
 
-	return RestoreAggregateFromHistory(agg, events)
+	return checkStreamDeletedEvent(agg.AggregateID(), last)
 }
 
 func (e *EventSourceRepository[ID]) LoadVersion(
@@ -67,24 +103,124 @@ func (e *EventSourceRepository[ID]) LoadVersion(
 	agg EventSourcedAggregate[ID],
 	version AggregateVersion,
 ) error {
+	last, err := e.restoreFromStream(ctx, agg, nil, RetrieveEventsToVersion(int(version)))
+	if err != nil {
+		return err
+	}
+
+	if last == nil {
+		return NewNotFoundError(agg.AggregateID())
+	}
+
+	return nil
+}
+
+// LoadAsOf loads the aggregate as it was at the given time.
+// Snapshots are not used, as they do not track the time of the events they cover.
+func (e *EventSourceRepository[ID]) LoadAsOf(ctx context.Context, agg EventSourcedAggregate[ID], asOf time.Time) error {
+	last, err := e.restoreFromStream(ctx, agg, func(event Event) bool {
+		return event.Timestamp().After(asOf)
+	})
+	if err != nil {
+		return err
+	}
+
+	if last == nil {
+		return NewNotFoundError(agg.AggregateID())
+	}
+
+	return checkStreamDeletedEvent(agg.AggregateID(), last)
+}
+
+// restoreFromStream restores the aggregate applying the events streamed from the event store,
+// up to the first event matching stop, if given.
+// It returns the last applied event, or nil if there was no event to apply.
+func (e *EventSourceRepository[ID]) restoreFromStream(
+	ctx context.Context,
+	agg EventSourcedAggregate[ID],
+	stop func(Event) bool,
+	opts ...RetrieveEventsOption,
+) (Event, error) {
+	var last Event
+	events := func(yield func(Event, error) bool) {
+		for event, err := range StreamEvents(ctx, e.eventStore, agg.AggregateID(), opts...) {
+			if err != nil {
+				yield(nil, fmt.Errorf("could not retrieve events: %w", err))
+				return
+			}
+
+			if stop != nil && stop(event) {
+				return
+			}
+
+			last = event
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+
+	err := RestoreAggregateFromStream(agg, events)
+	if last == nil && errors.Is(err, ErrEmptyEventHistory) {
+		return nil, nil
+	}
+	return last, err
+}
+
+// DiffVersions returns the events that turn the aggregate at version from into the aggregate at version to.
+// A zero to means the latest version.
+func (e *EventSourceRepository[ID]) DiffVersions(
+	ctx context.Context,
+	agg EventSourcedAggregate[ID],
+	from, to AggregateVersion,
+) (History, error) {
 	events, err := e.eventStore.RetrieveMany(
 		ctx,
 		agg.AggregateID(),
-		RetrieveEventsToVersion(int(version)),
+		RetrieveEventsFromVersion(int(from)+1),
+		RetrieveEventsToVersion(int(to)),
 	)
 	if err != nil {
-		return fmt.Errorf("could not retrieve events: %w", err)
+		return nil, fmt.Errorf("could not retrieve events: %w", err)
 	}
 
-	if len(events) == 0 {
-		return NewNotFoundError(agg.AggregateID())
+	return History(events).BetweenVersions(from, to), nil
+}
+
+// DiffTimes returns the events that turn the aggregate as of from into the aggregate as of to.
+// A zero to means the latest event.
+func (e *EventSourceRepository[ID]) DiffTimes(
+	ctx context.Context,
+	agg EventSourcedAggregate[ID],
+	from, to time.Time,
+) (History, error) {
+	events, err := e.eventStore.RetrieveMany(ctx, agg.AggregateID())
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve events: %w", err)
 	}
 
-	return RestoreAggregateFromHistory(agg, events)
+	return History(events).BetweenTimes(from, to), nil
 }
 
+// Save saves the aggregate uncommitted events to the event store.
+// The events are stamped with the event metadata carried by ctx, see ContextWithEventMetadata.
+//
+// If snapshots are enabled, a snapshot is taken after the events are committed
+// whenever the snapshot policy requires it. A snapshot failure does not fail the save,
+// as the events are committed; it is reported to the handler set with WithSnapshotErrorHandler.
+//
+// If the event store implements VersionedEventSaver, the aggregate version is used
+// as the expected version and a ConcurrencyConflictError is returned when
+// another writer has appended events in the meantime.
 func (e *EventSourceRepository[ID]) Save(ctx context.Context, agg EventSourcedAggregate[ID]) error {
-	err := e.eventStore.Save(ctx, agg.AggregateEvents())
+	events := StampEventsMetadataFromContext(ctx, agg.AggregateEvents())
+
+	var err error
+	if vs, ok := e.eventStore.(VersionedEventSaver); ok {
+		err = vs.SaveVersioned(ctx, agg.AggregateVersion(), events)
+	} else {
+		err = e.eventStore.Save(ctx, events)
+	}
 	if err != nil {
 		return fmt.Errorf("could not save aggregate events: %w", err)
 	}
@@ -93,14 +229,37 @@ func (e *EventSourceRepository[ID]) Save(ctx context.Context, agg EventSourcedAg
 		c.CommitEvents()
 	}
 
+	if err := e.saveSnapshot(ctx, agg, events); err != nil && e.snapshotErrorHandler != nil {
+		e.snapshotErrorHandler(ctx, agg.AggregateID(), fmt.Errorf("could not save aggregate snapshot: %w", err))
+	}
+
 	return nil
 }
 
+// Search returns the aggregates with at least one event matching the filters of the given criteria.
+// The aggregates are restored from their whole stream, then sorted and paginated.
+// Soft-deleted aggregates are excluded.
+//
+// The aggregates are bare BaseAggregates; use a TypedEventSourcedRepository to get typed aggregates.
 func (e *EventSourceRepository[ID]) Search(
 	ctx context.Context,
 	opts *SearchCriteriaOptions,
 ) ([]EventSourcedAggregate[ID], error) {
-	events, err := e.eventStore.Search(ctx, opts)
+	return searchEventSourcedAggregates(ctx, e, opts, newBaseAggregate[ID])
+}
+
+func searchEventSourcedAggregates[T EventSourcedAggregate[ID], ID comparable](
+	ctx context.Context,
+	e *EventSourceRepository[ID],
+	opts *SearchCriteriaOptions,
+	newAggregate func(id ID, name string) T,
+) ([]T, error) {
+	var filters *SearchCriteriaOptions
+	if opts != nil {
+		filters = opts.Filters()
+	}
+
+	events, err := e.eventStore.Search(ctx, filters)
 	if err != nil {
 		return nil, fmt.Errorf("could not search events: %w", err)
 	}
@@ -109,30 +268,195 @@ func (e *EventSourceRepository[ID]) Search(
 		return nil, nil
 	}
 
-	aggEvents := make(map[ID][]Event)
-	for _, event := range events {
-		id, ok := event.AggregateRef().ID().(ID)
+	return SearchEventSourcedAggregates(events, opts, newAggregate, func(agg T) error {
+		return e.Load(ctx, agg)
+	})
+}
+
+// SoftDelete appends a tombstone event to the stream of the aggregate.
+// The events are kept in the event store, but the aggregate can no longer be loaded
+// and is excluded from the search results.
+func (e *EventSourceRepository[ID]) SoftDelete(ctx context.Context, agg EventSourcedAggregate[ID], opts ...EventOption) error {
+	if tenantID, ok := TenantFromContext(ctx); ok {
+		opts = append(opts, WithEventTenant(tenantID))
+	}
+
+	if err := SoftDelete(agg, opts...); err != nil {
+		return err
+	}
+
+	return e.Save(ctx, agg)
+}
+
+// HardDelete physically removes the stream of the aggregate and its snapshot.
+// The event store must implement StreamDeleter and, if snapshots are enabled,
+// the snapshot store must implement SnapshotDeleter.
+//
+// The snapshot is deleted first, so that a failure never leaves a live snapshot of a deleted stream.
+func (e *EventSourceRepository[ID]) HardDelete(ctx context.Context, agg EventSourcedAggregate[ID]) error {
+	if e.snapshotStore != nil {
+		d, ok := e.snapshotStore.(SnapshotDeleter[ID])
 		if !ok {
-			return nil, fmt.Errorf("invalid aggregate ID type: %T", event.AggregateRef().ID())
+			return fmt.Errorf("could not delete aggregate snapshots: %w: %T does not implement SnapshotDeleter",
+				ErrStreamOperationNotSupported, e.snapshotStore)
+		}
+
+		if err := d.DeleteSnapshot(ctx, agg.AggregateID()); err != nil {
+			return fmt.Errorf("could not delete aggregate snapshots: %w", err)
 		}
-		aggEvents[id] = append(aggEvents[id], event)
 	}
 
-	result := make([]EventSourcedAggregate[ID], 0, len(aggEvents))
-	for id, events := range aggEvents {
-		firstEvent := events[0]
-		agg := NewAggregate(id, firstEvent.AggregateRef().Name())
-		if err != nil {
-			return nil, fmt.Errorf("could not create aggregate: %w", err)
+	if err := deleteStream(ctx, e.eventStore, agg.AggregateID()); err != nil {
+		return fmt.Errorf("could not delete aggregate stream: %w", err)
+	}
+
+	return nil
+}
+
+// Truncate physically removes the events of the aggregate with a version lower than beforeVersion.
+// The event store must implement StreamTruncater.
+//
+// The aggregate must be loaded, and the last event is always kept.
+// As the removed events can no longer be replayed, snapshots must be enabled and the latest snapshot
+// of the aggregate must cover them, i.e. be taken at version beforeVersion-1 or later.
+func (e *EventSourceRepository[ID]) Truncate(ctx context.Context, agg EventSourcedAggregate[ID], beforeVersion AggregateVersion) error {
+	// the last event is always kept so that the stream version is preserved
+	if beforeVersion <= 1 || beforeVersion > agg.AggregateVersion() {
+		return fmt.Errorf("%w: %d", ErrInvalidTruncateVersion, beforeVersion)
+	}
+
+	if e.snapshotStore == nil {
+		return fmt.Errorf("%w: snapshots are not enabled", ErrInvalidTruncateVersion)
+	}
+
+	snapshot, err := e.snapshotStore.LatestSnapshot(ctx, agg.AggregateID())
+	if err != nil && !errors.Is(err, ErrSnapshotNotFound) {
+		return fmt.Errorf("could not retrieve snapshot: %w", err)
+	}
+	if err != nil || snapshot.Deleted || snapshot.AggregateVersion < beforeVersion-1 {
+		return fmt.Errorf("%w: no snapshot covers the events before version %d", ErrInvalidTruncateVersion, beforeVersion)
+	}
+
+	if err := truncateStream(ctx, e.eventStore, agg.AggregateID(), beforeVersion); err != nil {
+		return fmt.Errorf("could not truncate aggregate stream: %w", err)
+	}
+
+	return nil
+}
+
+// Archive moves the stream of a closed aggregate to the archive of the event store,
+// which must implement StreamArchiver; see WithArchive.
+// The aggregate must be loaded, so that it is known to be closed, otherwise ErrAggregateNotClosed is returned.
+func (e *EventSourceRepository[ID]) Archive(ctx context.Context, agg EventSourcedAggregate[ID]) error {
+	if !IsAggregateClosed(agg) {
+		return fmt.Errorf("%w (id: %v)", ErrAggregateNotClosed, agg.AggregateID())
+	}
+
+	if err := archiveStream(ctx, e.eventStore, agg.AggregateID()); err != nil {
+		return fmt.Errorf("could not archive aggregate stream: %w", err)
+	}
+
+	return nil
+}
+
+func (e *EventSourceRepository[ID]) snapshotsEnabled(agg EventSourcedAggregate[ID]) bool {
+	if e.snapshotStore == nil {
+		return false
+	}
+
+	_, ok := agg.(Snapshotter)
+	return ok
+}
+
+func (e *EventSourceRepository[ID]) restoreSnapshot(ctx context.Context, agg EventSourcedAggregate[ID]) (bool, error) {
+	if !e.snapshotsEnabled(agg) {
+		return false, nil
+	}
+
+	snapshot, err := e.snapshotStore.LatestSnapshot(ctx, agg.AggregateID())
+	if errors.Is(err, ErrSnapshotNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not retrieve snapshot: %w", err)
+	}
+
+	// snapshots do not carry the tenant, the one of the stream is checked instead
+	if e.tenantIsolation {
+		if err := checkStreamTenant(ctx, e.eventStore, agg.AggregateID()); err != nil {
+			return false, err
+		}
+	}
+
+	if snapshot.Deleted {
+		return false, NewStreamDeletedError(agg.AggregateID(), snapshot.AggregateVersion)
+	}
+
+	if snapshot.DataSubjectID != "" {
+		if e.shredder == nil {
+			return false, fmt.Errorf("could not restore encrypted snapshot: crypto shredding is not enabled")
 		}
 
-		err = RestoreAggregateFromHistory(agg, events)
+		snapshot, err = e.shredder.DecryptSnapshot(ctx, snapshot)
+		if errors.Is(err, ErrKeyShredded) || errors.Is(err, ErrKeyNotFound) {
+			// the state is shredded: the aggregate is restored from its events instead
+			return false, nil
+		}
 		if err != nil {
-			return nil, fmt.Errorf("could not restore aggregate from history: %w", err)
+			return false, fmt.Errorf("could not decrypt snapshot: %w", err)
 		}
+	}
+
+	if err := RestoreAggregateFromSnapshot(agg, snapshot); err != nil {
+		return false, fmt.Errorf("could not restore aggregate from snapshot: %w", err)
+	}
+
+	return true, nil
+}
 
-		result = append(result, agg)
+// saveSnapshot takes a snapshot of the aggregate after the given events are saved, if the policy requires it.
+// No state is ever snapshotted at a tombstone: the snapshot is replaced by a deleted snapshot instead.
+func (e *EventSourceRepository[ID]) saveSnapshot(ctx context.Context, agg EventSourcedAggregate[ID], events []Event) error {
+	if !e.snapshotsEnabled(agg) {
+		return nil
+	}
+
+	if len(events) > 0 && IsStreamDeletedEvent(events[len(events)-1]) {
+		return e.snapshotStore.SaveSnapshot(ctx, NewDeletedSnapshot(agg))
+	}
+
+	if e.snapshotPolicy == nil {
+		return nil
+	}
+
+	state := SnapshotPolicyState{
+		AggregateVersion: agg.AggregateVersion(),
+		Now:              time.Now(),
+	}
+
+	last, err := e.snapshotStore.LatestSnapshot(ctx, agg.AggregateID())
+	switch {
+	case err == nil:
+		state.LastSnapshot = &last
+	case !errors.Is(err, ErrSnapshotNotFound):
+		return err
+	}
+
+	if !e.snapshotPolicy.ShouldSnapshot(state) {
+		return nil
+	}
+
+	snapshot, err := TakeSnapshot(agg)
+	if err != nil {
+		return err
+	}
+
+	if pda, ok := agg.(PersonalDataAggregate); ok && e.shredder != nil && pda.DataSubjectID() != "" {
+		snapshot, err = e.shredder.EncryptSnapshot(ctx, snapshot, pda.DataSubjectID())
+		if err != nil {
+			return err
+		}
 	}
 
-	return result, nil
+	return e.snapshotStore.SaveSnapshot(ctx, snapshot)
 }
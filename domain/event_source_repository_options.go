@@ -0,0 +1,68 @@
+package domain
+
+import "context"
+
+// EventSourceRepositoryOption configures an EventSourceRepository.
+type EventSourceRepositoryOption[ID comparable] func(*EventSourceRepository[ID])
+
+// WithSnapshots enables aggregate snapshots on the repository.
+// Snapshots are taken on Save, according to the given policy, for aggregates
+// implementing the Snapshotter interface, and used on Load to replay only the tail of the stream.
+func WithSnapshots[ID comparable](store SnapshotStore[ID], policy SnapshotPolicy) EventSourceRepositoryOption[ID] {
+	return func(r *EventSourceRepository[ID]) {
+		r.snapshotStore = store
+		r.snapshotPolicy = policy
+	}
+}
+
+// WithSnapshotErrorHandler sets the handler of the snapshots that could not be taken on Save.
+// The saved events are committed anyway, so Save does not fail; without handler, the errors are dropped.
+func WithSnapshotErrorHandler[ID comparable](handler func(ctx context.Context, aggregateID ID, err error)) EventSourceRepositoryOption[ID] {
+	return func(r *EventSourceRepository[ID]) {
+		r.snapshotErrorHandler = handler
+	}
+}
+
+// WithUpcasters transforms the events retrieved from the event store into their
+// latest shape, using the given registry, before they are applied to the aggregates.
+func WithUpcasters[ID comparable](upcasters *UpcasterRegistry) EventSourceRepositoryOption[ID] {
+	return func(r *EventSourceRepository[ID]) {
+		r.eventStore = NewUpcastingEventStore(r.eventStore, upcasters)
+	}
+}
+
+// WithCryptoShredding encrypts the personal data of the saved events, and decrypts it on retrieval,
+// with the keys of their data subjects managed by the given KeyStore.
+// See PersonalDataEvent.
+//
+// The snapshot state of the aggregates implementing PersonalDataAggregate is encrypted as well,
+// and the aggregates whose snapshot can no longer be decrypted are restored from their events.
+//
+// When combined with WithUpcasters, it must be applied first so that the events are decrypted before being upcasted.
+func WithCryptoShredding[ID comparable](keys KeyStore) EventSourceRepositoryOption[ID] {
+	return func(r *EventSourceRepository[ID]) {
+		r.shredder = NewCryptoShredder(keys)
+		r.eventStore = NewCryptoShreddingEventStore(r.eventStore, r.shredder)
+	}
+}
+
+// WithArchive enables the archival of the streams of closed aggregates to the given archive store,
+// see EventSourceRepository.Archive and ArchivingEventStore. The event store must implement StreamDeleter.
+//
+// It must be applied before the other options decorating the event store, so that the events are archived as stored.
+func WithArchive[ID comparable](archive EventStore[ID]) EventSourceRepositoryOption[ID] {
+	return func(r *EventSourceRepository[ID]) {
+		r.eventStore = NewArchivingEventStore(r.eventStore, archive)
+	}
+}
+
+// WithTenantIsolation isolates the tenants of the repository, given contexts scoped
+// with ContextWithTenant. See TenantEventStore.
+//
+// The aggregates restored from a snapshot are checked against the tenant of their stream as well.
+func WithTenantIsolation[ID comparable]() EventSourceRepositoryOption[ID] {
+	return func(r *EventSourceRepository[ID]) {
+		r.eventStore = NewTenantEventStore(r.eventStore)
+		r.tenantIsolation = true
+	}
+}
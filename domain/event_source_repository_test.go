@@ -0,0 +1,217 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xfrr/go-cqrsify/domain"
+	"github.com/xfrr/go-cqrsify/domain/inmemory"
+)
+
+type eventStoreMock struct {
+	version   domain.AggregateVersion
+	events    []domain.Event
+	retrieved int
+}
+
+func (m *eventStoreMock) Save(ctx context.Context, events []domain.Event) error {
+	return m.SaveVersioned(ctx, m.version, events)
+}
+
+func (m *eventStoreMock) SaveVersioned(_ context.Context, expected domain.AggregateVersion, events []domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	if expected != m.version {
+		return domain.NewConcurrencyConflictError(events[0].AggregateRef().ID(), expected, m.version)
+	}
+
+	m.events = append(m.events, events...)
+	m.version += domain.AggregateVersion(len(events))
+	return nil
+}
+
+func (m *eventStoreMock) RetrieveMany(_ context.Context, id string, opts ...domain.RetrieveEventsOption) ([]domain.Event, error) {
+	options := domain.RetrieveEventsOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var events []domain.Event
+	for _, evt := range m.events {
+		ref := evt.AggregateRef()
+		if ref.ID() != id {
+			continue
+		}
+		if options.FromVersion > 0 && int(ref.Version()) < options.FromVersion {
+			continue
+		}
+		if options.ToVersion > 0 && int(ref.Version()) > options.ToVersion {
+			continue
+		}
+		events = append(events, evt)
+	}
+
+	m.retrieved += len(events)
+	return events, nil
+}
+
+func (m *eventStoreMock) Search(_ context.Context, _ *domain.SearchCriteriaOptions) ([]domain.Event, error) {
+	return m.events, nil
+}
+
+func (m *eventStoreMock) ReadAll(_ context.Context, from domain.GlobalPosition, batchSize int, _ ...domain.ReadAllOption) ([]domain.StoredEvent, error) {
+	var stored []domain.StoredEvent
+	for i, evt := range m.events {
+		position := domain.GlobalPosition(i + 1)
+		if position < from {
+			continue
+		}
+		if batchSize > 0 && len(stored) == batchSize {
+			break
+		}
+		stored = append(stored, domain.StoredEvent{Position: position, Event: evt})
+	}
+	return stored, nil
+}
+
+type counterAggregate struct {
+	*domain.BaseAggregate[string]
+
+	count int
+}
+
+func newCounterAggregate(id string) *counterAggregate {
+	agg := &counterAggregate{BaseAggregate: domain.NewAggregate(id, "counter")}
+	agg.HandleEvent("counter.incremented", func(_ domain.Event) error {
+		agg.count++
+		return nil
+	})
+	return agg
+}
+
+func (a *counterAggregate) increment(t *testing.T) {
+	require.NoError(t, domain.NextEvent(a, domain.NewEvent("counter.incremented", domain.CreateEventAggregateRef(a))))
+}
+
+func (a *counterAggregate) SnapshotState() ([]byte, error) {
+	return []byte(strconv.Itoa(a.count)), nil
+}
+
+func (a *counterAggregate) RestoreSnapshotState(state []byte) error {
+	count, err := strconv.Atoi(string(state))
+	if err != nil {
+		return err
+	}
+	a.count = count
+	return nil
+}
+
+func TestEventSourceRepository_Save_ConcurrencyConflict(t *testing.T) {
+	ctx := context.Background()
+	store := &eventStoreMock{}
+	sut := domain.NewEventSourceRepository[string](store)
+
+	writer1 := domain.NewAggregate("agg-1", "test")
+	writer2 := domain.NewAggregate("agg-1", "test")
+
+	require.NoError(t, domain.NextEvent(writer1, domain.NewEvent("test.event", domain.CreateEventAggregateRef(writer1))))
+	require.NoError(t, sut.Save(ctx, writer1))
+	require.Equal(t, domain.AggregateVersion(1), writer1.AggregateVersion())
+
+	require.NoError(t, domain.NextEvent(writer2, domain.NewEvent("test.event", domain.CreateEventAggregateRef(writer2))))
+	err := sut.Save(ctx, writer2)
+	require.ErrorIs(t, err, domain.ErrConcurrencyConflict)
+
+	var conflictErr domain.ConcurrencyConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	require.Equal(t, domain.AggregateVersion(0), conflictErr.ExpectedVersion)
+	require.Equal(t, domain.AggregateVersion(1), conflictErr.ActualVersion)
+	require.Len(t, store.events, 1)
+}
+
+func TestEventSourceRepository_Snapshots(t *testing.T) {
+	ctx := context.Background()
+	store := &eventStoreMock{}
+	snapshots := inmemory.NewSnapshotStore[string]()
+	sut := domain.NewEventSourceRepository(
+		store,
+		domain.WithSnapshots[string](snapshots, domain.SnapshotEveryNEvents(3)),
+	)
+
+	agg := newCounterAggregate("agg-1")
+	for range 4 {
+		agg.increment(t)
+	}
+	require.NoError(t, sut.Save(ctx, agg))
+
+	snapshot, err := snapshots.LatestSnapshot(ctx, "agg-1")
+	require.NoError(t, err)
+	require.Equal(t, domain.AggregateVersion(4), snapshot.AggregateVersion)
+
+	agg.increment(t)
+	require.NoError(t, sut.Save(ctx, agg))
+
+	t.Run("should load from snapshot and replay only the tail", func(t *testing.T) {
+		store.retrieved = 0
+		loaded := newCounterAggregate("agg-1")
+		require.NoError(t, sut.Load(ctx, loaded))
+		require.Equal(t, 5, loaded.count)
+		require.Equal(t, domain.AggregateVersion(5), loaded.AggregateVersion())
+		require.Equal(t, 1, store.retrieved)
+	})
+
+	t.Run("should load from snapshot without tail events", func(t *testing.T) {
+		snapshot, err := domain.TakeSnapshot[string](agg)
+		require.NoError(t, err)
+		require.NoError(t, snapshots.SaveSnapshot(ctx, snapshot))
+
+		loaded := newCounterAggregate("agg-1")
+		require.NoError(t, sut.Load(ctx, loaded))
+		require.Equal(t, 5, loaded.count)
+		require.Equal(t, domain.AggregateVersion(5), loaded.AggregateVersion())
+	})
+
+	t.Run("should load from full history without snapshot", func(t *testing.T) {
+		require.NoError(t, snapshots.DeleteSnapshot(ctx, "agg-1"))
+
+		loaded := newCounterAggregate("agg-1")
+		require.NoError(t, sut.Load(ctx, loaded))
+		require.Equal(t, 5, loaded.count)
+		require.Equal(t, domain.AggregateVersion(5), loaded.AggregateVersion())
+	})
+}
+
+type failingSnapshotStore struct {
+	*inmemory.SnapshotStore[string]
+}
+
+func (failingSnapshotStore) SaveSnapshot(context.Context, domain.Snapshot) error {
+	return errors.New("snapshot store unavailable")
+}
+
+func TestEventSourceRepository_Save_SnapshotFailure(t *testing.T) {
+	ctx := context.Background()
+	store := &eventStoreMock{}
+
+	var reported error
+	sut := domain.NewEventSourceRepository(
+		store,
+		domain.WithSnapshots[string](failingSnapshotStore{inmemory.NewSnapshotStore[string]()}, domain.SnapshotEveryNEvents(1)),
+		domain.WithSnapshotErrorHandler(func(_ context.Context, id string, err error) {
+			require.Equal(t, "agg-1", id)
+			reported = err
+		}),
+	)
+
+	agg := newCounterAggregate("agg-1")
+	agg.increment(t)
+	require.NoError(t, sut.Save(ctx, agg))
+	require.ErrorContains(t, reported, "snapshot store unavailable")
+	require.Len(t, store.events, 1)
+	require.Empty(t, agg.AggregateEvents())
+}
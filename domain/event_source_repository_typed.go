@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	_ SearchableEventSourcedRepository[EventSourcedAggregate[any], any] = (*TypedEventSourcedRepository[EventSourcedAggregate[any], any])(nil)
+	_ EventHistoryDiffer[EventSourcedAggregate[any], any]               = (*TypedEventSourcedRepository[EventSourcedAggregate[any], any])(nil)
+)
+
+// AggregateFactory creates a new, empty aggregate with the given ID.
+// The returned aggregate must be ready to apply its events, i.e. have its event handlers registered.
+type AggregateFactory[T EventSourcedAggregate[ID], ID comparable] func(id ID) T
+
+// TypedEventSourcedRepository is an EventSourceRepository working with a concrete aggregate type.
+// The aggregates are created with an AggregateFactory, so that Get and Search return
+// fully typed aggregates instead of bare BaseAggregates.
+type TypedEventSourcedRepository[T EventSourcedAggregate[ID], ID comparable] struct {
+	repo    *EventSourceRepository[ID]
+	factory AggregateFactory[T, ID]
+}
+
+// NewTypedEventSourcedRepository creates a new TypedEventSourcedRepository with the given EventStore
+// and aggregate factory. The options are the ones of NewEventSourceRepository.
+func NewTypedEventSourcedRepository[T EventSourcedAggregate[ID], ID comparable](
+	eventStore EventStore[ID],
+	factory AggregateFactory[T, ID],
+	opts ...EventSourceRepositoryOption[ID],
+) *TypedEventSourcedRepository[T, ID] {
+	return &TypedEventSourcedRepository[T, ID]{
+		repo:    NewEventSourceRepository(eventStore, opts...),
+		factory: factory,
+	}
+}
+
+// Get creates the aggregate with the given ID using the factory and loads it from the event store.
+func (r *TypedEventSourcedRepository[T, ID]) Get(ctx context.Context, id ID) (T, error) {
+	agg := r.factory(id)
+	if err := r.repo.Load(ctx, agg); err != nil {
+		var zero T
+		return zero, err
+	}
+	return agg, nil
+}
+
+// GetVersion creates the aggregate with the given ID using the factory and loads it up to the given version.
+func (r *TypedEventSourcedRepository[T, ID]) GetVersion(ctx context.Context, id ID, version AggregateVersion) (T, error) {
+	agg := r.factory(id)
+	if err := r.repo.LoadVersion(ctx, agg, version); err != nil {
+		var zero T
+		return zero, err
+	}
+	return agg, nil
+}
+
+// Exists reports whether the aggregate has any event in the event store. See EventSourceRepository.Exists.
+func (r *TypedEventSourcedRepository[T, ID]) Exists(ctx context.Context, agg T) (bool, error) {
+	return r.repo.Exists(ctx, agg)
+}
+
+// ExistsVersion reports whether the stream of the aggregate holds the event of the given version.
+func (r *TypedEventSourcedRepository[T, ID]) ExistsVersion(ctx context.Context, agg T, version AggregateVersion) (bool, error) {
+	return r.repo.ExistsVersion(ctx, agg, version)
+}
+
+// Load loads the given aggregate from the event store. See EventSourceRepository.Load.
+func (r *TypedEventSourcedRepository[T, ID]) Load(ctx context.Context, agg T) error {
+	return r.repo.Load(ctx, agg)
+}
+
+// LoadVersion loads the given aggregate up to the given version, ignoring the snapshots.
+// See EventSourceRepository.LoadVersion.
+func (r *TypedEventSourcedRepository[T, ID]) LoadVersion(ctx context.Context, agg T, version AggregateVersion) error {
+	return r.repo.LoadVersion(ctx, agg, version)
+}
+
+// LoadAsOf loads the given aggregate as it was at the given time. See EventSourceRepository.LoadAsOf.
+func (r *TypedEventSourcedRepository[T, ID]) LoadAsOf(ctx context.Context, agg T, asOf time.Time) error {
+	return r.repo.LoadAsOf(ctx, agg, asOf)
+}
+
+// Save saves the aggregate uncommitted events to the event store. See EventSourceRepository.Save.
+func (r *TypedEventSourcedRepository[T, ID]) Save(ctx context.Context, agg T) error {
+	return r.repo.Save(ctx, agg)
+}
+
+// Search returns the aggregates with at least one event matching the filters of the given criteria,
+// created with the factory and restored from their whole stream. See EventSourceRepository.Search.
+func (r *TypedEventSourcedRepository[T, ID]) Search(ctx context.Context, opts *SearchCriteriaOptions) ([]T, error) {
+	return searchEventSourcedAggregates(ctx, r.repo, opts, func(id ID, _ string) T {
+		return r.factory(id)
+	})
+}
+
+// DiffVersions returns the events that turn the aggregate at version from into the aggregate at version to.
+// See EventSourceRepository.DiffVersions.
+func (r *TypedEventSourcedRepository[T, ID]) DiffVersions(ctx context.Context, agg T, from, to AggregateVersion) (History, error) {
+	return r.repo.DiffVersions(ctx, agg, from, to)
+}
+
+// DiffTimes returns the events that turn the aggregate as of from into the aggregate as of to.
+// See EventSourceRepository.DiffTimes.
+func (r *TypedEventSourcedRepository[T, ID]) DiffTimes(ctx context.Context, agg T, from, to time.Time) (History, error) {
+	return r.repo.DiffTimes(ctx, agg, from, to)
+}
+
+// SoftDelete appends a tombstone event to the stream of the aggregate. See EventSourceRepository.SoftDelete.
+func (r *TypedEventSourcedRepository[T, ID]) SoftDelete(ctx context.Context, agg T, opts ...EventOption) error {
+	return r.repo.SoftDelete(ctx, agg, opts...)
+}
+
+// HardDelete physically removes the stream of the aggregate. See EventSourceRepository.HardDelete.
+func (r *TypedEventSourcedRepository[T, ID]) HardDelete(ctx context.Context, agg T) error {
+	return r.repo.HardDelete(ctx, agg)
+}
+
+// Archive moves the stream of the closed aggregate to the archive. See EventSourceRepository.Archive.
+func (r *TypedEventSourcedRepository[T, ID]) Archive(ctx context.Context, agg T) error {
+	return r.repo.Archive(ctx, agg)
+}
+
+// Truncate physically removes the head of the stream of the aggregate. See EventSourceRepository.Truncate.
+func (r *TypedEventSourcedRepository[T, ID]) Truncate(ctx context.Context, agg T, beforeVersion AggregateVersion) error {
+	return r.repo.Truncate(ctx, agg, beforeVersion)
+}
@@ -0,0 +1,84 @@
+package domain_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xfrr/go-cqrsify/domain"
+	"github.com/xfrr/go-cqrsify/domain/filestore"
+)
+
+type accountAggregate struct {
+	*domain.BaseAggregate[int]
+
+	balance int
+}
+
+func newAccountAggregate(id int) *accountAggregate {
+	agg := &accountAggregate{BaseAggregate: domain.NewAggregate(id, "account")}
+	agg.HandleEvent("account.deposited", func(_ domain.Event) error {
+		agg.balance += 10
+		return nil
+	})
+	return agg
+}
+
+func (a *accountAggregate) deposit(t *testing.T, times int) {
+	t.Helper()
+
+	for range times {
+		require.NoError(t, domain.NextEvent(a, domain.NewEvent("account.deposited", domain.CreateEventAggregateRef(a))))
+	}
+}
+
+func TestTypedEventSourcedRepository(t *testing.T) {
+	ctx := context.Background()
+	store, err := filestore.Open(filestore.Config[int]{
+		Dir:              t.TempDir(),
+		ParseAggregateID: strconv.Atoi,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	sut := domain.NewTypedEventSourcedRepository(store, newAccountAggregate)
+
+	for id, deposits := range map[int]int{1: 1, 2: 3} {
+		agg := newAccountAggregate(id)
+		agg.deposit(t, deposits)
+		require.NoError(t, sut.Save(ctx, agg))
+	}
+
+	t.Run("should get typed aggregates", func(t *testing.T) {
+		agg, err := sut.Get(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 2, agg.AggregateID())
+		assert.Equal(t, 30, agg.balance)
+		assert.Equal(t, domain.AggregateVersion(3), agg.AggregateVersion())
+	})
+
+	t.Run("should get typed aggregates at a version", func(t *testing.T) {
+		agg, err := sut.GetVersion(ctx, 2, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 20, agg.balance)
+	})
+
+	t.Run("should fail to get unknown aggregates", func(t *testing.T) {
+		agg, err := sut.Get(ctx, 3)
+		require.ErrorAs(t, err, &domain.NotFoundError[int]{})
+		assert.Nil(t, agg)
+	})
+
+	t.Run("should search typed aggregates", func(t *testing.T) {
+		aggs, err := sut.Search(ctx, domain.SearchCriteria().WithSearchSort(domain.SearchSortByVersion, true))
+		require.NoError(t, err)
+		require.Len(t, aggs, 2)
+		assert.Equal(t, 2, aggs[0].AggregateID())
+		assert.Equal(t, 30, aggs[0].balance)
+		assert.Equal(t, 1, aggs[1].AggregateID())
+		assert.Equal(t, 10, aggs[1].balance)
+	})
+}
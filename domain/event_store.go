@@ -3,6 +3,9 @@ package domain
 import "context"
 
 // EventStore represents an event store that can save and retrieve events.
+//
+// Event stores must keep the event metadata, which holds the tenant owning each event.
+// Tenant isolation is enforced by wrapping the store in a TenantEventStore, see WithTenantIsolation.
 type EventStore[ID comparable] interface {
 	EventSaver
 	EventRetriever[ID]
@@ -24,6 +27,7 @@ type EventRetriever[ID comparable] interface {
 // EventSearcher represents an event store that can search for events.
 type EventSearcher interface {
 	// Search searches for events in the event store that match the given criteria.
+	// The events are sorted and paginated as requested by the criteria; see SearchStoredEvents.
 	Search(ctx context.Context, criteria *SearchCriteriaOptions) ([]Event, error)
 }
 
@@ -59,3 +63,12 @@ type RetrieveEventsOptions struct {
 	// BatchSize specifies the maximum number of events to retrieve in a single batch.
 	BatchSize int
 }
+
+// VersionedEventSaver represents an event store that can save events
+// enforcing optimistic concurrency on the aggregate version.
+type VersionedEventSaver interface {
+	// SaveVersioned saves the given events only if the current aggregate version
+	// in the event store matches the expected version.
+	// It returns a ConcurrencyConflictError if the versions differ.
+	SaveVersioned(ctx context.Context, expectedVersion AggregateVersion, events []Event) error
+}
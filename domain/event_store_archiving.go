@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+)
+
+var _ interface {
+	EventStore[any]
+	VersionedEventSaver
+	EventStreamer[any]
+	StreamExistenceChecker[any]
+	StreamArchiver[any]
+	StreamDeleter[any]
+	StreamTruncater[any]
+} = (*ArchivingEventStore[any])(nil)
+
+// ArchivingEventStore decorates an EventStore moving the streams of closed aggregates
+// to an archive store, so that the main store only keeps the live streams.
+//
+// The events of an archived stream are retrieved from the archive, while the searches
+// and the global stream read the main store only.
+type ArchivingEventStore[ID comparable] struct {
+	EventStore[ID]
+
+	archive EventStore[ID]
+}
+
+// NewArchivingEventStore creates a new ArchivingEventStore wrapping the given EventStore.
+// The main store must implement StreamDeleter.
+func NewArchivingEventStore[ID comparable](store EventStore[ID], archive EventStore[ID]) *ArchivingEventStore[ID] {
+	return &ArchivingEventStore[ID]{
+		EventStore: store,
+		archive:    archive,
+	}
+}
+
+// Save saves the given events in the main store.
+// It returns an AggregateClosedError if the stream of the aggregate has been archived.
+func (s *ArchivingEventStore[ID]) Save(ctx context.Context, events []Event) error {
+	if err := s.checkNotArchived(ctx, events); err != nil {
+		return err
+	}
+	return s.EventStore.Save(ctx, events)
+}
+
+// SaveVersioned saves the given events using the underlying store concurrency check if available.
+// It returns an AggregateClosedError if the stream of the aggregate has been archived.
+func (s *ArchivingEventStore[ID]) SaveVersioned(ctx context.Context, expectedVersion AggregateVersion, events []Event) error {
+	if err := s.checkNotArchived(ctx, events); err != nil {
+		return err
+	}
+	if vs, ok := s.EventStore.(VersionedEventSaver); ok {
+		return vs.SaveVersioned(ctx, expectedVersion, events)
+	}
+	return s.EventStore.Save(ctx, events)
+}
+
+// checkNotArchived rejects appending the given events to an archived stream,
+// as it would start a new stream in the main store shadowing the archived one.
+func (s *ArchivingEventStore[ID]) checkNotArchived(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ref := events[0].AggregateRef()
+	aggregateID, ok := ref.ID().(ID)
+	if !ok {
+		return nil
+	}
+
+	archived, err := StreamExists(ctx, s.archive, aggregateID)
+	if err != nil {
+		return fmt.Errorf("could not check archived stream: %w", err)
+	}
+	if archived {
+		return NewAggregateClosedError(aggregateID, ref.Name(), events[0].Name())
+	}
+	return nil
+}
+
+// RetrieveMany retrieves the events of the given aggregate from the main store,
+// or from the archive if the stream has been archived.
+func (s *ArchivingEventStore[ID]) RetrieveMany(ctx context.Context, aggregateID ID, opts ...RetrieveEventsOption) ([]Event, error) {
+	events, err := s.EventStore.RetrieveMany(ctx, aggregateID, opts...)
+	if err != nil || len(events) > 0 {
+		return events, err
+	}
+
+	return s.archive.RetrieveMany(ctx, aggregateID, opts...)
+}
+
+// StreamEvents streams the events of the given aggregate from the main store,
+// or from the archive if the stream has been archived.
+func (s *ArchivingEventStore[ID]) StreamEvents(ctx context.Context, aggregateID ID, opts ...RetrieveEventsOption) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		found := false
+		for event, err := range StreamEvents(ctx, s.EventStore, aggregateID, opts...) {
+			found = true
+			if !yield(event, err) || err != nil {
+				return
+			}
+		}
+		if found {
+			return
+		}
+
+		for event, err := range StreamEvents(ctx, s.archive, aggregateID, opts...) {
+			if !yield(event, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// StreamExists reports whether the given aggregate has any event in the main store or in the archive.
+func (s *ArchivingEventStore[ID]) StreamExists(ctx context.Context, aggregateID ID) (bool, error) {
+	exists, err := StreamExists(ctx, s.EventStore, aggregateID)
+	if err != nil || exists {
+		return exists, err
+	}
+	return StreamExists(ctx, s.archive, aggregateID)
+}
+
+// ArchiveStream copies the events of the given aggregate to the archive, as stored,
+// and deletes them from the main store.
+// Archiving an already archived stream does nothing.
+func (s *ArchivingEventStore[ID]) ArchiveStream(ctx context.Context, aggregateID ID) error {
+	events, err := s.EventStore.RetrieveMany(ctx, aggregateID)
+	if err != nil {
+		return fmt.Errorf("could not retrieve events: %w", err)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	// the stream may have been copied by a previous attempt failing to delete it
+	archived, err := StreamExists(ctx, s.archive, aggregateID)
+	if err != nil {
+		return fmt.Errorf("could not check archived stream: %w", err)
+	}
+	if !archived {
+		if err := s.archive.Save(ctx, events); err != nil {
+			return fmt.Errorf("could not save archived events: %w", err)
+		}
+	}
+
+	return deleteStream(ctx, s.EventStore, aggregateID)
+}
+
+// DeleteStream deletes the stream from the main store and, if the archive implements StreamDeleter, from the archive.
+func (s *ArchivingEventStore[ID]) DeleteStream(ctx context.Context, aggregateID ID) error {
+	if err := deleteStream(ctx, s.EventStore, aggregateID); err != nil {
+		return err
+	}
+
+	if err := deleteStream(ctx, s.archive, aggregateID); err != nil && !errors.Is(err, ErrStreamOperationNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// TruncateStream truncates the stream using the underlying store if it implements StreamTruncater.
+func (s *ArchivingEventStore[ID]) TruncateStream(ctx context.Context, aggregateID ID, beforeVersion AggregateVersion) error {
+	return truncateStream(ctx, s.EventStore, aggregateID, beforeVersion)
+}
@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+var _ interface {
+	EventStore[any]
+	VersionedEventSaver
+	EventStreamer[any]
+	EventStreamReader
+	StreamExistenceChecker[any]
+	StreamArchiver[any]
+	StreamDeleter[any]
+	StreamTruncater[any]
+} = (*CryptoShreddingEventStore[any])(nil)
+
+// CryptoShreddingEventStore decorates an EventStore encrypting the personal data
+// of the saved events and decrypting it on retrieval using a CryptoShredder.
+type CryptoShreddingEventStore[ID comparable] struct {
+	EventStore[ID]
+
+	shredder *CryptoShredder
+}
+
+// NewCryptoShreddingEventStore creates a new CryptoShreddingEventStore wrapping the given EventStore.
+func NewCryptoShreddingEventStore[ID comparable](store EventStore[ID], shredder *CryptoShredder) *CryptoShreddingEventStore[ID] {
+	return &CryptoShreddingEventStore[ID]{
+		EventStore: store,
+		shredder:   shredder,
+	}
+}
+
+// Save encrypts and saves the given events.
+func (s *CryptoShreddingEventStore[ID]) Save(ctx context.Context, events []Event) error {
+	encrypted, err := s.encrypt(ctx, events)
+	if err != nil {
+		return err
+	}
+
+	return s.EventStore.Save(ctx, encrypted)
+}
+
+// SaveVersioned encrypts and saves the given events using the underlying store concurrency check if available.
+func (s *CryptoShreddingEventStore[ID]) SaveVersioned(ctx context.Context, expectedVersion AggregateVersion, events []Event) error {
+	encrypted, err := s.encrypt(ctx, events)
+	if err != nil {
+		return err
+	}
+
+	if vs, ok := s.EventStore.(VersionedEventSaver); ok {
+		return vs.SaveVersioned(ctx, expectedVersion, encrypted)
+	}
+	return s.EventStore.Save(ctx, encrypted)
+}
+
+// RetrieveMany retrieves and decrypts the events of the given aggregate.
+func (s *CryptoShreddingEventStore[ID]) RetrieveMany(ctx context.Context, aggregateID ID, opts ...RetrieveEventsOption) ([]Event, error) {
+	events, err := s.EventStore.RetrieveMany(ctx, aggregateID, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decrypt(ctx, events)
+}
+
+// StreamEvents streams and decrypts the events of the given aggregate.
+func (s *CryptoShreddingEventStore[ID]) StreamEvents(ctx context.Context, aggregateID ID, opts ...RetrieveEventsOption) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		for event, err := range StreamEvents(ctx, s.EventStore, aggregateID, opts...) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			decrypted, err := s.decrypt(ctx, []Event{event})
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if !yield(decrypted[0], nil) {
+				return
+			}
+		}
+	}
+}
+
+// Search searches and decrypts the events matching the given criteria.
+func (s *CryptoShreddingEventStore[ID]) Search(ctx context.Context, criteria *SearchCriteriaOptions) ([]Event, error) {
+	events, err := s.EventStore.Search(ctx, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decrypt(ctx, events)
+}
+
+// ReadAll reads and decrypts up to batchSize events, in commit order, starting from the given position (inclusive).
+// The underlying store must implement EventStreamReader.
+func (s *CryptoShreddingEventStore[ID]) ReadAll(
+	ctx context.Context,
+	fromPosition GlobalPosition,
+	batchSize int,
+	opts ...ReadAllOption,
+) ([]StoredEvent, error) {
+	reader, ok := s.EventStore.(EventStreamReader)
+	if !ok {
+		return nil, ErrStreamOperationNotSupported
+	}
+
+	stored, err := reader.ReadAll(ctx, fromPosition, batchSize, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, len(stored))
+	for i, se := range stored {
+		events[i] = se.Event
+	}
+	decrypted, err := s.decrypt(ctx, events)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]StoredEvent, len(stored))
+	for i, se := range stored {
+		result[i] = StoredEvent{Position: se.Position, Event: decrypted[i]}
+	}
+	return result, nil
+}
+
+// StreamExists checks the stream using the underlying store, see StreamExists.
+func (s *CryptoShreddingEventStore[ID]) StreamExists(ctx context.Context, aggregateID ID) (bool, error) {
+	return StreamExists(ctx, s.EventStore, aggregateID)
+}
+
+// ArchiveStream archives the stream using the underlying store if it implements StreamArchiver.
+func (s *CryptoShreddingEventStore[ID]) ArchiveStream(ctx context.Context, aggregateID ID) error {
+	return archiveStream(ctx, s.EventStore, aggregateID)
+}
+
+// DeleteStream deletes the stream using the underlying store if it implements StreamDeleter.
+func (s *CryptoShreddingEventStore[ID]) DeleteStream(ctx context.Context, aggregateID ID) error {
+	return deleteStream(ctx, s.EventStore, aggregateID)
+}
+
+// TruncateStream truncates the stream using the underlying store if it implements StreamTruncater.
+func (s *CryptoShreddingEventStore[ID]) TruncateStream(ctx context.Context, aggregateID ID, beforeVersion AggregateVersion) error {
+	return truncateStream(ctx, s.EventStore, aggregateID, beforeVersion)
+}
+
+func (s *CryptoShreddingEventStore[ID]) encrypt(ctx context.Context, events []Event) ([]Event, error) {
+	encrypted, err := s.shredder.EncryptEvents(ctx, events)
+	if err != nil {
+		return nil, fmt.Errorf("could not encrypt events: %w", err)
+	}
+	return encrypted, nil
+}
+
+func (s *CryptoShreddingEventStore[ID]) decrypt(ctx context.Context, events []Event) ([]Event, error) {
+	decrypted, err := s.shredder.DecryptEvents(ctx, events)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt events: %w", err)
+	}
+	return decrypted, nil
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"context"
+	"iter"
+)
+
+// DefaultStreamBatchSize is the number of events fetched per batch
+// when streaming events without a RetrieveEventsBatchSize option.
+const DefaultStreamBatchSize = 256
+
+// EventStreamer represents an event store that can stream the events of an aggregate.
+// The events are fetched lazily, in batches of RetrieveEventsOptions.BatchSize events,
+// so that the memory used does not depend on the length of the stream.
+type EventStreamer[ID comparable] interface {
+	// StreamEvents streams the events of the given aggregate ordered by version.
+	// The iteration stops after yielding the first error.
+	StreamEvents(ctx context.Context, aggregateID ID, opts ...RetrieveEventsOption) iter.Seq2[Event, error]
+}
+
+// NewRetrieveEventsOptions creates a new RetrieveEventsOptions applying the given options.
+func NewRetrieveEventsOptions(opts ...RetrieveEventsOption) RetrieveEventsOptions {
+	options := RetrieveEventsOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// StreamEvents streams the events of the given aggregate from the given retriever.
+//
+// If the retriever implements EventStreamer the events are fetched in batches,
+// otherwise they are retrieved at once with RetrieveMany and then yielded one by one.
+func StreamEvents[ID comparable](
+	ctx context.Context,
+	retriever EventRetriever[ID],
+	aggregateID ID,
+	opts ...RetrieveEventsOption,
+) iter.Seq2[Event, error] {
+	if s, ok := retriever.(EventStreamer[ID]); ok {
+		return s.StreamEvents(ctx, aggregateID, opts...)
+	}
+
+	return func(yield func(Event, error) bool) {
+		events, err := retriever.RetrieveMany(ctx, aggregateID, opts...)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		for _, event := range events {
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
+
+// PaginateEvents streams the events returned by successive calls to fetchPage.
+// It helps implementing EventStreamer on top of a store able to fetch a page of
+// at most limit events, ordered by version, starting from the given version (inclusive).
+//
+// The pages are fetched until one is shorter than the batch size or the ToVersion option is reached.
+func PaginateEvents(
+	ctx context.Context,
+	options RetrieveEventsOptions,
+	fetchPage func(ctx context.Context, fromVersion, limit int) ([]Event, error),
+) iter.Seq2[Event, error] {
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultStreamBatchSize
+	}
+
+	return func(yield func(Event, error) bool) {
+		fromVersion := options.FromVersion
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			page, err := fetchPage(ctx, fromVersion, batchSize)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, event := range page {
+				if options.ToVersion > 0 && int(event.AggregateRef().Version()) > options.ToVersion {
+					return
+				}
+				if !yield(event, nil) {
+					return
+				}
+			}
+
+			if len(page) < batchSize {
+				return
+			}
+
+			lastVersion := int(page[len(page)-1].AggregateRef().Version())
+			if options.ToVersion > 0 && lastVersion >= options.ToVersion {
+				return
+			}
+			fromVersion = lastVersion + 1
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+// pagedEventStoreMock is an eventStoreMock streaming its events in pages.
+type pagedEventStoreMock struct {
+	eventStoreMock
+
+	pages   int
+	failing error
+}
+
+func (m *pagedEventStoreMock) fetchPage(_ context.Context, fromVersion, limit int) ([]domain.Event, error) {
+	m.pages++
+	if m.failing != nil && m.pages > 1 {
+		return nil, m.failing
+	}
+
+	page := make([]domain.Event, 0, limit)
+	for _, evt := range m.events {
+		if int(evt.AggregateRef().Version()) < fromVersion {
+			continue
+		}
+		page = append(page, evt)
+		if len(page) == limit {
+			break
+		}
+	}
+	return page, nil
+}
+
+func (m *pagedEventStoreMock) StreamEvents(ctx context.Context, _ string, opts ...domain.RetrieveEventsOption) iter.Seq2[domain.Event, error] {
+	return domain.PaginateEvents(ctx, domain.NewRetrieveEventsOptions(opts...), m.fetchPage)
+}
+
+func TestPaginateEvents(t *testing.T) {
+	ctx := context.Background()
+	agg := newCounterAggregate("agg-1")
+	for range 5 {
+		agg.increment(t)
+	}
+	store := &pagedEventStoreMock{}
+	require.NoError(t, store.Save(ctx, agg.AggregateEvents()))
+
+	t.Run("should fetch the events in batches", func(t *testing.T) {
+		store.pages = 0
+
+		var versions []domain.AggregateVersion
+		for evt, err := range domain.StreamEvents[string](ctx, store, "agg-1", domain.RetrieveEventsBatchSize(2)) {
+			require.NoError(t, err)
+			versions = append(versions, evt.AggregateRef().Version())
+		}
+		require.Equal(t, []domain.AggregateVersion{1, 2, 3, 4, 5}, versions)
+		require.Equal(t, 3, store.pages)
+	})
+
+	t.Run("should stop at the given version", func(t *testing.T) {
+		store.pages = 0
+
+		count := 0
+		for _, err := range domain.StreamEvents[string](ctx, store, "agg-1",
+			domain.RetrieveEventsFromVersion(2),
+			domain.RetrieveEventsToVersion(3),
+			domain.RetrieveEventsBatchSize(2),
+		) {
+			require.NoError(t, err)
+			count++
+		}
+		require.Equal(t, 2, count)
+		require.Equal(t, 1, store.pages)
+	})
+
+	t.Run("should not fetch more pages when the consumer stops", func(t *testing.T) {
+		store.pages = 0
+
+		for range domain.StreamEvents[string](ctx, store, "agg-1", domain.RetrieveEventsBatchSize(1)) {
+			break
+		}
+		require.Equal(t, 1, store.pages)
+	})
+
+	t.Run("should stop on the first error", func(t *testing.T) {
+		store.pages = 0
+		store.failing = errors.New("connection lost")
+		defer func() { store.failing = nil }()
+
+		var errs []error
+		for _, err := range domain.StreamEvents[string](ctx, store, "agg-1", domain.RetrieveEventsBatchSize(2)) {
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		require.Equal(t, []error{store.failing}, errs)
+	})
+
+	t.Run("should fall back to RetrieveMany", func(t *testing.T) {
+		count := 0
+		for _, err := range domain.StreamEvents[string](ctx, &store.eventStoreMock, "agg-1") {
+			require.NoError(t, err)
+			count++
+		}
+		require.Equal(t, 5, count)
+	})
+}
+
+func TestRestoreAggregateFromStream(t *testing.T) {
+	ctx := context.Background()
+	source := newCounterAggregate("agg-1")
+	for range 5 {
+		source.increment(t)
+	}
+	store := &pagedEventStoreMock{}
+	require.NoError(t, store.Save(ctx, source.AggregateEvents()))
+
+	t.Run("should apply the streamed events", func(t *testing.T) {
+		agg := newCounterAggregate("agg-1")
+		err := domain.RestoreAggregateFromStream(agg, domain.StreamEvents[string](ctx, store, "agg-1", domain.RetrieveEventsBatchSize(2)))
+		require.NoError(t, err)
+		require.Equal(t, 5, agg.count)
+		require.Equal(t, domain.AggregateVersion(5), agg.AggregateVersion())
+		require.Empty(t, agg.AggregateEvents())
+	})
+
+	t.Run("should verify the integrity of the streamed events", func(t *testing.T) {
+		history := domain.History{store.events[0], store.events[2]}
+
+		agg := newCounterAggregate("agg-1")
+		err := domain.RestoreAggregateFromStream(agg, history.Events())
+
+		var integrityErr *domain.HistoryIntegrityError
+		require.ErrorAs(t, err, &integrityErr)
+		require.Equal(t, "VERSION_MISMATCH", integrityErr.ErrorType)
+		require.Equal(t, 1, integrityErr.EventIndex)
+		require.Equal(t, 1, agg.count, "the events before the failure are applied")
+	})
+
+	t.Run("should fail on empty streams", func(t *testing.T) {
+		err := domain.RestoreAggregateFromStream(newCounterAggregate("agg-1"), domain.History{}.Events())
+		require.ErrorIs(t, err, domain.ErrEmptyEventHistory)
+	})
+
+	t.Run("should fail on stream errors", func(t *testing.T) {
+		store.pages = 0
+		store.failing = errors.New("connection lost")
+		defer func() { store.failing = nil }()
+
+		agg := newCounterAggregate("agg-1")
+		err := domain.RestoreAggregateFromStream(agg, domain.StreamEvents[string](ctx, store, "agg-1", domain.RetrieveEventsBatchSize(2)))
+		require.ErrorIs(t, err, store.failing)
+		require.Equal(t, 2, agg.count)
+	})
+
+	t.Run("should load through the repository", func(t *testing.T) {
+		store.pages = 0
+		repo := domain.NewEventSourceRepository[string](store)
+
+		agg := newCounterAggregate("agg-1")
+		require.NoError(t, repo.Load(ctx, agg))
+		require.Equal(t, 5, agg.count)
+		require.Equal(t, 1, store.pages)
+	})
+}
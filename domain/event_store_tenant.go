@@ -0,0 +1,238 @@
+package domain
+
+import (
+	"context"
+	"iter"
+)
+
+var _ interface {
+	EventStore[any]
+	VersionedEventSaver
+	EventStreamer[any]
+	EventStreamReader
+	StreamExistenceChecker[any]
+	StreamArchiver[any]
+	StreamDeleter[any]
+	StreamTruncater[any]
+} = (*TenantEventStore[any])(nil)
+
+// TenantEventStore decorates an EventStore isolating the tenants.
+//
+// Given a context scoped to a tenant (see ContextWithTenant), the saved events must be stamped
+// with that tenant, and the streams and events of other tenants can be neither read, searched,
+// appended to nor deleted: the access is rejected with a TenantMismatchError, or the events
+// are left out of the search results.
+// A context without tenant is not scoped and has access to all the streams.
+//
+// The underlying store must keep the event metadata, where the tenant is stored.
+type TenantEventStore[ID comparable] struct {
+	EventStore[ID]
+}
+
+// NewTenantEventStore creates a new TenantEventStore wrapping the given EventStore.
+func NewTenantEventStore[ID comparable](store EventStore[ID]) *TenantEventStore[ID] {
+	return &TenantEventStore[ID]{EventStore: store}
+}
+
+// Save checks the tenant of the given events and of their streams, and saves them.
+func (s *TenantEventStore[ID]) Save(ctx context.Context, events []Event) error {
+	if err := s.checkSave(ctx, events); err != nil {
+		return err
+	}
+
+	return s.EventStore.Save(ctx, events)
+}
+
+// SaveVersioned checks the tenant of the given events and of their streams, and saves them
+// using the underlying store concurrency check if available.
+func (s *TenantEventStore[ID]) SaveVersioned(ctx context.Context, expectedVersion AggregateVersion, events []Event) error {
+	if err := s.checkSave(ctx, events); err != nil {
+		return err
+	}
+
+	if vs, ok := s.EventStore.(VersionedEventSaver); ok {
+		return vs.SaveVersioned(ctx, expectedVersion, events)
+	}
+	return s.EventStore.Save(ctx, events)
+}
+
+// RetrieveMany retrieves the events of the given aggregate, if the stream belongs to the tenant of the context.
+func (s *TenantEventStore[ID]) RetrieveMany(ctx context.Context, aggregateID ID, opts ...RetrieveEventsOption) ([]Event, error) {
+	events, err := s.EventStore.RetrieveMany(ctx, aggregateID, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// all the events of a stream belong to the same tenant
+	if len(events) > 0 {
+		if err := CheckEventTenant(ctx, events[0]); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// StreamEvents streams the events of the given aggregate, if the stream belongs to the tenant of the context.
+func (s *TenantEventStore[ID]) StreamEvents(ctx context.Context, aggregateID ID, opts ...RetrieveEventsOption) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		checked := false
+		for event, err := range StreamEvents(ctx, s.EventStore, aggregateID, opts...) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if !checked {
+				if err := CheckEventTenant(ctx, event); err != nil {
+					yield(nil, err)
+					return
+				}
+				checked = true
+			}
+
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Search searches the events of the tenant of the context matching the given criteria.
+func (s *TenantEventStore[ID]) Search(ctx context.Context, criteria *SearchCriteriaOptions) ([]Event, error) {
+	return s.EventStore.Search(ctx, TenantSearchCriteria(ctx, criteria))
+}
+
+// ReadAll reads up to batchSize events of the tenant of the context, in commit order,
+// starting from the given position (inclusive).
+// The underlying store must implement EventStreamReader.
+func (s *TenantEventStore[ID]) ReadAll(
+	ctx context.Context,
+	fromPosition GlobalPosition,
+	batchSize int,
+	opts ...ReadAllOption,
+) ([]StoredEvent, error) {
+	reader, ok := s.EventStore.(EventStreamReader)
+	if !ok {
+		return nil, ErrStreamOperationNotSupported
+	}
+
+	if _, scoped := TenantFromContext(ctx); !scoped {
+		return reader.ReadAll(ctx, fromPosition, batchSize, opts...)
+	}
+
+	// read until the batch is full so that the readers do not stop at a batch of other tenants' events
+	result := make([]StoredEvent, 0)
+	for {
+		limit := batchSize - len(result)
+		stored, err := reader.ReadAll(ctx, fromPosition, limit, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, se := range stored {
+			if CheckEventTenant(ctx, se.Event) == nil {
+				result = append(result, se)
+			}
+		}
+
+		if batchSize <= 0 || len(stored) < limit || len(result) >= batchSize {
+			return result, nil
+		}
+		fromPosition = stored[len(stored)-1].Position + 1
+	}
+}
+
+// StreamExists reports whether the stream exists, checking that it belongs to the tenant of the context.
+func (s *TenantEventStore[ID]) StreamExists(ctx context.Context, aggregateID ID) (bool, error) {
+	if _, scoped := TenantFromContext(ctx); !scoped {
+		return StreamExists(ctx, s.EventStore, aggregateID)
+	}
+
+	for event, err := range StreamEvents(ctx, s.EventStore, aggregateID, RetrieveEventsBatchSize(1)) {
+		if err != nil {
+			return false, err
+		}
+		if err := CheckEventTenant(ctx, event); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// ArchiveStream archives the stream, if it belongs to the tenant of the context,
+// using the underlying store if it implements StreamArchiver.
+func (s *TenantEventStore[ID]) ArchiveStream(ctx context.Context, aggregateID ID) error {
+	if err := s.checkStream(ctx, aggregateID); err != nil {
+		return err
+	}
+	return archiveStream(ctx, s.EventStore, aggregateID)
+}
+
+// DeleteStream deletes the stream, if it belongs to the tenant of the context,
+// using the underlying store if it implements StreamDeleter.
+func (s *TenantEventStore[ID]) DeleteStream(ctx context.Context, aggregateID ID) error {
+	if err := s.checkStream(ctx, aggregateID); err != nil {
+		return err
+	}
+	return deleteStream(ctx, s.EventStore, aggregateID)
+}
+
+// TruncateStream truncates the stream, if it belongs to the tenant of the context,
+// using the underlying store if it implements StreamTruncater.
+func (s *TenantEventStore[ID]) TruncateStream(ctx context.Context, aggregateID ID, beforeVersion AggregateVersion) error {
+	if err := s.checkStream(ctx, aggregateID); err != nil {
+		return err
+	}
+	return truncateStream(ctx, s.EventStore, aggregateID, beforeVersion)
+}
+
+// checkSave checks that the events are stamped with the tenant of the context
+// and are not appended to the stream of another tenant.
+func (s *TenantEventStore[ID]) checkSave(ctx context.Context, events []Event) error {
+	if _, scoped := TenantFromContext(ctx); !scoped {
+		return nil
+	}
+
+	checkedStreams := make(map[any]bool)
+	for _, event := range events {
+		if err := CheckEventTenant(ctx, event); err != nil {
+			return err
+		}
+
+		ref := event.AggregateRef()
+		if ref.Version() <= 1 || checkedStreams[ref.ID()] {
+			continue
+		}
+		checkedStreams[ref.ID()] = true
+
+		id, ok := ref.ID().(ID)
+		if !ok {
+			continue
+		}
+		if err := s.checkStream(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkStream checks that the stream of the given aggregate belongs to the tenant of the context.
+func (s *TenantEventStore[ID]) checkStream(ctx context.Context, aggregateID ID) error {
+	if _, scoped := TenantFromContext(ctx); !scoped {
+		return nil
+	}
+	return checkStreamTenant(ctx, s.EventStore, aggregateID)
+}
+
+// checkStreamTenant checks that the stream of the given aggregate, if any,
+// belongs to the tenant of the context reading its first event.
+func checkStreamTenant[ID comparable](ctx context.Context, store EventStore[ID], aggregateID ID) error {
+	for event, err := range StreamEvents(ctx, store, aggregateID, RetrieveEventsBatchSize(1)) {
+		if err != nil {
+			return err
+		}
+		return CheckEventTenant(ctx, event)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+var _ interface {
+	EventStore[any]
+	VersionedEventSaver
+	EventStreamer[any]
+	EventStreamReader
+	StreamExistenceChecker[any]
+	StreamArchiver[any]
+	StreamDeleter[any]
+	StreamTruncater[any]
+} = (*UpcastingEventStore[any])(nil)
+
+// UpcastingEventStore decorates an EventStore transforming the retrieved events
+// into their latest shape using an UpcasterRegistry.
+type UpcastingEventStore[ID comparable] struct {
+	EventStore[ID]
+
+	upcasters *UpcasterRegistry
+}
+
+// NewUpcastingEventStore creates a new UpcastingEventStore wrapping the given EventStore.
+func NewUpcastingEventStore[ID comparable](store EventStore[ID], upcasters *UpcasterRegistry) *UpcastingEventStore[ID] {
+	return &UpcastingEventStore[ID]{
+		EventStore: store,
+		upcasters:  upcasters,
+	}
+}
+
+// SaveVersioned saves the given events using the underlying store concurrency check if available.
+func (s *UpcastingEventStore[ID]) SaveVersioned(ctx context.Context, expectedVersion AggregateVersion, events []Event) error {
+	if vs, ok := s.EventStore.(VersionedEventSaver); ok {
+		return vs.SaveVersioned(ctx, expectedVersion, events)
+	}
+	return s.EventStore.Save(ctx, events)
+}
+
+// StreamExists checks the stream using the underlying store, see StreamExists.
+func (s *UpcastingEventStore[ID]) StreamExists(ctx context.Context, aggregateID ID) (bool, error) {
+	return StreamExists(ctx, s.EventStore, aggregateID)
+}
+
+// ArchiveStream archives the stream using the underlying store if it implements StreamArchiver.
+func (s *UpcastingEventStore[ID]) ArchiveStream(ctx context.Context, aggregateID ID) error {
+	return archiveStream(ctx, s.EventStore, aggregateID)
+}
+
+// DeleteStream deletes the stream using the underlying store if it implements StreamDeleter.
+func (s *UpcastingEventStore[ID]) DeleteStream(ctx context.Context, aggregateID ID) error {
+	return deleteStream(ctx, s.EventStore, aggregateID)
+}
+
+// TruncateStream truncates the stream using the underlying store if it implements StreamTruncater.
+func (s *UpcastingEventStore[ID]) TruncateStream(ctx context.Context, aggregateID ID, beforeVersion AggregateVersion) error {
+	return truncateStream(ctx, s.EventStore, aggregateID, beforeVersion)
+}
+
+// RetrieveMany retrieves and upcasts the events of the given aggregate.
+func (s *UpcastingEventStore[ID]) RetrieveMany(ctx context.Context, aggregateID ID, opts ...RetrieveEventsOption) ([]Event, error) {
+	events, err := s.EventStore.RetrieveMany(ctx, aggregateID, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.upcast(events)
+}
+
+// StreamEvents streams and upcasts the events of the given aggregate.
+func (s *UpcastingEventStore[ID]) StreamEvents(ctx context.Context, aggregateID ID, opts ...RetrieveEventsOption) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		for event, err := range StreamEvents(ctx, s.EventStore, aggregateID, opts...) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			upcasted, err := s.upcasters.Upcast(event)
+			if err != nil {
+				yield(nil, fmt.Errorf("could not upcast events: %w", err))
+				return
+			}
+
+			if !yield(upcasted, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Search searches and upcasts the events matching the given criteria.
+func (s *UpcastingEventStore[ID]) Search(ctx context.Context, criteria *SearchCriteriaOptions) ([]Event, error) {
+	events, err := s.EventStore.Search(ctx, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.upcast(events)
+}
+
+// ReadAll reads and upcasts up to batchSize events, in commit order, starting from the given position (inclusive).
+// The underlying store must implement EventStreamReader.
+func (s *UpcastingEventStore[ID]) ReadAll(
+	ctx context.Context,
+	fromPosition GlobalPosition,
+	batchSize int,
+	opts ...ReadAllOption,
+) ([]StoredEvent, error) {
+	reader, ok := s.EventStore.(EventStreamReader)
+	if !ok {
+		return nil, ErrStreamOperationNotSupported
+	}
+
+	stored, err := reader.ReadAll(ctx, fromPosition, batchSize, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	upcasted := make([]StoredEvent, len(stored))
+	for i, se := range stored {
+		event, err := s.upcasters.Upcast(se.Event)
+		if err != nil {
+			return nil, fmt.Errorf("could not upcast events: %w", err)
+		}
+		upcasted[i] = StoredEvent{Position: se.Position, Event: event}
+	}
+	return upcasted, nil
+}
+
+func (s *UpcastingEventStore[ID]) upcast(events []Event) ([]Event, error) {
+	upcasted, err := s.upcasters.UpcastEvents(events)
+	if err != nil {
+		return nil, fmt.Errorf("could not upcast events: %w", err)
+	}
+	return upcasted, nil
+}
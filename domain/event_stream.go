@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"context"
+	"slices"
+)
+
+// GlobalPosition represents the position of an event in the global,
+// commit-ordered stream of the event store. Positions start at 1.
+type GlobalPosition uint64
+
+// StoredEvent represents an event persisted in the event store along with its global position.
+type StoredEvent struct {
+	// Position is the global position of the event in the event store.
+	Position GlobalPosition
+	// Event is the stored event.
+	Event Event
+}
+
+// EventStreamReader represents an event store that can read all the events in commit order.
+type EventStreamReader interface {
+	// ReadAll reads up to batchSize events, in commit order, starting from the given position (inclusive).
+	// A batchSize lower than or equal to 0 reads all the remaining events.
+	ReadAll(ctx context.Context, fromPosition GlobalPosition, batchSize int, opts ...ReadAllOption) ([]StoredEvent, error)
+}
+
+// ReadAllOption represents an option for reading the global event stream.
+type ReadAllOption func(*ReadAllOptions)
+
+// ReadAllAggregateNames filters the stream by the given aggregate names.
+func ReadAllAggregateNames(names ...string) ReadAllOption {
+	return func(opts *ReadAllOptions) {
+		opts.AggregateNames = names
+	}
+}
+
+// ReadAllEventNames filters the stream by the given event names.
+func ReadAllEventNames(names ...string) ReadAllOption {
+	return func(opts *ReadAllOptions) {
+		opts.EventNames = names
+	}
+}
+
+type ReadAllOptions struct {
+	// AggregateNames filters the events by aggregate name. Empty means all.
+	AggregateNames []string
+	// EventNames filters the events by event name. Empty means all.
+	EventNames []string
+}
+
+// Matches checks if the given event matches the options filters.
+func (o ReadAllOptions) Matches(event Event) bool {
+	if len(o.EventNames) > 0 && !slices.Contains(o.EventNames, event.Name()) {
+		return false
+	}
+
+	if len(o.AggregateNames) > 0 {
+		ref := event.AggregateRef()
+		if ref == nil || !slices.Contains(o.AggregateNames, ref.Name()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewReadAllOptions builds the ReadAllOptions from the given options.
+func NewReadAllOptions(opts ...ReadAllOption) ReadAllOptions {
+	options := ReadAllOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
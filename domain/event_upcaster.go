@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrUpcasterAlreadyRegistered = errors.New("upcaster already registered")
+	ErrInvalidUpcastedRevision   = errors.New("upcasted event revision must be greater than the source revision")
+)
+
+// Upcaster transforms a stored event from one schema revision into the next one.
+type Upcaster func(Event) (Event, error)
+
+type upcasterKey struct {
+	name     string
+	revision int
+}
+
+// UpcasterRegistry holds the upcasters keyed by event name and source revision.
+// Upcasters are chained, so an event stored at revision 1 is transformed
+// through every registered upcaster (v1→v2→v3) until no upcaster matches.
+type UpcasterRegistry struct {
+	mu        sync.RWMutex
+	upcasters map[upcasterKey]Upcaster
+}
+
+// NewUpcasterRegistry creates a new empty UpcasterRegistry.
+func NewUpcasterRegistry() *UpcasterRegistry {
+	return &UpcasterRegistry{
+		upcasters: make(map[upcasterKey]Upcaster),
+	}
+}
+
+// Register registers an upcaster for the given event name and source revision.
+// The upcaster must return an event with a greater revision than fromRevision.
+func (r *UpcasterRegistry) Register(eventName string, fromRevision int, upcaster Upcaster) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := upcasterKey{name: eventName, revision: fromRevision}
+	if _, ok := r.upcasters[key]; ok {
+		return fmt.Errorf("%w: %s (revision %d)", ErrUpcasterAlreadyRegistered, eventName, fromRevision)
+	}
+
+	r.upcasters[key] = upcaster
+	return nil
+}
+
+// Upcast transforms the given event into its latest shape applying
+// the chain of registered upcasters. Events without upcasters are returned unchanged.
+func (r *UpcasterRegistry) Upcast(event Event) (Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for event != nil {
+		revision := EventRevision(event)
+		upcaster, ok := r.upcasters[upcasterKey{name: event.Name(), revision: revision}]
+		if !ok {
+			return event, nil
+		}
+
+		upcasted, err := upcaster(event)
+		if err != nil {
+			return nil, fmt.Errorf("could not upcast event %s (revision %d): %w", event.Name(), revision, err)
+		}
+
+		if upcasted == nil || EventRevision(upcasted) <= revision {
+			return nil, fmt.Errorf("%w: %s (revision %d)", ErrInvalidUpcastedRevision, event.Name(), revision)
+		}
+
+		event = upcasted
+	}
+
+	return event, nil
+}
+
+// UpcastEvents transforms every given event into its latest shape.
+func (r *UpcasterRegistry) UpcastEvents(events []Event) ([]Event, error) {
+	upcasted := make([]Event, len(events))
+	for i, event := range events {
+		evt, err := r.Upcast(event)
+		if err != nil {
+			return nil, err
+		}
+		upcasted[i] = evt
+	}
+
+	return upcasted, nil
+}
+
+// UpcastBaseEvent creates a BaseEvent from the given event with the given revision,
+// keeping its name, timestamp, metadata and aggregate reference.
+// It is intended to be embedded by the concrete events returned by upcasters.
+func UpcastBaseEvent(from Event, revision int) BaseEvent {
+	return NewEvent(
+		from.Name(),
+		from.AggregateRef(),
+		WithEventTimestamp(from.Timestamp()),
+		WithEventRevision(revision),
+		WithEventMetadata(EventMetadata(from)),
+	)
+}
@@ -0,0 +1,172 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+type customerRegisteredV1 struct {
+	domain.BaseEvent
+	CustomerName string
+}
+
+type customerRegisteredV2 struct {
+	domain.BaseEvent
+	FullName string
+}
+
+type customerRegisteredV3 struct {
+	domain.BaseEvent
+	FirstName string
+	LastName  string
+}
+
+func upcastCustomerRegisteredV1(evt domain.Event) (domain.Event, error) {
+	v1, ok := evt.(customerRegisteredV1)
+	if !ok {
+		return nil, domain.InvalidEventTypeError{Event: evt, Expected: customerRegisteredV1{}}
+	}
+	return customerRegisteredV2{BaseEvent: domain.UpcastBaseEvent(v1, 2), FullName: v1.CustomerName}, nil
+}
+
+func upcastCustomerRegisteredV2(evt domain.Event) (domain.Event, error) {
+	v2, ok := evt.(customerRegisteredV2)
+	if !ok {
+		return nil, domain.InvalidEventTypeError{Event: evt, Expected: customerRegisteredV2{}}
+	}
+	first, last, _ := strings.Cut(v2.FullName, " ")
+	return customerRegisteredV3{BaseEvent: domain.UpcastBaseEvent(v2, 3), FirstName: first, LastName: last}, nil
+}
+
+func newCustomerUpcasters(t *testing.T) *domain.UpcasterRegistry {
+	registry := domain.NewUpcasterRegistry()
+	require.NoError(t, registry.Register("customer.registered", 1, upcastCustomerRegisteredV1))
+	require.NoError(t, registry.Register("customer.registered", 2, upcastCustomerRegisteredV2))
+	return registry
+}
+
+func TestUpcaster_Isolated(t *testing.T) {
+	ref := domain.NewEventAggregateReference("c-1", "customer", 1)
+	v1 := customerRegisteredV1{BaseEvent: domain.NewEvent("customer.registered", ref), CustomerName: "John Doe"}
+
+	upcasted, err := upcastCustomerRegisteredV1(v1)
+	require.NoError(t, err)
+
+	v2, ok := upcasted.(customerRegisteredV2)
+	require.True(t, ok)
+	assert.Equal(t, "John Doe", v2.FullName)
+	assert.Equal(t, 2, v2.Revision())
+	assert.Equal(t, v1.Timestamp(), v2.Timestamp())
+	assert.Equal(t, ref, v2.AggregateRef())
+}
+
+func TestUpcasterRegistry_Upcast(t *testing.T) {
+	registry := newCustomerUpcasters(t)
+	ref := domain.NewEventAggregateReference("c-1", "customer", 1)
+
+	t.Run("should chain upcasters up to the latest revision", func(t *testing.T) {
+		v1 := customerRegisteredV1{BaseEvent: domain.NewEvent("customer.registered", ref), CustomerName: "John Doe"}
+
+		upcasted, err := registry.Upcast(v1)
+		require.NoError(t, err)
+
+		v3, ok := upcasted.(customerRegisteredV3)
+		require.True(t, ok, "expected customerRegisteredV3, got %T", upcasted)
+		assert.Equal(t, "John", v3.FirstName)
+		assert.Equal(t, "Doe", v3.LastName)
+		assert.Equal(t, 3, v3.Revision())
+	})
+
+	t.Run("should start the chain from the stored revision", func(t *testing.T) {
+		v2 := customerRegisteredV2{
+			BaseEvent: domain.NewEvent("customer.registered", ref, domain.WithEventRevision(2)),
+			FullName:  "Jane Roe",
+		}
+
+		upcasted, err := registry.Upcast(v2)
+		require.NoError(t, err)
+		assert.IsType(t, customerRegisteredV3{}, upcasted)
+	})
+
+	t.Run("should return events without upcasters unchanged", func(t *testing.T) {
+		evt := domain.NewEvent("customer.deleted", ref)
+
+		upcasted, err := registry.Upcast(evt)
+		require.NoError(t, err)
+		assert.Equal(t, evt, upcasted)
+	})
+
+	t.Run("should reject duplicated upcasters", func(t *testing.T) {
+		err := registry.Register("customer.registered", 1, upcastCustomerRegisteredV1)
+		require.ErrorIs(t, err, domain.ErrUpcasterAlreadyRegistered)
+	})
+
+	t.Run("should reject upcasters that do not increase the revision", func(t *testing.T) {
+		r := domain.NewUpcasterRegistry()
+		require.NoError(t, r.Register("customer.registered", 1, func(e domain.Event) (domain.Event, error) {
+			return e, nil
+		}))
+
+		_, err := r.Upcast(domain.NewEvent("customer.registered", ref))
+		require.ErrorIs(t, err, domain.ErrInvalidUpcastedRevision)
+	})
+
+	t.Run("should return upcaster errors", func(t *testing.T) {
+		r := domain.NewUpcasterRegistry()
+		expectedErr := errors.New("upcast failed")
+		require.NoError(t, r.Register("customer.registered", 1, func(domain.Event) (domain.Event, error) {
+			return nil, expectedErr
+		}))
+
+		_, err := r.Upcast(domain.NewEvent("customer.registered", ref))
+		require.ErrorIs(t, err, expectedErr)
+	})
+}
+
+func TestEventSourceRepository_WithUpcasters(t *testing.T) {
+	ctx := context.Background()
+	store := &eventStoreMock{}
+
+	agg := domain.NewAggregate("c-1", "customer")
+	v1 := customerRegisteredV1{BaseEvent: domain.NewEvent("customer.registered", domain.CreateEventAggregateRef(agg)), CustomerName: "John Doe"}
+	require.NoError(t, domain.NextEvent(agg, v1))
+	require.NoError(t, store.Save(ctx, agg.AggregateEvents()))
+
+	sut := domain.NewEventSourceRepository(store, domain.WithUpcasters[string](newCustomerUpcasters(t)))
+
+	loaded := domain.NewAggregate("c-1", "customer")
+	var applied domain.Event
+	loaded.HandleEvent("customer.registered", func(e domain.Event) error {
+		applied = e
+		return nil
+	})
+
+	require.NoError(t, sut.Load(ctx, loaded))
+	require.IsType(t, customerRegisteredV3{}, applied)
+	assert.Equal(t, "Doe", applied.(customerRegisteredV3).LastName)
+}
+
+func TestUpcastingEventStore_ReadAll(t *testing.T) {
+	ctx := context.Background()
+	store := &eventStoreMock{}
+
+	agg := domain.NewAggregate("c-1", "customer")
+	v1 := customerRegisteredV1{BaseEvent: domain.NewEvent("customer.registered", domain.CreateEventAggregateRef(agg)), CustomerName: "John Doe"}
+	require.NoError(t, domain.NextEvent(agg, v1))
+	require.NoError(t, store.Save(ctx, agg.AggregateEvents()))
+
+	sut := domain.NewUpcastingEventStore[string](store, newCustomerUpcasters(t))
+
+	stored, err := sut.ReadAll(ctx, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, domain.GlobalPosition(1), stored[0].Position)
+	require.IsType(t, customerRegisteredV3{}, stored[0].Event)
+	assert.Equal(t, "Doe", stored[0].Event.(customerRegisteredV3).LastName)
+}
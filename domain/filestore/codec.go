@@ -0,0 +1,67 @@
+package filestore
+
+import (
+	"time"
+
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+// EventRecord is the persisted representation of a domain event.
+type EventRecord struct {
+	Position     domain.GlobalPosition
+	AggregateRef *domain.EventAggregateReference
+	Name         string
+	Revision     int
+	Timestamp    time.Time
+	Metadata     map[string]string
+	Payload      []byte
+}
+
+// EventCodec encodes the payload of domain events and rehydrates them from their records.
+type EventCodec interface {
+	// Encode serializes the event payload.
+	Encode(event domain.Event) ([]byte, error)
+	// Decode rehydrates the event from the given record.
+	Decode(record EventRecord) (domain.Event, error)
+}
+
+// BaseEventCodec is the default EventCodec.
+// It persists no payload and rehydrates the events as domain.BaseEvent.
+type BaseEventCodec struct{}
+
+func (BaseEventCodec) Encode(_ domain.Event) ([]byte, error) {
+	return nil, nil
+}
+
+func (c BaseEventCodec) Decode(record EventRecord) (domain.Event, error) {
+	return c.base(record), nil
+}
+
+func (BaseEventCodec) base(record EventRecord) domain.BaseEvent {
+	return domain.NewEvent(
+		record.Name,
+		record.AggregateRef,
+		domain.WithEventTimestamp(record.Timestamp),
+		domain.WithEventRevision(record.Revision),
+		domain.WithEventMetadata(record.Metadata),
+	)
+}
+
+// RegistryCodec is an EventCodec rehydrating the concrete event types
+// registered in a domain.EventRegistry.
+type RegistryCodec struct {
+	registry *domain.EventRegistry
+}
+
+// NewRegistryCodec creates a new RegistryCodec backed by the given registry.
+func NewRegistryCodec(registry *domain.EventRegistry) RegistryCodec {
+	return RegistryCodec{registry: registry}
+}
+
+func (c RegistryCodec) Encode(event domain.Event) ([]byte, error) {
+	return c.registry.Encode(event)
+}
+
+func (c RegistryCodec) Decode(record EventRecord) (domain.Event, error) {
+	return c.registry.Decode(BaseEventCodec{}.base(record), record.Payload)
+}
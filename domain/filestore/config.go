@@ -0,0 +1,40 @@
+package filestore
+
+import "time"
+
+const (
+	defaultMaxSegmentSize = 64 << 20 // 64MiB
+	defaultSyncInterval   = time.Second
+)
+
+// SyncPolicy defines when the segment files are flushed to stable storage.
+type SyncPolicy int
+
+const (
+	// SyncEveryWrite fsyncs the active segment after every save. It is the safest and slowest policy.
+	SyncEveryWrite SyncPolicy = iota
+	// SyncInterval fsyncs the active segment periodically, every Config.SyncInterval.
+	// Events saved since the last sync may be lost on power failure.
+	SyncInterval
+	// SyncNone never fsyncs explicitly and relies on the operating system.
+	SyncNone
+)
+
+type Config[ID comparable] struct {
+	// Dir is the directory holding the segment files. Required.
+	Dir string
+	// MaxSegmentSize is the size in bytes after which a new segment is started.
+	// If zero or negative, a default of 64MiB is used.
+	MaxSegmentSize int64
+	// SyncPolicy defines when the segment files are fsynced. Defaults to SyncEveryWrite.
+	SyncPolicy SyncPolicy
+	// SyncInterval is the fsync interval of the SyncInterval policy. Defaults to 1s.
+	SyncInterval time.Duration
+	// Codec encodes and decodes the event payloads. Defaults to BaseEventCodec.
+	Codec EventCodec
+	// FormatAggregateID formats the aggregate ID to be stored. Defaults to fmt.Sprint.
+	FormatAggregateID func(ID) string
+	// ParseAggregateID parses a stored aggregate ID.
+	// It is required when ID is not a string.
+	ParseAggregateID func(string) (ID, error)
+}
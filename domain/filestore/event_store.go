@@ -0,0 +1,852 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+var (
+	_ domain.EventStore[string]             = (*EventStore[string])(nil)
+	_ domain.VersionedEventSaver            = (*EventStore[string])(nil)
+	_ domain.EventStreamReader              = (*EventStore[string])(nil)
+	_ domain.EventStreamer[string]          = (*EventStore[string])(nil)
+	_ domain.StreamExistenceChecker[string] = (*EventStore[string])(nil)
+	_ domain.StreamDeleter[string]          = (*EventStore[string])(nil)
+	_ domain.StreamTruncater[string]        = (*EventStore[string])(nil)
+)
+
+var (
+	ErrEmptyDir               = errors.New("file event store directory cannot be empty")
+	ErrStoreClosed            = errors.New("file event store is closed")
+	ErrMixedAggregateEvents   = errors.New("events belong to different aggregates")
+	ErrInvalidAggregateIDType = errors.New("cannot parse aggregate ID: configure Config.ParseAggregateID")
+)
+
+// indexEntry locates an event record in the segment files.
+type indexEntry struct {
+	position      domain.GlobalPosition
+	aggregateID   string
+	aggregateName string
+	eventName     string
+	version       domain.AggregateVersion
+	segment       *segment
+	offset        int64
+	size          int64
+}
+
+// EventStore is an append-only, file-based implementation of domain.EventStore.
+//
+// Events are written as length-prefixed, CRC-32C checksummed records to segment files
+// and indexed in memory per aggregate. On Open, the index is rebuilt from the segments
+// and torn writes at the tail of the last segment are truncated.
+// Deleted streams are tombstoned and physically removed by Compact, which first persists
+// the last assigned global position so that positions are never reissued.
+type EventStore[ID comparable] struct {
+	cfg Config[ID]
+
+	mu          sync.RWMutex
+	segments    []*segment
+	entries     []*indexEntry
+	streams     map[string][]*indexEntry
+	position    domain.GlobalPosition
+	deadRecords int
+	dirty       bool
+	closed      bool
+
+	stopSync chan struct{}
+	syncDone chan struct{}
+}
+
+// Open opens the file event store located at cfg.Dir, creating it if needed,
+// and recovers its index from the segment files.
+func Open[ID comparable](cfg Config[ID]) (*EventStore[ID], error) {
+	if cfg.Dir == "" {
+		return nil, ErrEmptyDir
+	}
+	if cfg.MaxSegmentSize <= 0 {
+		cfg.MaxSegmentSize = defaultMaxSegmentSize
+	}
+	if cfg.SyncInterval <= 0 {
+		cfg.SyncInterval = defaultSyncInterval
+	}
+	if cfg.Codec == nil {
+		cfg.Codec = BaseEventCodec{}
+	}
+	if cfg.FormatAggregateID == nil {
+		cfg.FormatAggregateID = func(id ID) string { return fmt.Sprint(id) }
+	}
+	if cfg.ParseAggregateID == nil {
+		cfg.ParseAggregateID = func(s string) (ID, error) {
+			id, ok := any(s).(ID)
+			if !ok {
+				return id, ErrInvalidAggregateIDType
+			}
+			return id, nil
+		}
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("could not create directory %s: %w", cfg.Dir, err)
+	}
+
+	s := &EventStore[ID]{
+		cfg:     cfg,
+		streams: make(map[string][]*indexEntry),
+	}
+
+	if err := s.recover(); err != nil {
+		s.closeSegments()
+		return nil, err
+	}
+
+	if cfg.SyncPolicy == SyncInterval {
+		s.stopSync = make(chan struct{})
+		s.syncDone = make(chan struct{})
+		go s.syncLoop()
+	}
+
+	return s, nil
+}
+
+// Close flushes and closes the segment files.
+func (s *EventStore[ID]) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	if s.stopSync != nil {
+		close(s.stopSync)
+		<-s.syncDone
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.active().file.Sync()
+	return errors.Join(err, s.closeSegments())
+}
+
+// Save appends the given events to the store.
+// Each event version must follow the current version of its aggregate,
+// otherwise a domain.ConcurrencyConflictError is returned.
+func (s *EventStore[ID]) Save(_ context.Context, events []domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	return s.append(events)
+}
+
+// SaveVersioned appends the given events to the store only if the current aggregate
+// version matches the expected version.
+func (s *EventStore[ID]) SaveVersioned(_ context.Context, expectedVersion domain.AggregateVersion, events []domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	aggregateID := events[0].AggregateRef().ID()
+	for _, event := range events[1:] {
+		if event.AggregateRef().ID() != aggregateID {
+			return ErrMixedAggregateEvents
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	streamID, err := s.streamID(aggregateID)
+	if err != nil {
+		return err
+	}
+
+	if current := s.streamVersion(streamID); current != expectedVersion {
+		return domain.NewConcurrencyConflictError(aggregateID, expectedVersion, current)
+	}
+
+	return s.append(events)
+}
+
+// RetrieveMany retrieves the events of the given aggregate ordered by version.
+func (s *EventStore[ID]) RetrieveMany(_ context.Context, aggregateID ID, opts ...domain.RetrieveEventsOption) ([]domain.Event, error) {
+	options := domain.RetrieveEventsOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+
+	entries := make([]*indexEntry, 0)
+	for _, entry := range s.streams[s.cfg.FormatAggregateID(aggregateID)] {
+		if options.FromVersion > 0 && int(entry.version) < options.FromVersion {
+			continue
+		}
+		if options.ToVersion > 0 && int(entry.version) > options.ToVersion {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return s.readEvents(entries)
+}
+
+// StreamEvents streams the events of the given aggregate ordered by version, reading them in batches.
+// The store is locked while a batch is read, but not while its events are yielded.
+// It implements the domain.EventStreamer interface.
+func (s *EventStore[ID]) StreamEvents(ctx context.Context, aggregateID ID, opts ...domain.RetrieveEventsOption) iter.Seq2[domain.Event, error] {
+	options := domain.NewRetrieveEventsOptions(opts...)
+	streamID := s.cfg.FormatAggregateID(aggregateID)
+
+	return domain.PaginateEvents(ctx, options, func(_ context.Context, fromVersion, limit int) ([]domain.Event, error) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		if s.closed {
+			return nil, ErrStoreClosed
+		}
+
+		stream := s.streams[streamID]
+		start := sort.Search(len(stream), func(i int) bool {
+			return int(stream[i].version) >= fromVersion
+		})
+
+		entries := make([]*indexEntry, 0, limit)
+		for _, entry := range stream[start:] {
+			if options.ToVersion > 0 && int(entry.version) > options.ToVersion {
+				break
+			}
+			entries = append(entries, entry)
+			if len(entries) == limit {
+				break
+			}
+		}
+
+		return s.readEvents(entries)
+	})
+}
+
+// StreamExists reports whether the given aggregate has any event, looking it up in the index.
+// It implements the domain.StreamExistenceChecker interface.
+func (s *EventStore[ID]) StreamExists(_ context.Context, aggregateID ID) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return false, ErrStoreClosed
+	}
+
+	return len(s.streams[s.cfg.FormatAggregateID(aggregateID)]) > 0, nil
+}
+
+// Search searches the events matching the given criteria ordered by global position,
+// or by the sort field of the criteria, and paginated.
+//
+// The filters on the indexed fields are applied before reading the events;
+// the timestamp, metadata and custom criteria are applied to the read events.
+func (s *EventStore[ID]) Search(_ context.Context, criteria *domain.SearchCriteriaOptions) ([]domain.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+
+	stored := make([]domain.StoredEvent, 0)
+	for _, entry := range s.entries {
+		if criteria != nil && !matchesCriteria(criteria, entry) {
+			continue
+		}
+
+		event, err := s.readEvent(entry)
+		if err != nil {
+			return nil, err
+		}
+		stored = append(stored, domain.StoredEvent{Position: entry.position, Event: event})
+	}
+
+	if criteria != nil {
+		// the aggregate IDs have been matched in their stored format
+		remaining := *criteria
+		var err error
+		stored, err = domain.SearchStoredEvents(stored, remaining.WithSearchAggregateIDs())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	events := make([]domain.Event, len(stored))
+	for i, se := range stored {
+		events[i] = se.Event
+	}
+	return events, nil
+}
+
+// ReadAll reads up to batchSize events, in commit order, starting from the given position (inclusive).
+// It implements the domain.EventStreamReader interface.
+func (s *EventStore[ID]) ReadAll(
+	_ context.Context,
+	fromPosition domain.GlobalPosition,
+	batchSize int,
+	opts ...domain.ReadAllOption,
+) ([]domain.StoredEvent, error) {
+	options := domain.NewReadAllOptions(opts...)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+
+	start := sort.Search(len(s.entries), func(i int) bool {
+		return s.entries[i].position >= fromPosition
+	})
+
+	result := make([]domain.StoredEvent, 0)
+	for _, entry := range s.entries[start:] {
+		if batchSize > 0 && len(result) >= batchSize {
+			break
+		}
+		if len(options.EventNames) > 0 && !slices.Contains(options.EventNames, entry.eventName) {
+			continue
+		}
+		if len(options.AggregateNames) > 0 && !slices.Contains(options.AggregateNames, entry.aggregateName) {
+			continue
+		}
+
+		event, err := s.readEvent(entry)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, domain.StoredEvent{Position: entry.position, Event: event})
+	}
+
+	return result, nil
+}
+
+// DeleteStream tombstones the stream of the given aggregate.
+// The events are no longer readable and are physically removed on the next compaction.
+func (s *EventStore[ID]) DeleteStream(_ context.Context, aggregateID ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	streamID := s.cfg.FormatAggregateID(aggregateID)
+	if _, ok := s.streams[streamID]; !ok {
+		return nil
+	}
+
+	buf, err := encodeRecord(fileRecord{
+		Kind:        recordKindTombstone,
+		AggregateID: streamID,
+		Timestamp:   time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.write(buf); err != nil {
+		return err
+	}
+
+	s.applyTombstone(streamID)
+	s.deadRecords++ // the tombstone itself
+	return nil
+}
+
+// TruncateStream removes the events of the given aggregate with a version lower than beforeVersion.
+// The events are no longer readable and are physically removed on the next compaction.
+func (s *EventStore[ID]) TruncateStream(_ context.Context, aggregateID ID, beforeVersion domain.AggregateVersion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	streamID := s.cfg.FormatAggregateID(aggregateID)
+	if _, ok := s.streams[streamID]; !ok {
+		return nil
+	}
+
+	buf, err := encodeRecord(fileRecord{
+		Kind:        recordKindTruncate,
+		AggregateID: streamID,
+		Version:     int64(beforeVersion),
+		Timestamp:   time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.write(buf); err != nil {
+		return err
+	}
+
+	s.applyTruncate(streamID, beforeVersion)
+	s.deadRecords++ // the truncate record itself
+	return nil
+}
+
+// Compact rewrites the segment files dropping the records of the deleted and truncated streams.
+// The active segment is sealed first so that every dead record can be reclaimed.
+func (s *EventStore[ID]) Compact(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	if s.deadRecords == 0 {
+		return nil
+	}
+
+	if s.active().size > 0 {
+		if err := s.roll(); err != nil {
+			return err
+		}
+	}
+
+	// the compacted segments may drop the records holding the last assigned position,
+	// so it is persisted in the active segment, which is not compacted, beforehand
+	if err := s.writePosition(); err != nil {
+		return err
+	}
+
+	live := make(map[*segment][]*indexEntry, len(s.segments))
+	for _, entry := range s.entries {
+		live[entry.segment] = append(live[entry.segment], entry)
+	}
+
+	sealed := s.segments[:len(s.segments)-1]
+	kept := make([]*segment, 0, len(s.segments))
+	for _, seg := range sealed {
+		compacted, err := s.compactSegment(seg, live[seg])
+		if err != nil {
+			return err
+		}
+		if compacted != nil {
+			kept = append(kept, compacted)
+		}
+	}
+
+	s.segments = append(kept, s.active())
+	s.deadRecords = 0
+	return syncDir(s.cfg.Dir)
+}
+
+// writePosition writes and syncs a record holding the last assigned global position.
+func (s *EventStore[ID]) writePosition() error {
+	buf, err := encodeRecord(fileRecord{
+		Kind:      recordKindPosition,
+		Position:  uint64(s.position),
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.write(buf); err != nil {
+		return err
+	}
+
+	active := s.active()
+	if err := active.file.Sync(); err != nil {
+		return fmt.Errorf("could not sync segment %s: %w", active.path, err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// compactSegment rewrites the segment keeping only the given live entries.
+// It returns nil if the segment has no live entries and has been removed.
+func (s *EventStore[ID]) compactSegment(seg *segment, entries []*indexEntry) (*segment, error) {
+	if len(entries) == 0 {
+		_ = seg.file.Close()
+		if err := os.Remove(seg.path); err != nil {
+			return nil, fmt.Errorf("could not remove segment %s: %w", seg.path, err)
+		}
+		return nil, nil
+	}
+
+	tmpPath := seg.path + compactExt
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("could not create compacted segment: %w", err)
+	}
+
+	offsets := make([]int64, len(entries))
+	var size int64
+	for i, entry := range entries {
+		buf := make([]byte, entry.size)
+		if _, err := seg.file.ReadAt(buf, entry.offset); err != nil {
+			_ = tmp.Close()
+			return nil, fmt.Errorf("could not read segment %s: %w", seg.path, err)
+		}
+		if _, err := tmp.WriteAt(buf, size); err != nil {
+			_ = tmp.Close()
+			return nil, fmt.Errorf("could not write compacted segment: %w", err)
+		}
+		offsets[i] = size
+		size += entry.size
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return nil, fmt.Errorf("could not sync compacted segment: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, seg.path); err != nil {
+		_ = tmp.Close()
+		return nil, fmt.Errorf("could not replace segment %s: %w", seg.path, err)
+	}
+
+	_ = seg.file.Close()
+	compacted := &segment{id: seg.id, path: seg.path, file: tmp, size: size}
+	for i, entry := range entries {
+		entry.segment = compacted
+		entry.offset = offsets[i]
+	}
+
+	return compacted, nil
+}
+
+func (s *EventStore[ID]) recover() error {
+	ids, err := listSegments(s.cfg.Dir)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) == 0 {
+		ids = []int{1}
+	}
+
+	for i, id := range ids {
+		seg, err := openSegment(s.cfg.Dir, id)
+		if err != nil {
+			return err
+		}
+		s.segments = append(s.segments, seg)
+
+		validSize, err := seg.scan(func(rec fileRecord, offset, size int64) {
+			s.applyRecord(rec, seg, offset, size)
+		})
+		if errors.Is(err, errTornRecord) {
+			// only the tail of the last segment can hold a torn write
+			if i != len(ids)-1 {
+				return fmt.Errorf("%w: %s at offset %d", ErrCorruptedSegment, seg.path, validSize)
+			}
+			if err := seg.truncate(validSize); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *EventStore[ID]) applyRecord(rec fileRecord, seg *segment, offset, size int64) {
+	switch rec.Kind {
+	case recordKindEvent:
+		entry := &indexEntry{
+			position:      domain.GlobalPosition(rec.Position),
+			aggregateID:   rec.AggregateID,
+			aggregateName: rec.AggregateName,
+			eventName:     rec.EventName,
+			version:       domain.AggregateVersion(rec.Version),
+			segment:       seg,
+			offset:        offset,
+			size:          size,
+		}
+		s.entries = append(s.entries, entry)
+		s.streams[rec.AggregateID] = append(s.streams[rec.AggregateID], entry)
+		s.position = max(s.position, entry.position)
+	case recordKindPosition:
+		s.position = max(s.position, domain.GlobalPosition(rec.Position))
+	case recordKindTombstone:
+		s.applyTombstone(rec.AggregateID)
+		s.deadRecords++
+	case recordKindTruncate:
+		s.applyTruncate(rec.AggregateID, domain.AggregateVersion(rec.Version))
+		s.deadRecords++
+	}
+}
+
+func (s *EventStore[ID]) applyTombstone(streamID string) {
+	s.deadRecords += len(s.streams[streamID])
+	delete(s.streams, streamID)
+	s.entries = slices.DeleteFunc(s.entries, func(entry *indexEntry) bool {
+		return entry.aggregateID == streamID
+	})
+}
+
+func (s *EventStore[ID]) applyTruncate(streamID string, beforeVersion domain.AggregateVersion) {
+	truncated := func(entry *indexEntry) bool {
+		return entry.aggregateID == streamID && entry.version < beforeVersion
+	}
+
+	stream := s.streams[streamID]
+	kept := slices.DeleteFunc(slices.Clone(stream), truncated)
+	s.deadRecords += len(stream) - len(kept)
+	if len(kept) == 0 {
+		delete(s.streams, streamID)
+	} else {
+		s.streams[streamID] = kept
+	}
+	s.entries = slices.DeleteFunc(s.entries, truncated)
+}
+
+// append encodes and writes the given events, then updates the index.
+// It must be called with the write lock held.
+func (s *EventStore[ID]) append(events []domain.Event) error {
+	versions := make(map[string]domain.AggregateVersion)
+	records := make([]fileRecord, len(events))
+	for i, event := range events {
+		ref := event.AggregateRef()
+		if ref == nil {
+			return fmt.Errorf("event %s has nil aggregate reference", event.Name())
+		}
+
+		streamID, err := s.streamID(ref.ID())
+		if err != nil {
+			return err
+		}
+
+		current, ok := versions[streamID]
+		if !ok {
+			current = s.streamVersion(streamID)
+		}
+		if ref.Version() != current+1 {
+			return domain.NewConcurrencyConflictError(ref.ID(), ref.Version()-1, current)
+		}
+		versions[streamID] = ref.Version()
+
+		payload, err := s.cfg.Codec.Encode(event)
+		if err != nil {
+			return fmt.Errorf("could not encode event %s: %w", event.Name(), err)
+		}
+
+		records[i] = fileRecord{
+			Kind:          recordKindEvent,
+			Position:      uint64(s.position) + uint64(i) + 1,
+			AggregateID:   streamID,
+			AggregateName: ref.Name(),
+			Version:       int64(ref.Version()),
+			EventName:     event.Name(),
+			Revision:      domain.EventRevision(event),
+			Timestamp:     event.Timestamp().UTC(),
+			Metadata:      domain.EventMetadata(event),
+			Payload:       payload,
+		}
+	}
+
+	var buf []byte
+	sizes := make([]int64, len(records))
+	for i, rec := range records {
+		encoded, err := encodeRecord(rec)
+		if err != nil {
+			return err
+		}
+		sizes[i] = int64(len(encoded))
+		buf = append(buf, encoded...)
+	}
+
+	if err := s.write(buf); err != nil {
+		return err
+	}
+
+	seg := s.active()
+	offset := seg.size - int64(len(buf))
+	for i, rec := range records {
+		s.applyRecord(rec, seg, offset, sizes[i])
+		offset += sizes[i]
+	}
+
+	return nil
+}
+
+// write appends the buffer to the active segment, rolling it if full,
+// and flushes it according to the sync policy.
+func (s *EventStore[ID]) write(buf []byte) error {
+	if active := s.active(); active.size > 0 && active.size+int64(len(buf)) > s.cfg.MaxSegmentSize {
+		if err := s.roll(); err != nil {
+			return err
+		}
+	}
+
+	active := s.active()
+	if err := active.append(buf); err != nil {
+		return err
+	}
+
+	switch s.cfg.SyncPolicy {
+	case SyncEveryWrite:
+		if err := active.file.Sync(); err != nil {
+			return fmt.Errorf("could not sync segment %s: %w", active.path, err)
+		}
+	case SyncInterval:
+		s.dirty = true
+	case SyncNone:
+	}
+
+	return nil
+}
+
+// roll seals the active segment and starts a new one.
+func (s *EventStore[ID]) roll() error {
+	active := s.active()
+	if err := active.file.Sync(); err != nil {
+		return fmt.Errorf("could not sync segment %s: %w", active.path, err)
+	}
+
+	seg, err := openSegment(s.cfg.Dir, active.id+1)
+	if err != nil {
+		return err
+	}
+
+	s.segments = append(s.segments, seg)
+	s.dirty = false
+	return syncDir(s.cfg.Dir)
+}
+
+func (s *EventStore[ID]) syncLoop() {
+	defer close(s.syncDone)
+
+	ticker := time.NewTicker(s.cfg.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopSync:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.dirty && !s.closed {
+				if err := s.active().file.Sync(); err == nil {
+					s.dirty = false
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *EventStore[ID]) active() *segment {
+	return s.segments[len(s.segments)-1]
+}
+
+func (s *EventStore[ID]) closeSegments() error {
+	var errs []error
+	for _, seg := range s.segments {
+		if err := seg.file.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *EventStore[ID]) streamID(aggregateID any) (string, error) {
+	id, ok := aggregateID.(ID)
+	if !ok {
+		return "", fmt.Errorf("invalid aggregate ID type: %T", aggregateID)
+	}
+	return s.cfg.FormatAggregateID(id), nil
+}
+
+func (s *EventStore[ID]) streamVersion(streamID string) domain.AggregateVersion {
+	entries := s.streams[streamID]
+	if len(entries) == 0 {
+		return 0
+	}
+	return entries[len(entries)-1].version
+}
+
+func (s *EventStore[ID]) readEvents(entries []*indexEntry) ([]domain.Event, error) {
+	events := make([]domain.Event, len(entries))
+	for i, entry := range entries {
+		event, err := s.readEvent(entry)
+		if err != nil {
+			return nil, err
+		}
+		events[i] = event
+	}
+	return events, nil
+}
+
+func (s *EventStore[ID]) readEvent(entry *indexEntry) (domain.Event, error) {
+	rec, err := entry.segment.readAt(entry.offset)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.cfg.ParseAggregateID(rec.AggregateID)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := s.cfg.Codec.Decode(EventRecord{
+		Position:     domain.GlobalPosition(rec.Position),
+		AggregateRef: domain.NewEventAggregateReference(id, rec.AggregateName, domain.AggregateVersion(rec.Version)),
+		Name:         rec.EventName,
+		Revision:     rec.Revision,
+		Timestamp:    rec.Timestamp,
+		Metadata:     rec.Metadata,
+		Payload:      rec.Payload,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not decode event %s: %w", rec.EventName, err)
+	}
+	return event, nil
+}
+
+func matchesCriteria(criteria *domain.SearchCriteriaOptions, entry *indexEntry) bool {
+	if ids := criteria.AggregateIDs(); len(ids) > 0 && !slices.Contains(ids, entry.aggregateID) {
+		return false
+	}
+	if names := criteria.AggregateNames(); len(names) > 0 && !slices.Contains(names, entry.aggregateName) {
+		return false
+	}
+	if versions := criteria.AggregateVersions(); len(versions) > 0 && !slices.Contains(versions, int(entry.version)) {
+		return false
+	}
+	if names := criteria.EventNames(); len(names) > 0 && !slices.Contains(names, entry.eventName) {
+		return false
+	}
+	minVersion, maxVersion := criteria.VersionRange()
+	if (minVersion > 0 && entry.version < minVersion) || (maxVersion > 0 && entry.version > maxVersion) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,376 @@
+package filestore_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xfrr/go-cqrsify/domain"
+	"github.com/xfrr/go-cqrsify/domain/filestore"
+	"github.com/xfrr/go-cqrsify/domain/repositorytest"
+)
+
+func openEventStore(t *testing.T, cfg filestore.Config[string]) *filestore.EventStore[string] {
+	t.Helper()
+
+	store, err := filestore.Open(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func recordEvents(t *testing.T, agg *domain.BaseAggregate[string], names ...string) []domain.Event {
+	t.Helper()
+
+	for _, name := range names {
+		require.NoError(t, domain.NextEvent(agg, domain.NewEvent(name, domain.CreateEventAggregateRef(agg))))
+	}
+	return append([]domain.Event(nil), agg.AggregateEvents()...)
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.seg"))
+	require.NoError(t, err)
+	return files
+}
+
+type fileRepository struct {
+	*domain.EventSourceRepository[string]
+	store *filestore.EventStore[string]
+}
+
+func (r fileRepository) Delete(ctx context.Context, agg domain.EventSourcedAggregate[string]) error {
+	return r.store.DeleteStream(ctx, agg.AggregateID())
+}
+
+func TestEventStore_RepositorySuite(t *testing.T) {
+	repositorytest.RunEventSourcedRepositoryTests(t, func(t *testing.T) repositorytest.Repository {
+		store := openEventStore(t, filestore.Config[string]{Dir: t.TempDir()})
+		return fileRepository{
+			EventSourceRepository: domain.NewEventSourceRepository[string](store),
+			store:                 store,
+		}
+	})
+}
+
+func TestEventStore_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := filestore.Open(filestore.Config[string]{Dir: dir})
+	require.NoError(t, err)
+
+	agg := domain.NewAggregate("order-1", "order")
+	events := recordEvents(t, agg, "order.placed", "order.paid")
+	require.NoError(t, store.Save(ctx, events))
+	require.NoError(t, store.Close())
+
+	_, err = store.RetrieveMany(ctx, "order-1")
+	require.ErrorIs(t, err, filestore.ErrStoreClosed)
+
+	sut := openEventStore(t, filestore.Config[string]{Dir: dir})
+	retrieved, err := sut.RetrieveMany(ctx, "order-1")
+	require.NoError(t, err)
+	require.Len(t, retrieved, 2)
+	for i, evt := range retrieved {
+		assert.Equal(t, events[i].Name(), evt.Name())
+		assert.Equal(t, "order-1", evt.AggregateRef().ID())
+		assert.Equal(t, "order", evt.AggregateRef().Name())
+		assert.Equal(t, domain.AggregateVersion(i+1), evt.AggregateRef().Version())
+		assert.True(t, events[i].Timestamp().Equal(evt.Timestamp()))
+	}
+
+	t.Run("should keep appending after the recovered version", func(t *testing.T) {
+		agg.CommitEvents()
+		events := recordEvents(t, agg, "order.shipped")
+		require.NoError(t, sut.SaveVersioned(ctx, 2, events))
+
+		stored, err := sut.ReadAll(ctx, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, stored, 3)
+		assert.Equal(t, domain.GlobalPosition(3), stored[2].Position)
+	})
+}
+
+func TestEventStore_CrashRecovery(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := filestore.Open(filestore.Config[string]{Dir: dir})
+	require.NoError(t, err)
+	agg := domain.NewAggregate("order-1", "order")
+	require.NoError(t, store.Save(ctx, recordEvents(t, agg, "order.placed", "order.paid")))
+	require.NoError(t, store.Close())
+
+	files := segmentFiles(t, dir)
+	require.Len(t, files, 1)
+	info, err := os.Stat(files[0])
+	require.NoError(t, err)
+
+	// simulate a torn write: a partial record header followed by garbage
+	f, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0x00, 0x00, 0x01, 0x00, 0xde, 0xad})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	sut := openEventStore(t, filestore.Config[string]{Dir: dir})
+
+	recovered, err := os.Stat(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), recovered.Size(), "torn tail must be truncated")
+
+	retrieved, err := sut.RetrieveMany(ctx, "order-1")
+	require.NoError(t, err)
+	require.Len(t, retrieved, 2)
+
+	agg.CommitEvents()
+	require.NoError(t, sut.Save(ctx, recordEvents(t, agg, "order.shipped")))
+	retrieved, err = sut.RetrieveMany(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Len(t, retrieved, 3)
+}
+
+func TestEventStore_Save_ConcurrencyConflict(t *testing.T) {
+	ctx := context.Background()
+	sut := openEventStore(t, filestore.Config[string]{Dir: t.TempDir()})
+
+	agg := domain.NewAggregate("order-1", "order")
+	require.NoError(t, sut.Save(ctx, recordEvents(t, agg, "order.placed")))
+
+	stale := domain.NewAggregate("order-1", "order")
+	err := sut.Save(ctx, recordEvents(t, stale, "order.placed"))
+	require.ErrorIs(t, err, domain.ErrConcurrencyConflict)
+
+	err = sut.SaveVersioned(ctx, 0, recordEvents(t, domain.NewAggregate("order-1", "order"), "order.placed"))
+	require.ErrorIs(t, err, domain.ErrConcurrencyConflict)
+
+	retrieved, err := sut.RetrieveMany(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Len(t, retrieved, 1)
+}
+
+func TestEventStore_SegmentsAndCompaction(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := filestore.Config[string]{
+		Dir:            dir,
+		MaxSegmentSize: 512,
+		SyncPolicy:     filestore.SyncNone,
+	}
+	sut := openEventStore(t, cfg)
+
+	for _, id := range []string{"order-1", "order-2", "order-3"} {
+		agg := domain.NewAggregate(id, "order")
+		require.NoError(t, sut.Save(ctx, recordEvents(t, agg, "order.placed", "order.paid", "order.shipped")))
+	}
+	require.Greater(t, len(segmentFiles(t, dir)), 1, "segments must roll when full")
+
+	require.NoError(t, sut.DeleteStream(ctx, "order-1"))
+	require.NoError(t, sut.DeleteStream(ctx, "order-2"))
+
+	retrieved, err := sut.RetrieveMany(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Empty(t, retrieved)
+
+	before := dirSize(t, dir)
+	require.NoError(t, sut.Compact(ctx))
+	assert.Less(t, dirSize(t, dir), before, "compaction must reclaim deleted records")
+
+	assertStream := func(t *testing.T, store *filestore.EventStore[string]) {
+		t.Helper()
+
+		stored, err := store.ReadAll(ctx, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, stored, 3)
+		for i, evt := range stored {
+			assert.Equal(t, "order-3", evt.Event.AggregateRef().ID())
+			assert.Equal(t, domain.GlobalPosition(7+i), evt.Position, "positions must be stable")
+		}
+	}
+
+	t.Run("should keep live events after compaction", func(t *testing.T) {
+		assertStream(t, sut)
+	})
+
+	t.Run("should recover compacted segments", func(t *testing.T) {
+		require.NoError(t, sut.Close())
+		assertStream(t, openEventStore(t, cfg))
+	})
+}
+
+func TestEventStore_Compact_KeepsPositions(t *testing.T) {
+	ctx := context.Background()
+	cfg := filestore.Config[string]{Dir: t.TempDir()}
+	sut := openEventStore(t, cfg)
+
+	first := domain.NewAggregate("order-1", "order")
+	require.NoError(t, sut.Save(ctx, recordEvents(t, first, "order.placed")))
+	second := domain.NewAggregate("order-2", "order")
+	require.NoError(t, sut.Save(ctx, recordEvents(t, second, "order.placed", "order.paid")))
+
+	require.NoError(t, sut.DeleteStream(ctx, "order-2"))
+	require.NoError(t, sut.Compact(ctx))
+	require.NoError(t, sut.Close())
+
+	reopened := openEventStore(t, cfg)
+	third := domain.NewAggregate("order-3", "order")
+	require.NoError(t, reopened.Save(ctx, recordEvents(t, third, "order.placed")))
+
+	stored, err := reopened.ReadAll(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	assert.Equal(t, domain.GlobalPosition(1), stored[0].Position)
+	assert.Equal(t, domain.GlobalPosition(4), stored[1].Position, "positions of deleted events must not be reissued")
+
+	t.Run("should keep the position across successive compactions", func(t *testing.T) {
+		require.NoError(t, reopened.DeleteStream(ctx, "order-3"))
+		require.NoError(t, reopened.Compact(ctx))
+		require.NoError(t, reopened.DeleteStream(ctx, "order-1"))
+		require.NoError(t, reopened.Compact(ctx))
+		require.NoError(t, reopened.Close())
+
+		store := openEventStore(t, cfg)
+		fourth := domain.NewAggregate("order-4", "order")
+		require.NoError(t, store.Save(ctx, recordEvents(t, fourth, "order.placed")))
+
+		stored, err := store.ReadAll(ctx, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, stored, 1)
+		assert.Equal(t, domain.GlobalPosition(5), stored[0].Position)
+	})
+}
+
+func TestEventStore_TruncateStream(t *testing.T) {
+	ctx := context.Background()
+	cfg := filestore.Config[string]{Dir: t.TempDir()}
+	sut := openEventStore(t, cfg)
+
+	agg := domain.NewAggregate("order-1", "order")
+	require.NoError(t, sut.Save(ctx, recordEvents(t, agg, "order.placed", "order.paid", "order.shipped")))
+	agg.CommitEvents()
+
+	require.NoError(t, sut.TruncateStream(ctx, "order-1", 3))
+
+	t.Run("should hide the truncated events", func(t *testing.T) {
+		retrieved, err := sut.RetrieveMany(ctx, "order-1")
+		require.NoError(t, err)
+		require.Len(t, retrieved, 1)
+		assert.Equal(t, "order.shipped", retrieved[0].Name())
+	})
+
+	t.Run("should keep the stream version", func(t *testing.T) {
+		require.NoError(t, sut.SaveVersioned(ctx, 3, recordEvents(t, agg, "order.delivered")))
+		agg.CommitEvents()
+	})
+
+	t.Run("should recover the truncation", func(t *testing.T) {
+		require.NoError(t, sut.Close())
+		reopened := openEventStore(t, cfg)
+
+		retrieved, err := reopened.RetrieveMany(ctx, "order-1")
+		require.NoError(t, err)
+		require.Len(t, retrieved, 2)
+		assert.Equal(t, domain.AggregateVersion(3), retrieved[0].AggregateRef().Version())
+
+		require.NoError(t, reopened.Compact(ctx))
+		retrieved, err = reopened.RetrieveMany(ctx, "order-1")
+		require.NoError(t, err)
+		require.Len(t, retrieved, 2)
+	})
+}
+
+func TestEventStore_StreamEvents(t *testing.T) {
+	ctx := context.Background()
+	sut := openEventStore(t, filestore.Config[string]{Dir: t.TempDir()})
+
+	agg := domain.NewAggregate("order-1", "order")
+	require.NoError(t, sut.Save(ctx, recordEvents(t, agg, "order.placed", "order.paid", "order.shipped", "order.delivered")))
+
+	var names []string
+	for evt, err := range sut.StreamEvents(ctx, "order-1", domain.RetrieveEventsFromVersion(2), domain.RetrieveEventsBatchSize(2)) {
+		require.NoError(t, err)
+		names = append(names, evt.Name())
+	}
+	assert.Equal(t, []string{"order.paid", "order.shipped", "order.delivered"}, names)
+
+	loaded := domain.NewAggregate("order-1", "order")
+	require.NoError(t, domain.RestoreAggregateFromStream(loaded, sut.StreamEvents(ctx, "order-1", domain.RetrieveEventsBatchSize(3))))
+	assert.Equal(t, domain.AggregateVersion(4), loaded.AggregateVersion())
+}
+
+func TestEventStore_ReadAll(t *testing.T) {
+	ctx := context.Background()
+	sut := openEventStore(t, filestore.Config[string]{Dir: t.TempDir()})
+
+	order := domain.NewAggregate("order-1", "order")
+	customer := domain.NewAggregate("customer-1", "customer")
+	require.NoError(t, sut.Save(ctx, recordEvents(t, order, "order.placed")))
+	require.NoError(t, sut.Save(ctx, recordEvents(t, customer, "customer.registered")))
+	order.CommitEvents()
+	require.NoError(t, sut.Save(ctx, recordEvents(t, order, "order.paid")))
+
+	stored, err := sut.ReadAll(ctx, 2, 0)
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	assert.Equal(t, "customer.registered", stored[0].Event.Name())
+
+	stored, err = sut.ReadAll(ctx, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+
+	stored, err = sut.ReadAll(ctx, 0, 0, domain.ReadAllAggregateNames("order"))
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	assert.Equal(t, domain.GlobalPosition(3), stored[1].Position)
+}
+
+func dirSize(t *testing.T, dir string) int64 {
+	t.Helper()
+
+	var size int64
+	for _, file := range segmentFiles(t, dir) {
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		size += info.Size()
+	}
+	return size
+}
+
+type orderPlaced struct {
+	domain.BaseEvent
+	Amount int `json:"amount"`
+}
+
+func TestEventStore_RegistryCodec(t *testing.T) {
+	ctx := context.Background()
+
+	registry := domain.NewEventRegistry(domain.JSONEventCodec{})
+	require.NoError(t, domain.RegisterEvent[orderPlaced](registry, "order.placed"))
+
+	sut := openEventStore(t, filestore.Config[string]{
+		Dir:   t.TempDir(),
+		Codec: filestore.NewRegistryCodec(registry),
+	})
+
+	ref := domain.NewEventAggregateReference("order-1", "order", 1)
+	require.NoError(t, sut.Save(ctx, []domain.Event{
+		orderPlaced{BaseEvent: domain.NewEvent("order.placed", ref), Amount: 42},
+	}))
+
+	retrieved, err := sut.RetrieveMany(ctx, "order-1")
+	require.NoError(t, err)
+	require.Len(t, retrieved, 1)
+
+	placed, ok := retrieved[0].(orderPlaced)
+	require.True(t, ok, "retrieved event type = %T, want orderPlaced", retrieved[0])
+	assert.Equal(t, 42, placed.Amount)
+	assert.Equal(t, "order-1", placed.AggregateRef().ID())
+	assert.Equal(t, domain.AggregateVersion(1), placed.AggregateRef().Version())
+}
@@ -0,0 +1,225 @@
+package filestore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	segmentExt       = ".seg"
+	compactExt       = ".compact"
+	recordHeaderSize = 8       // 4 bytes body length + 4 bytes body CRC-32C
+	maxRecordSize    = 1 << 30 // defensive limit against corrupted length prefixes
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	ErrCorruptedSegment = errors.New("corrupted segment file")
+	errTornRecord       = errors.New("torn or corrupted record")
+)
+
+type recordKind uint8
+
+const (
+	recordKindEvent     recordKind = 1
+	recordKindTombstone recordKind = 2
+	recordKindTruncate  recordKind = 3
+	// recordKindPosition records the last assigned global position so that it survives
+	// the compaction of the events that carried it.
+	recordKindPosition recordKind = 4
+)
+
+// fileRecord is the body of a length-prefixed, checksummed segment record.
+type fileRecord struct {
+	Kind          recordKind        `json:"k"`
+	Position      uint64            `json:"p,omitempty"`
+	AggregateID   string            `json:"aid"`
+	AggregateName string            `json:"an,omitempty"`
+	Version       int64             `json:"v,omitempty"`
+	EventName     string            `json:"n,omitempty"`
+	Revision      int               `json:"r,omitempty"`
+	Timestamp     time.Time         `json:"t"`
+	Metadata      map[string]string `json:"m,omitempty"`
+	Payload       []byte            `json:"d,omitempty"`
+}
+
+// segment is an append-only file holding a sequence of records.
+type segment struct {
+	id   int
+	path string
+	file *os.File
+	size int64
+}
+
+func segmentPath(dir string, id int) string {
+	return filepath.Join(dir, fmt.Sprintf("%016d%s", id, segmentExt))
+}
+
+func openSegment(dir string, id int) (*segment, error) {
+	path := segmentPath(dir, id)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open segment %s: %w", path, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("could not stat segment %s: %w", path, err)
+	}
+
+	return &segment{id: id, path: path, file: f, size: info.Size()}, nil
+}
+
+// listSegments returns the sorted ids of the segment files found in dir.
+// Leftovers of interrupted compactions are removed.
+func listSegments(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read directory %s: %w", dir, err)
+	}
+
+	ids := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, compactExt) {
+			_ = os.Remove(filepath.Join(dir, name))
+			continue
+		}
+
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+
+		id, err := strconv.Atoi(strings.TrimSuffix(name, segmentExt))
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func encodeRecord(rec fileRecord) ([]byte, error) {
+	body, err := json.Marshal(rec)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode record: %w", err)
+	}
+
+	buf := make([]byte, recordHeaderSize+len(body))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(body)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.Checksum(body, crcTable))
+	copy(buf[recordHeaderSize:], body)
+	return buf, nil
+}
+
+func decodeRecordBody(header, body []byte) (fileRecord, error) {
+	if crc32.Checksum(body, crcTable) != binary.BigEndian.Uint32(header[4:8]) {
+		return fileRecord{}, errTornRecord
+	}
+
+	var rec fileRecord
+	if err := json.Unmarshal(body, &rec); err != nil {
+		return fileRecord{}, errTornRecord
+	}
+	return rec, nil
+}
+
+// readAt reads the record stored at the given offset of the segment.
+func (s *segment) readAt(offset int64) (fileRecord, error) {
+	header := make([]byte, recordHeaderSize)
+	if _, err := s.file.ReadAt(header, offset); err != nil {
+		return fileRecord{}, fmt.Errorf("could not read record header: %w", err)
+	}
+
+	body := make([]byte, binary.BigEndian.Uint32(header[0:4]))
+	if _, err := s.file.ReadAt(body, offset+recordHeaderSize); err != nil {
+		return fileRecord{}, fmt.Errorf("could not read record body: %w", err)
+	}
+
+	rec, err := decodeRecordBody(header, body)
+	if err != nil {
+		return fileRecord{}, fmt.Errorf("%w: %s at offset %d", ErrCorruptedSegment, s.path, offset)
+	}
+	return rec, nil
+}
+
+// scan reads every record of the segment calling fn with the record, its offset and its size.
+// It returns the size of the valid prefix of the segment and errTornRecord if
+// a torn or corrupted record is found after it.
+func (s *segment) scan(fn func(rec fileRecord, offset, size int64)) (int64, error) {
+	r := bufio.NewReader(io.NewSectionReader(s.file, 0, s.size))
+	header := make([]byte, recordHeaderSize)
+
+	var offset int64
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return offset, nil
+			}
+			return offset, errTornRecord
+		}
+
+		length := binary.BigEndian.Uint32(header[0:4])
+		if length > maxRecordSize {
+			return offset, errTornRecord
+		}
+
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return offset, errTornRecord
+		}
+
+		rec, err := decodeRecordBody(header, body)
+		if err != nil {
+			return offset, err
+		}
+
+		size := int64(recordHeaderSize) + int64(length)
+		fn(rec, offset, size)
+		offset += size
+	}
+}
+
+// truncate discards the segment content after the given size.
+func (s *segment) truncate(size int64) error {
+	if err := s.file.Truncate(size); err != nil {
+		return fmt.Errorf("could not truncate segment %s: %w", s.path, err)
+	}
+	s.size = size
+	return s.file.Sync()
+}
+
+// append writes the given bytes at the end of the segment.
+// On failure the segment is truncated back to its previous size.
+func (s *segment) append(buf []byte) error {
+	if _, err := s.file.WriteAt(buf, s.size); err != nil {
+		_ = s.file.Truncate(s.size)
+		return fmt.Errorf("could not write segment %s: %w", s.path, err)
+	}
+	s.size += int64(len(buf))
+	return nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
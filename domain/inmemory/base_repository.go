@@ -14,41 +14,69 @@ type BaseAggregateRepository struct {
 	mu              sync.RWMutex
 	aggregates      []domain.Aggregate[string]
 	aggregatesIndex map[string]domain.Aggregate[string]
+	// tenants holds the tenant owning each aggregate, see domain.ContextWithTenant.
+	tenants map[string]string
 }
 
 func NewBaseAggregateRepository() *BaseAggregateRepository {
 	return &BaseAggregateRepository{
 		aggregates:      make([]domain.Aggregate[string], 0),
 		aggregatesIndex: make(map[string]domain.Aggregate[string]),
+		tenants:         make(map[string]string),
 	}
 }
 
-func (repo *BaseAggregateRepository) Exists(_ context.Context, id string) (bool, error) {
+func (repo *BaseAggregateRepository) Exists(ctx context.Context, id string) (bool, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
 	_, exists := repo.aggregatesIndex[id]
-	return exists, nil
+	if !exists {
+		return false, nil
+	}
+
+	if err := domain.CheckTenant(ctx, repo.tenants[id]); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-func (repo *BaseAggregateRepository) Save(_ context.Context, agg domain.Aggregate[string]) error {
+// Save saves the aggregate, owned by the tenant of the context if any.
+// Saving an aggregate owned by another tenant is rejected.
+func (repo *BaseAggregateRepository) Save(ctx context.Context, agg domain.Aggregate[string]) error {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
+	id := agg.AggregateID()
+	if _, exists := repo.aggregatesIndex[id]; exists {
+		if err := domain.CheckTenant(ctx, repo.tenants[id]); err != nil {
+			return err
+		}
+	}
+
 	repo.aggregates = append(repo.aggregates, agg)
-	repo.aggregatesIndex[agg.AggregateID()] = agg
+	repo.aggregatesIndex[id] = agg
+	if tenantID, ok := domain.TenantFromContext(ctx); ok {
+		repo.tenants[id] = tenantID
+	}
 	return nil
 }
 
 // Delete removes an aggregate by its instance.
-func (repo *BaseAggregateRepository) Delete(_ context.Context, agg domain.Aggregate[string]) error {
+func (repo *BaseAggregateRepository) Delete(ctx context.Context, agg domain.Aggregate[string]) error {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
 	id := agg.AggregateID()
+	if _, exists := repo.aggregatesIndex[id]; exists {
+		if err := domain.CheckTenant(ctx, repo.tenants[id]); err != nil {
+			return err
+		}
+	}
 
 	// Remove from index
 	delete(repo.aggregatesIndex, id)
+	delete(repo.tenants, id)
 
 	// Remove from slice
 	for i, a := range repo.aggregates {
@@ -61,7 +89,7 @@ func (repo *BaseAggregateRepository) Delete(_ context.Context, agg domain.Aggreg
 }
 
 // Load retrieves an aggregate by its ID.
-func (repo *BaseAggregateRepository) Get(_ context.Context, id string) (domain.Aggregate[string], error) {
+func (repo *BaseAggregateRepository) Get(ctx context.Context, id string) (domain.Aggregate[string], error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
@@ -70,24 +98,34 @@ func (repo *BaseAggregateRepository) Get(_ context.Context, id string) (domain.A
 		return nil, domain.NewNotFoundError(id)
 	}
 
+	if err := domain.CheckTenant(ctx, repo.tenants[id]); err != nil {
+		return nil, err
+	}
+
 	return loadedAgg, nil
 }
 
 // Search retrieves aggregates based on the provided search criteria.
-func (repo *BaseAggregateRepository) Search(_ context.Context, criteria *domain.SearchCriteriaOptions) ([]domain.Aggregate[string], error) {
+// Only the aggregate filters apply, as the repository does not keep the aggregate events.
+// The results are sorted and paginated according to the criteria.
+// Given a tenant-scoped context, only the aggregates of the tenant are searched.
+func (repo *BaseAggregateRepository) Search(ctx context.Context, criteria *domain.SearchCriteriaOptions) ([]domain.Aggregate[string], error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
 	var results []domain.Aggregate[string]
 	for _, agg := range repo.aggregates {
-		if criteria.Matches(agg) {
+		if domain.CheckTenant(ctx, repo.tenants[agg.AggregateID()]) != nil {
+			continue
+		}
+		if domain.MatchesAggregate(criteria, agg) {
 			results = append(results, agg)
 		}
 	}
-	return results, nil
+	return domain.SearchAggregates(results, criteria)
 }
 
-func (repo *BaseAggregateRepository) ExistsVersion(_ context.Context, id string, version domain.AggregateVersion) (bool, error) {
+func (repo *BaseAggregateRepository) ExistsVersion(ctx context.Context, id string, version domain.AggregateVersion) (bool, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
@@ -96,6 +134,10 @@ func (repo *BaseAggregateRepository) ExistsVersion(_ context.Context, id string,
 		return false, nil
 	}
 
+	if err := domain.CheckTenant(ctx, repo.tenants[id]); err != nil {
+		return false, err
+	}
+
 	versionedAgg, ok := loadedAgg.(domain.VersionedAggregate[string])
 	if !ok {
 		return false, nil
@@ -108,7 +150,7 @@ func (repo *BaseAggregateRepository) ExistsVersion(_ context.Context, id string,
 	return true, nil
 }
 
-func (repo *BaseAggregateRepository) GetVersion(_ context.Context, id string, version domain.AggregateVersion) (domain.VersionedAggregate[string], error) {
+func (repo *BaseAggregateRepository) GetVersion(ctx context.Context, id string, version domain.AggregateVersion) (domain.VersionedAggregate[string], error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
@@ -117,6 +159,10 @@ func (repo *BaseAggregateRepository) GetVersion(_ context.Context, id string, ve
 		return nil, domain.NewNotFoundError(id)
 	}
 
+	if err := domain.CheckTenant(ctx, repo.tenants[id]); err != nil {
+		return nil, err
+	}
+
 	versionedAgg, ok := loadedAgg.(domain.VersionedAggregate[string])
 	if !ok {
 		return nil, domain.NewNotFoundError(id)
@@ -3,54 +3,64 @@ package inmemory
 import (
 	"context"
 	"errors"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/xfrr/go-cqrsify/domain"
 )
 
 var _ domain.EventSourcedRepository[domain.EventSourcedAggregate[string], string] = (*EventSourcedAggregateRepository)(nil)
+var _ domain.EventStreamReader = (*EventSourcedAggregateRepository)(nil)
+var _ domain.EventHistoryDiffer[domain.EventSourcedAggregate[string], string] = (*EventSourcedAggregateRepository)(nil)
+var _ domain.StreamDeleter[string] = (*EventSourcedAggregateRepository)(nil)
+var _ domain.StreamTruncater[string] = (*EventSourcedAggregateRepository)(nil)
 
 var (
 	ErrInvalidAggregateEventID = errors.New("invalid aggregate event id")
 )
 
 type eventSourcedAggregateDTO struct {
-	id      string
-	name    string
-	version int
-	events  []domain.Event
+	id       string
+	name     string
+	version  int
+	events   []domain.Event
+	tenantID string
 }
 
+// EventSourcedAggregateRepository is an in-memory event sourced repository keyed by string aggregate IDs.
+// Use domain.TypedEventSourcedRepository over a domain.EventStore[ID] for other ID types.
 type EventSourcedAggregateRepository struct {
 	mu sync.RWMutex
 
 	dtosIndex map[string]*eventSourcedAggregateDTO
-	events    []domain.Event
+	events    []domain.StoredEvent
+	position  domain.GlobalPosition
 }
 
 func NewEventSourcedAggregateRepository() *EventSourcedAggregateRepository {
 	return &EventSourcedAggregateRepository{
 		mu:        sync.RWMutex{},
 		dtosIndex: make(map[string]*eventSourcedAggregateDTO),
-		events:    make([]domain.Event, 0),
+		events:    make([]domain.StoredEvent, 0),
 	}
 }
 
-func (repo *EventSourcedAggregateRepository) Exists(_ context.Context, agg domain.EventSourcedAggregate[string]) (bool, error) {
+func (repo *EventSourcedAggregateRepository) Exists(ctx context.Context, agg domain.EventSourcedAggregate[string]) (bool, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
-	_, ok := repo.dtosIndex[agg.AggregateID()]
-	return ok, nil
+	_, ok, err := repo.lookup(ctx, agg.AggregateID())
+	return ok, err
 }
 
-func (repo *EventSourcedAggregateRepository) ExistsVersion(_ context.Context, agg domain.EventSourcedAggregate[string], version domain.AggregateVersion) (bool, error) {
+func (repo *EventSourcedAggregateRepository) ExistsVersion(ctx context.Context, agg domain.EventSourcedAggregate[string], version domain.AggregateVersion) (bool, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
-	dto, ok := repo.dtosIndex[agg.AggregateID()]
-	if !ok {
-		return false, nil
+	dto, ok, err := repo.lookup(ctx, agg.AggregateID())
+	if !ok || err != nil {
+		return false, err
 	}
 
 	if dto.version < int(version) {
@@ -60,23 +70,37 @@ func (repo *EventSourcedAggregateRepository) ExistsVersion(_ context.Context, ag
 	return true, nil
 }
 
-func (repo *EventSourcedAggregateRepository) Load(_ context.Context, agg domain.EventSourcedAggregate[string]) error {
+func (repo *EventSourcedAggregateRepository) Load(ctx context.Context, agg domain.EventSourcedAggregate[string]) error {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
-	dto, ok := repo.dtosIndex[agg.AggregateID()]
+	return repo.load(ctx, agg)
+}
+
+func (repo *EventSourcedAggregateRepository) load(ctx context.Context, agg domain.EventSourcedAggregate[string]) error {
+	dto, ok, err := repo.lookup(ctx, agg.AggregateID())
+	if err != nil {
+		return err
+	}
 	if !ok {
 		return domain.NewNotFoundError(agg.AggregateID())
 	}
 
+	if n := len(dto.events); n > 0 && domain.IsStreamDeletedEvent(dto.events[n-1]) {
+		return domain.NewStreamDeletedError(agg.AggregateID(), dto.events[n-1].AggregateRef().Version())
+	}
+
 	return domain.RestoreAggregateFromHistory(agg, dto.events)
 }
 
-func (repo *EventSourcedAggregateRepository) LoadVersion(_ context.Context, agg domain.EventSourcedAggregate[string], version domain.AggregateVersion) error {
+func (repo *EventSourcedAggregateRepository) LoadVersion(ctx context.Context, agg domain.EventSourcedAggregate[string], version domain.AggregateVersion) error {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
-	dto, ok := repo.dtosIndex[agg.AggregateID()]
+	dto, ok, err := repo.lookup(ctx, agg.AggregateID())
+	if err != nil {
+		return err
+	}
 	if !ok {
 		return domain.NewNotFoundError(agg.AggregateID())
 	}
@@ -88,27 +112,110 @@ func (repo *EventSourcedAggregateRepository) LoadVersion(_ context.Context, agg
 	return domain.RestoreAggregateFromHistory(agg, filterEventsFromVersion(version, dto.events))
 }
 
+func (repo *EventSourcedAggregateRepository) LoadAsOf(ctx context.Context, agg domain.EventSourcedAggregate[string], asOf time.Time) error {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	dto, ok, err := repo.lookup(ctx, agg.AggregateID())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.NewNotFoundError(agg.AggregateID())
+	}
+
+	history := domain.History(dto.events).AsOf(asOf)
+	if len(history) == 0 {
+		return domain.NewNotFoundError(agg.AggregateID())
+	}
+
+	if last := history[len(history)-1]; domain.IsStreamDeletedEvent(last) {
+		return domain.NewStreamDeletedError(agg.AggregateID(), last.AggregateRef().Version())
+	}
+
+	return domain.RestoreAggregateFromHistory(agg, history)
+}
+
+// DiffVersions returns the events that turn the aggregate at version from into the aggregate at version to.
+// It implements the domain.EventHistoryDiffer interface.
+func (repo *EventSourcedAggregateRepository) DiffVersions(
+	ctx context.Context,
+	agg domain.EventSourcedAggregate[string],
+	from, to domain.AggregateVersion,
+) (domain.History, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	dto, ok, err := repo.lookup(ctx, agg.AggregateID())
+	if !ok || err != nil {
+		return domain.History{}, err
+	}
+
+	return domain.History(dto.events).BetweenVersions(from, to), nil
+}
+
+// DiffTimes returns the events that turn the aggregate as of from into the aggregate as of to.
+// It implements the domain.EventHistoryDiffer interface.
+func (repo *EventSourcedAggregateRepository) DiffTimes(
+	ctx context.Context,
+	agg domain.EventSourcedAggregate[string],
+	from, to time.Time,
+) (domain.History, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	dto, ok, err := repo.lookup(ctx, agg.AggregateID())
+	if !ok || err != nil {
+		return domain.History{}, err
+	}
+
+	return domain.History(dto.events).BetweenTimes(from, to), nil
+}
+
+// Save appends the uncommitted events of the aggregate to its stream.
+// The events are stamped with the event metadata carried by ctx, see domain.ContextWithEventMetadata.
+// Given a tenant-scoped context, the events must belong to the tenant and so must the stream.
 func (repo *EventSourcedAggregateRepository) Save(ctx context.Context, agg domain.EventSourcedAggregate[string]) error {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
-	return repo.saveEvents(agg)
+	return repo.saveEvents(ctx, agg)
 }
 
-func (repo *EventSourcedAggregateRepository) saveEvents(agg domain.EventSourcedAggregate[string]) error {
-	dto, ok := repo.dtosIndex[agg.AggregateID()]
+func (repo *EventSourcedAggregateRepository) saveEvents(ctx context.Context, agg domain.EventSourcedAggregate[string]) error {
+	dto, ok, err := repo.lookup(ctx, agg.AggregateID())
+	if err != nil {
+		return err
+	}
 	if !ok {
+		tenantID, _ := domain.TenantFromContext(ctx)
 		dto = &eventSourcedAggregateDTO{
-			id:      agg.AggregateID(),
-			name:    agg.AggregateName(),
-			version: 0,
-			events:  make([]domain.Event, 0),
+			id:       agg.AggregateID(),
+			name:     agg.AggregateName(),
+			version:  0,
+			events:   make([]domain.Event, 0),
+			tenantID: tenantID,
+		}
+	}
+
+	events := domain.StampEventsMetadataFromContext(ctx, agg.AggregateEvents())
+	for _, event := range events {
+		if err := domain.CheckEventTenant(ctx, event); err != nil {
+			return err
 		}
 	}
 
+	// optimistic concurrency check
+	if dto.version != int(agg.AggregateVersion()) {
+		return domain.NewConcurrencyConflictError(
+			agg.AggregateID(),
+			agg.AggregateVersion(),
+			domain.AggregateVersion(dto.version),
+		)
+	}
+
 	// copy the aggregate events
-	events := make([]domain.Event, len(agg.AggregateEvents()))
-	copy(events, agg.AggregateEvents())
+	events = slices.Clone(events)
 	if c, ok := agg.(domain.EventCommitter); ok {
 		c.CommitEvents()
 	}
@@ -120,79 +227,143 @@ func (repo *EventSourcedAggregateRepository) saveEvents(agg domain.EventSourcedA
 	dto.events = append(dto.events, events...)
 	repo.dtosIndex[agg.AggregateID()] = dto
 
-	// append the events to the repository
-	repo.events = append(repo.events, events...)
+	// append the events to the global stream
+	for _, event := range events {
+		repo.position++
+		repo.events = append(repo.events, domain.StoredEvent{
+			Position: repo.position,
+			Event:    event,
+		})
+	}
 
 	return nil
 }
 
-func (repo *EventSourcedAggregateRepository) Search(_ context.Context, criteria *domain.SearchCriteriaOptions) ([]domain.EventSourcedAggregate[string], error) {
+// Search returns the aggregates with at least one event matching the filters of the given criteria.
+// The aggregates are restored from their whole stream, then sorted and paginated.
+// Soft-deleted aggregates are excluded.
+// Given a tenant-scoped context, only the aggregates of the tenant are searched.
+func (repo *EventSourcedAggregateRepository) Search(ctx context.Context, criteria *domain.SearchCriteriaOptions) ([]domain.EventSourcedAggregate[string], error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
-	events := repo.events
-	if len(events) == 0 {
+	var filters *domain.SearchCriteriaOptions
+	if criteria != nil {
+		filters = criteria.Filters()
+	}
+	filters = domain.TenantSearchCriteria(ctx, filters)
+
+	stored, err := domain.SearchStoredEvents(repo.events, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stored) == 0 {
 		return nil, nil
 	}
 
-	if criteria == nil || criteria.IsEmpty() {
-		return getAggregates(events)
+	newAggregate := func(id, name string) domain.EventSourcedAggregate[string] {
+		return domain.NewAggregate(id, name)
 	}
+	load := func(agg domain.EventSourcedAggregate[string]) error {
+		return repo.load(ctx, agg)
+	}
+	return domain.SearchEventSourcedAggregates(unwrapStoredEvents(stored), criteria, newAggregate, load)
+}
 
-	events = filterEventsByAggregateIDs(criteria.AggregateIDs(), events)
-	events = filterEventsByAggregateNames(criteria.AggregateNames(), events)
-	events = filterEventsByAggregateVersions(criteria.AggregateVersions(), events)
-	return getAggregates(events)
+// Delete physically removes the events of the given aggregate.
+func (repo *EventSourcedAggregateRepository) Delete(ctx context.Context, agg domain.EventSourcedAggregate[string]) error {
+	return repo.DeleteStream(ctx, agg.AggregateID())
 }
 
-func (repo *EventSourcedAggregateRepository) Delete(_ context.Context, agg domain.EventSourcedAggregate[string]) error {
+// SoftDelete appends a tombstone event to the stream of the given aggregate.
+// The aggregate can no longer be loaded and is excluded from the search results.
+func (repo *EventSourcedAggregateRepository) SoftDelete(ctx context.Context, agg domain.EventSourcedAggregate[string], opts ...domain.EventOption) error {
+	if tenantID, ok := domain.TenantFromContext(ctx); ok {
+		opts = append(opts, domain.WithEventTenant(tenantID))
+	}
+
+	if err := domain.SoftDelete(agg, opts...); err != nil {
+		return err
+	}
+
+	return repo.Save(ctx, agg)
+}
+
+// DeleteStream physically removes the events of the given aggregate.
+// It implements the domain.StreamDeleter interface.
+func (repo *EventSourcedAggregateRepository) DeleteStream(ctx context.Context, aggregateID string) error {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
-	repo.deleteAggregate(agg.AggregateID())
+	if _, _, err := repo.lookup(ctx, aggregateID); err != nil {
+		return err
+	}
+
+	repo.deleteAggregate(aggregateID)
 	return nil
 }
 
-func (repo *EventSourcedAggregateRepository) deleteAggregate(aggID string) {
-	for idx, event := range repo.events {
-		aggregateRef := event.AggregateRef()
-		if aggregateRef.ID() == aggID {
-			repo.events = append(repo.events[:idx], repo.events[idx+1:]...)
-		}
+// TruncateStream physically removes the events of the given aggregate with a version lower than beforeVersion.
+// As the repository does not keep snapshots, the aggregate can no longer be loaded
+// from a truncated stream; only the events left can be read and searched.
+// It implements the domain.StreamTruncater interface.
+func (repo *EventSourcedAggregateRepository) TruncateStream(ctx context.Context, aggregateID string, beforeVersion domain.AggregateVersion) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	dto, ok, err := repo.lookup(ctx, aggregateID)
+	if !ok || err != nil {
+		return err
 	}
 
-	delete(repo.dtosIndex, aggID)
-}
+	truncated := func(event domain.Event) bool {
+		ref := event.AggregateRef()
+		return ref.ID() == aggregateID && ref.Version() < beforeVersion
+	}
 
-func getAggregates(events []domain.Event) ([]domain.EventSourcedAggregate[string], error) {
-	var (
-		aggregates     = make([]domain.EventSourcedAggregate[string], 0)
-		aggregateIndex = make(map[string]domain.EventSourcedAggregate[string])
-	)
+	dto.events = slices.DeleteFunc(dto.events, truncated)
+	repo.events = slices.DeleteFunc(repo.events, func(stored domain.StoredEvent) bool {
+		return truncated(stored.Event)
+	})
+	return nil
+}
 
-	for _, event := range events {
-		aggregateRef := event.AggregateRef()
-		aggregateID, ok := aggregateRef.ID().(string)
-		if !ok {
-			return nil, ErrInvalidAggregateEventID
-		}
+func (repo *EventSourcedAggregateRepository) deleteAggregate(aggID string) {
+	repo.events = slices.DeleteFunc(repo.events, func(stored domain.StoredEvent) bool {
+		return stored.Event.AggregateRef().ID() == aggID
+	})
 
-		agg, ok := aggregateIndex[aggregateID]
-		if !ok {
-			agg = domain.NewAggregate(aggregateID, aggregateRef.Name())
-		}
+	delete(repo.dtosIndex, aggID)
+}
 
-		err := domain.RestoreAggregateFromHistory(agg, []domain.Event{event})
-		if err != nil {
-			return nil, err
-		}
+// ReadAll reads up to batchSize events, in commit order, starting from the given position (inclusive).
+// Given a tenant-scoped context, only the events of the tenant are read.
+// It implements the domain.EventStreamReader interface.
+func (repo *EventSourcedAggregateRepository) ReadAll(
+	ctx context.Context,
+	fromPosition domain.GlobalPosition,
+	batchSize int,
+	opts ...domain.ReadAllOption,
+) ([]domain.StoredEvent, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
 
-		aggregateIndex[aggregateID] = agg
+	matches := func(event domain.Event) bool {
+		return domain.CheckEventTenant(ctx, event) == nil
 	}
+	return readStoredEvents(repo.events, fromPosition, batchSize, domain.NewReadAllOptions(opts...), matches), nil
+}
 
-	for _, agg := range aggregateIndex {
-		aggregates = append(aggregates, agg)
+// lookup returns the aggregate with the given ID, checking that it belongs to the tenant of the context.
+func (repo *EventSourcedAggregateRepository) lookup(ctx context.Context, aggregateID string) (*eventSourcedAggregateDTO, bool, error) {
+	dto, ok := repo.dtosIndex[aggregateID]
+	if !ok {
+		return nil, false, nil
 	}
 
-	return aggregates, nil
+	if err := domain.CheckTenant(ctx, dto.tenantID); err != nil {
+		return nil, false, err
+	}
+	return dto, true, nil
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/xfrr/go-cqrsify/domain"
 
 	inmemory "github.com/xfrr/go-cqrsify/domain/inmemory"
+	"github.com/xfrr/go-cqrsify/domain/repositorytest"
 )
 
 func TestNewRepository(t *testing.T) {
@@ -34,6 +35,53 @@ func TestInMemory_Delete(t *testing.T) {
 	require.NoError(t, sut.Delete(ctx, agg), "Delete() error = %v, want nil")
 }
 
+func TestInMemory_SoftDelete(t *testing.T) {
+	sut := inmemory.NewEventSourcedAggregateRepository()
+	ctx := context.Background()
+
+	agg := domain.NewAggregate("1", "test")
+	require.NoError(t, domain.NextEvent(agg, domain.NewEvent("cname", domain.CreateEventAggregateRef(agg))))
+	require.NoError(t, sut.Save(ctx, agg))
+
+	require.NoError(t, sut.SoftDelete(ctx, agg))
+	assert.Equal(t, domain.AggregateVersion(2), agg.AggregateVersion())
+
+	err := sut.Load(ctx, domain.NewAggregate("1", "test"))
+	require.ErrorIs(t, err, domain.ErrStreamDeleted)
+
+	aggs, err := sut.Search(ctx, domain.SearchCriteria().WithSearchAggregateIDs("1"))
+	require.NoError(t, err)
+	assert.Empty(t, aggs)
+
+	// the events are kept in the global stream
+	stored, err := sut.ReadAll(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	assert.True(t, domain.IsStreamDeletedEvent(stored[1].Event))
+}
+
+func TestInMemory_TruncateStream(t *testing.T) {
+	sut := inmemory.NewEventSourcedAggregateRepository()
+	ctx := context.Background()
+
+	agg := domain.NewAggregate("1", "test")
+	for range 3 {
+		require.NoError(t, domain.NextEvent(agg, domain.NewEvent("cname", domain.CreateEventAggregateRef(agg))))
+	}
+	require.NoError(t, sut.Save(ctx, agg))
+
+	require.NoError(t, sut.TruncateStream(ctx, "1", 3))
+
+	stored, err := sut.ReadAll(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, domain.AggregateVersion(3), stored[0].Event.AggregateRef().Version())
+
+	// the stream version is kept
+	require.NoError(t, domain.NextEvent(agg, domain.NewEvent("cname", domain.CreateEventAggregateRef(agg))))
+	require.NoError(t, sut.Save(ctx, agg))
+}
+
 func TestInMemory_Exists(t *testing.T) {
 	sut := inmemory.NewEventSourcedAggregateRepository()
 	ctx := context.Background()
@@ -194,6 +242,170 @@ func TestInMemory_Save(t *testing.T) {
 	require.NoError(t, err, "Save() error = %v, want nil", err)
 }
 
+func TestInMemory_Save_ConcurrencyConflict(t *testing.T) {
+	sut := inmemory.NewEventSourcedAggregateRepository()
+	ctx := context.Background()
+
+	agg := domain.NewAggregate("1", "test")
+	require.NoError(t, domain.NextEvent(agg, domain.NewEvent("cname", domain.CreateEventAggregateRef(agg))))
+	require.NoError(t, sut.Save(ctx, agg))
+
+	// two writers load the same aggregate version
+	writer1 := domain.NewAggregate("1", "test")
+	require.NoError(t, sut.Load(ctx, writer1))
+	writer2 := domain.NewAggregate("1", "test")
+	require.NoError(t, sut.Load(ctx, writer2))
+
+	require.NoError(t, domain.NextEvent(writer1, domain.NewEvent("cname", domain.CreateEventAggregateRef(writer1))))
+	require.NoError(t, sut.Save(ctx, writer1))
+
+	require.NoError(t, domain.NextEvent(writer2, domain.NewEvent("cname", domain.CreateEventAggregateRef(writer2))))
+	err := sut.Save(ctx, writer2)
+	require.ErrorIs(t, err, domain.ErrConcurrencyConflict)
+
+	var conflictErr domain.ConcurrencyConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, "1", conflictErr.AggregateID)
+	assert.Equal(t, domain.AggregateVersion(1), conflictErr.ExpectedVersion)
+	assert.Equal(t, domain.AggregateVersion(2), conflictErr.ActualVersion)
+	assert.Len(t, writer2.AggregateEvents(), 1, "expected uncommitted events to be kept on conflict")
+}
+
+func TestInMemory_ReadAll(t *testing.T) {
+	ctx := context.Background()
+	sut := inmemory.NewEventSourcedAggregateRepository()
+
+	order := domain.NewAggregate("order-1", "order")
+	customer := domain.NewAggregate("customer-1", "customer")
+	require.NoError(t, domain.NextEvent(order, domain.NewEvent("order.placed", domain.CreateEventAggregateRef(order))))
+	require.NoError(t, sut.Save(ctx, order))
+	require.NoError(t, domain.NextEvent(customer, domain.NewEvent("customer.registered", domain.CreateEventAggregateRef(customer))))
+	require.NoError(t, sut.Save(ctx, customer))
+	require.NoError(t, domain.NextEvent(order, domain.NewEvent("order.paid", domain.CreateEventAggregateRef(order))))
+	require.NoError(t, domain.NextEvent(order, domain.NewEvent("order.shipped", domain.CreateEventAggregateRef(order))))
+	require.NoError(t, sut.Save(ctx, order))
+
+	t.Run("should read all the events in commit order", func(t *testing.T) {
+		events, err := sut.ReadAll(ctx, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, events, 4)
+
+		expectedNames := []string{"order.placed", "customer.registered", "order.paid", "order.shipped"}
+		for i, se := range events {
+			assert.Equal(t, domain.GlobalPosition(i+1), se.Position)
+			assert.Equal(t, expectedNames[i], se.Event.Name())
+		}
+	})
+
+	t.Run("should read in batches from the given position", func(t *testing.T) {
+		events, err := sut.ReadAll(ctx, 2, 2)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, domain.GlobalPosition(2), events[0].Position)
+		assert.Equal(t, domain.GlobalPosition(3), events[1].Position)
+
+		events, err = sut.ReadAll(ctx, 5, 2)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("should filter by aggregate and event names", func(t *testing.T) {
+		events, err := sut.ReadAll(ctx, 0, 0, domain.ReadAllAggregateNames("order"))
+		require.NoError(t, err)
+		require.Len(t, events, 3)
+
+		events, err = sut.ReadAll(ctx, 0, 0, domain.ReadAllEventNames("order.paid", "customer.registered"))
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, domain.GlobalPosition(2), events[0].Position)
+		assert.Equal(t, domain.GlobalPosition(3), events[1].Position)
+	})
+
+	t.Run("should keep positions after deleting an aggregate", func(t *testing.T) {
+		require.NoError(t, sut.Delete(ctx, customer))
+
+		events, err := sut.ReadAll(ctx, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, events, 3)
+		assert.Equal(t, domain.GlobalPosition(3), events[1].Position)
+	})
+}
+
+func TestInMemory_TenantIsolation(t *testing.T) {
+	sut := inmemory.NewEventSourcedAggregateRepository()
+	acme := domain.ContextWithTenant(context.Background(), "acme")
+	globex := domain.ContextWithTenant(context.Background(), "globex")
+
+	for ctx, id := range map[context.Context]string{acme: "acme-1", globex: "globex-1"} {
+		agg := domain.NewAggregate(id, "order")
+		require.NoError(t, domain.NextEvent(agg, domain.NewEvent("order.placed", domain.CreateEventAggregateRef(agg), domain.WithEventMetadataFromContext(ctx))))
+		require.NoError(t, sut.Save(ctx, agg))
+	}
+
+	t.Run("should reject cross-tenant access", func(t *testing.T) {
+		require.NoError(t, sut.Load(acme, domain.NewAggregate("acme-1", "order")))
+		require.ErrorIs(t, sut.Load(acme, domain.NewAggregate("globex-1", "order")), domain.ErrTenantMismatch)
+
+		_, err := sut.Exists(acme, domain.NewAggregate("globex-1", "order"))
+		require.ErrorIs(t, err, domain.ErrTenantMismatch)
+		require.ErrorIs(t, sut.DeleteStream(acme, "globex-1"), domain.ErrTenantMismatch)
+	})
+
+	t.Run("should reject events of another tenant", func(t *testing.T) {
+		agg := domain.NewAggregate("acme-2", "order")
+		require.NoError(t, domain.NextEvent(agg, domain.NewEvent("order.placed", domain.CreateEventAggregateRef(agg), domain.WithEventTenant("globex"))))
+		require.ErrorIs(t, sut.Save(acme, agg), domain.ErrTenantMismatch)
+	})
+
+	t.Run("should search and read the events of the tenant", func(t *testing.T) {
+		aggs, err := sut.Search(globex, domain.SearchCriteria())
+		require.NoError(t, err)
+		require.Len(t, aggs, 1)
+		assert.Equal(t, "globex-1", aggs[0].AggregateID())
+
+		stored, err := sut.ReadAll(globex, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, stored, 1)
+		assert.Equal(t, "globex", domain.EventTenantID(stored[0].Event))
+
+		stored, err = sut.ReadAll(context.Background(), 0, 0)
+		require.NoError(t, err)
+		assert.Len(t, stored, 2)
+	})
+
+	t.Run("should stamp the events with the tenant of the context", func(t *testing.T) {
+		agg := domain.NewAggregate("acme-3", "order")
+		require.NoError(t, domain.NextEvent(agg, domain.NewEvent("order.placed", domain.CreateEventAggregateRef(agg))))
+		require.NoError(t, sut.Save(acme, agg))
+
+		stored, err := sut.ReadAll(acme, 0, 0, domain.ReadAllAggregateNames("order"))
+		require.NoError(t, err)
+		require.Len(t, stored, 2)
+		assert.Equal(t, "acme-3", stored[1].Event.AggregateRef().ID())
+		assert.Equal(t, "acme", domain.EventTenantID(stored[1].Event))
+	})
+}
+
+func TestBaseAggregateRepository_TenantIsolation(t *testing.T) {
+	sut := inmemory.NewBaseAggregateRepository()
+	acme := domain.ContextWithTenant(context.Background(), "acme")
+	globex := domain.ContextWithTenant(context.Background(), "globex")
+
+	require.NoError(t, sut.Save(acme, domain.NewAggregate("acme-1", "order")))
+	require.NoError(t, sut.Save(globex, domain.NewAggregate("globex-1", "order")))
+
+	_, err := sut.Get(acme, "acme-1")
+	require.NoError(t, err)
+	_, err = sut.Get(acme, "globex-1")
+	require.ErrorIs(t, err, domain.ErrTenantMismatch)
+	require.ErrorIs(t, sut.Save(acme, domain.NewAggregate("globex-1", "order")), domain.ErrTenantMismatch)
+
+	aggs, err := sut.Search(acme, domain.SearchCriteria())
+	require.NoError(t, err)
+	require.Len(t, aggs, 1)
+	assert.Equal(t, "acme-1", aggs[0].AggregateID())
+}
+
 func newEventSourcedAggregateRepositoryWithAggregates(t *testing.T, aggregates ...domain.EventSourcedAggregate[string]) *inmemory.EventSourcedAggregateRepository {
 	repo := inmemory.NewEventSourcedAggregateRepository()
 	for _, agg := range aggregates {
@@ -214,3 +426,9 @@ func newEventSourcedAggregateRepositoryWithAggregates(t *testing.T, aggregates .
 type testEventPayload struct {
 	id string
 }
+
+func TestInMemory_RepositorySuite(t *testing.T) {
+	repositorytest.RunEventSourcedRepositoryTests(t, func(_ *testing.T) repositorytest.Repository {
+		return inmemory.NewEventSourcedAggregateRepository()
+	})
+}
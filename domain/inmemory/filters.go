@@ -1,7 +1,7 @@
 package inmemory
 
 import (
-	"slices"
+	"sort"
 
 	"github.com/xfrr/go-cqrsify/domain"
 )
@@ -18,52 +18,36 @@ func filterEventsFromVersion(version domain.AggregateVersion, events []domain.Ev
 	return filtered
 }
 
-func filterEventsByAggregateIDs(aggIDs []string, events []domain.Event) []domain.Event {
-	if len(aggIDs) == 0 {
-		return events
+func unwrapStoredEvents(stored []domain.StoredEvent) []domain.Event {
+	events := make([]domain.Event, len(stored))
+	for i, se := range stored {
+		events[i] = se.Event
 	}
 
-	filtered := make([]domain.Event, 0)
-	for _, ev := range events {
-		aggregateRef := ev.AggregateRef()
-
-		aggID, _ := aggregateRef.ID().(string)
-		if slices.Contains(aggIDs, aggID) {
-			filtered = append(filtered, ev)
-		}
-	}
-
-	return filtered
+	return events
 }
 
-func filterEventsByAggregateNames(aggNames []string, events []domain.Event) []domain.Event {
-	if len(aggNames) == 0 {
-		return events
-	}
-
-	filtered := make([]domain.Event, 0)
-	for _, ev := range events {
-		aggregateRef := ev.AggregateRef()
-		if slices.Contains(aggNames, aggregateRef.Name()) {
-			filtered = append(filtered, ev)
+func readStoredEvents(
+	stored []domain.StoredEvent,
+	fromPosition domain.GlobalPosition,
+	batchSize int,
+	opts domain.ReadAllOptions,
+	matches func(domain.Event) bool,
+) []domain.StoredEvent {
+	start := sort.Search(len(stored), func(i int) bool {
+		return stored[i].Position >= fromPosition
+	})
+
+	result := make([]domain.StoredEvent, 0)
+	for _, se := range stored[start:] {
+		if batchSize > 0 && len(result) >= batchSize {
+			break
 		}
-	}
-
-	return filtered
-}
 
-func filterEventsByAggregateVersions(aggVersions []int, events []domain.Event) []domain.Event {
-	if len(aggVersions) == 0 {
-		return events
-	}
-
-	filtered := make([]domain.Event, 0)
-	for _, ev := range events {
-		aggregateRef := ev.AggregateRef()
-		if slices.Contains(aggVersions, int(aggregateRef.Version())) {
-			filtered = append(filtered, ev)
+		if opts.Matches(se.Event) && matches(se.Event) {
+			result = append(result, se)
 		}
 	}
 
-	return filtered
+	return result
 }
@@ -0,0 +1,76 @@
+package inmemory
+
+import (
+	"context"
+	"crypto/rand"
+	"slices"
+	"sync"
+
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+var _ domain.KeyStore = (*KeyStore)(nil)
+
+// keySize is the size of the generated keys, selecting AES-256.
+const keySize = 32
+
+// KeyStore is an in-memory implementation of domain.KeyStore.
+// It remembers the shredded subjects so that their keys are never created again.
+type KeyStore struct {
+	mu       sync.RWMutex
+	keys     map[string][]byte
+	shredded map[string]struct{}
+}
+
+func NewKeyStore() *KeyStore {
+	return &KeyStore{
+		keys:     make(map[string][]byte),
+		shredded: make(map[string]struct{}),
+	}
+}
+
+func (s *KeyStore) CreateKey(_ context.Context, subjectID string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.shredded[subjectID]; ok {
+		return nil, domain.ErrKeyShredded
+	}
+
+	if key, ok := s.keys[subjectID]; ok {
+		return slices.Clone(key), nil
+	}
+
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	s.keys[subjectID] = key
+	return slices.Clone(key), nil
+}
+
+func (s *KeyStore) Key(_ context.Context, subjectID string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.shredded[subjectID]; ok {
+		return nil, domain.ErrKeyShredded
+	}
+
+	key, ok := s.keys[subjectID]
+	if !ok {
+		return nil, domain.ErrKeyNotFound
+	}
+
+	return slices.Clone(key), nil
+}
+
+func (s *KeyStore) DeleteKey(_ context.Context, subjectID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, subjectID)
+	s.shredded[subjectID] = struct{}{}
+	return nil
+}
@@ -0,0 +1,46 @@
+package inmemory_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xfrr/go-cqrsify/domain"
+
+	inmemory "github.com/xfrr/go-cqrsify/domain/inmemory"
+)
+
+func TestKeyStore(t *testing.T) {
+	ctx := context.Background()
+	sut := inmemory.NewKeyStore()
+
+	t.Run("should return ErrKeyNotFound when the subject has no key", func(t *testing.T) {
+		_, err := sut.Key(ctx, "unknown")
+		require.ErrorIs(t, err, domain.ErrKeyNotFound)
+	})
+
+	t.Run("should create the key once", func(t *testing.T) {
+		created, err := sut.CreateKey(ctx, "1")
+		require.NoError(t, err)
+		assert.Len(t, created, 32)
+
+		again, err := sut.CreateKey(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, created, again)
+
+		key, err := sut.Key(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, created, key)
+	})
+
+	t.Run("should never recreate a deleted key", func(t *testing.T) {
+		require.NoError(t, sut.DeleteKey(ctx, "1"))
+
+		_, err := sut.Key(ctx, "1")
+		require.ErrorIs(t, err, domain.ErrKeyShredded)
+
+		_, err = sut.CreateKey(ctx, "1")
+		require.ErrorIs(t, err, domain.ErrKeyShredded)
+	})
+}
@@ -0,0 +1,69 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+var (
+	_ domain.SnapshotStore[string]   = (*SnapshotStore[string])(nil)
+	_ domain.SnapshotDeleter[string] = (*SnapshotStore[string])(nil)
+)
+
+// SnapshotStore is an in-memory implementation of domain.SnapshotStore.
+// It keeps only the latest snapshot of each aggregate.
+type SnapshotStore[ID comparable] struct {
+	mu        sync.RWMutex
+	snapshots map[ID]domain.Snapshot
+}
+
+func NewSnapshotStore[ID comparable]() *SnapshotStore[ID] {
+	return &SnapshotStore[ID]{
+		snapshots: make(map[ID]domain.Snapshot),
+	}
+}
+
+func (s *SnapshotStore[ID]) SaveSnapshot(_ context.Context, snapshot domain.Snapshot) error {
+	id, ok := snapshot.AggregateID.(ID)
+	if !ok {
+		return ErrInvalidAggregateEventID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// never replace a snapshot with an older one
+	if current, exists := s.snapshots[id]; exists && current.AggregateVersion > snapshot.AggregateVersion {
+		return nil
+	}
+
+	state := make([]byte, len(snapshot.State))
+	copy(state, snapshot.State)
+	snapshot.State = state
+
+	s.snapshots[id] = snapshot
+	return nil
+}
+
+func (s *SnapshotStore[ID]) LatestSnapshot(_ context.Context, aggregateID ID) (domain.Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot, ok := s.snapshots[aggregateID]
+	if !ok {
+		return domain.Snapshot{}, domain.ErrSnapshotNotFound
+	}
+
+	return snapshot, nil
+}
+
+// DeleteSnapshot removes the snapshot of the given aggregate.
+func (s *SnapshotStore[ID]) DeleteSnapshot(_ context.Context, aggregateID ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.snapshots, aggregateID)
+	return nil
+}
@@ -0,0 +1,63 @@
+package inmemory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xfrr/go-cqrsify/domain"
+
+	inmemory "github.com/xfrr/go-cqrsify/domain/inmemory"
+)
+
+func TestSnapshotStore(t *testing.T) {
+	ctx := context.Background()
+	sut := inmemory.NewSnapshotStore[string]()
+
+	t.Run("should return ErrSnapshotNotFound when there is no snapshot", func(t *testing.T) {
+		_, err := sut.LatestSnapshot(ctx, "unknown")
+		require.ErrorIs(t, err, domain.ErrSnapshotNotFound)
+	})
+
+	t.Run("should save and retrieve the latest snapshot", func(t *testing.T) {
+		require.NoError(t, sut.SaveSnapshot(ctx, newSnapshot("1", 5, "v5")))
+		require.NoError(t, sut.SaveSnapshot(ctx, newSnapshot("1", 10, "v10")))
+
+		snapshot, err := sut.LatestSnapshot(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, domain.AggregateVersion(10), snapshot.AggregateVersion)
+		assert.Equal(t, []byte("v10"), snapshot.State)
+	})
+
+	t.Run("should ignore older snapshots", func(t *testing.T) {
+		require.NoError(t, sut.SaveSnapshot(ctx, newSnapshot("1", 3, "v3")))
+
+		snapshot, err := sut.LatestSnapshot(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, domain.AggregateVersion(10), snapshot.AggregateVersion)
+	})
+
+	t.Run("should reject snapshots with invalid aggregate id type", func(t *testing.T) {
+		snapshot := newSnapshot("1", 1, "v1")
+		snapshot.AggregateID = 1
+		require.ErrorIs(t, sut.SaveSnapshot(ctx, snapshot), inmemory.ErrInvalidAggregateEventID)
+	})
+
+	t.Run("should delete snapshots", func(t *testing.T) {
+		require.NoError(t, sut.DeleteSnapshot(ctx, "1"))
+		_, err := sut.LatestSnapshot(ctx, "1")
+		require.ErrorIs(t, err, domain.ErrSnapshotNotFound)
+	})
+}
+
+func newSnapshot(id string, version int, state string) domain.Snapshot {
+	return domain.Snapshot{
+		AggregateID:      id,
+		AggregateName:    "test",
+		AggregateVersion: domain.AggregateVersion(version),
+		Timestamp:        time.Now(),
+		State:            []byte(state),
+	}
+}
@@ -0,0 +1,79 @@
+package domainpolicy
+
+import (
+	"context"
+	"slices"
+
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+// AggregateEvent is the subject of the policies attached to an aggregate type:
+// the aggregate about to record the event and the event itself.
+type AggregateEvent[A any] struct {
+	Aggregate A
+	Event     domain.Event
+}
+
+// EventGuardAdder represents an aggregate accepting event guards, such as domain.BaseAggregate.
+type EventGuardAdder interface {
+	AddEventGuard(guard domain.EventGuard)
+}
+
+// AggregatePolicies holds the named policies enforced on the aggregates of a type
+// before they record an event.
+//
+// The policies are attached to each aggregate with Attach, usually from its factory,
+// so that domain.NextEvent rejects the denied events with a domain.PolicyViolationError.
+type AggregatePolicies[A EventGuardAdder] struct {
+	engine *PolicyEngine[AggregateEvent[A]]
+	// events holds the names of the events each policy is restricted to.
+	events map[string][]string
+}
+
+// NewAggregatePolicies creates a new, empty set of aggregate policies.
+func NewAggregatePolicies[A EventGuardAdder]() *AggregatePolicies[A] {
+	return &AggregatePolicies[A]{
+		engine: NewPolicyEngine[AggregateEvent[A]](),
+		events: make(map[string][]string),
+	}
+}
+
+// Register registers a policy evaluated before the given events are recorded,
+// or before any event if no event name is given.
+func (ap *AggregatePolicies[A]) Register(policy Policy[AggregateEvent[A]], eventNames ...string) {
+	ap.engine.Register(policy)
+	if len(eventNames) == 0 {
+		delete(ap.events, policy.Name())
+		return
+	}
+	ap.events[policy.Name()] = eventNames
+}
+
+// Check evaluates the policies applying to the given event, sorted by name,
+// and returns a domain.PolicyViolationError for the first denied one.
+func (ap *AggregatePolicies[A]) Check(ctx context.Context, agg A, event domain.Event) error {
+	names := ap.engine.GetPolicyNames()
+	slices.Sort(names)
+
+	applicable := names[:0]
+	for _, name := range names {
+		if events, ok := ap.events[name]; ok && !slices.Contains(events, event.Name()) {
+			continue
+		}
+		applicable = append(applicable, name)
+	}
+	if len(applicable) == 0 {
+		return nil
+	}
+
+	return ap.engine.Enforce(ctx, AggregateEvent[A]{Aggregate: agg, Event: event}, applicable...)
+}
+
+// Attach adds a guard to the aggregate checking the policies before each event is recorded by domain.NextEvent.
+// The guards run with a background context, since NextEvent does not take one; use Check
+// to evaluate context dependent policies.
+func (ap *AggregatePolicies[A]) Attach(agg A) {
+	agg.AddEventGuard(func(event domain.Event) error {
+		return ap.Check(context.Background(), agg, event)
+	})
+}
@@ -0,0 +1,147 @@
+package domainpolicy_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xfrr/go-cqrsify/domain"
+	policy "github.com/xfrr/go-cqrsify/domain/policy"
+)
+
+type account struct {
+	*domain.BaseAggregate[string]
+	balance int
+}
+
+type withdrawn struct {
+	domain.BaseEvent
+	Amount int
+}
+
+func newWithdrawn(acc *account, amount int) withdrawn {
+	return withdrawn{BaseEvent: domain.NewEvent("withdrawn", domain.CreateEventAggregateRef(acc)), Amount: amount}
+}
+
+func newAccount(id string, policies *policy.AggregatePolicies[*account]) *account {
+	acc := &account{BaseAggregate: domain.NewAggregate(id, "account"), balance: 100}
+	domain.HandleEvent(acc, "withdrawn", func(acc *account, e withdrawn) error {
+		acc.balance -= e.Amount
+		return nil
+	})
+	policies.Attach(acc)
+	return acc
+}
+
+type sufficientFundsPolicy struct {
+	policy.BasePolicy
+}
+
+func (p *sufficientFundsPolicy) Evaluate(_ context.Context, subject policy.AggregateEvent[*account]) policy.Result {
+	if subject.Event.(withdrawn).Amount > subject.Aggregate.balance {
+		return policy.Deny("Insufficient funds", "INSUFFICIENT_FUNDS")
+	}
+	return policy.Allow("Sufficient funds")
+}
+
+type openAccountPolicy struct {
+	policy.BasePolicy
+	closed bool
+}
+
+func (p *openAccountPolicy) Evaluate(_ context.Context, _ policy.AggregateEvent[*account]) policy.Result {
+	if p.closed {
+		return policy.Deny("Account is closed", "ACCOUNT_CLOSED")
+	}
+	return policy.Allow("Account is open")
+}
+
+func TestResultErr(t *testing.T) {
+	assert.NoError(t, policy.Allow("ok").Err("test-policy"))
+
+	err := policy.Deny("not allowed", "DENIED").Err("test-policy")
+	require.ErrorIs(t, err, domain.ErrPolicyViolation)
+
+	var violation domain.PolicyViolationError
+	require.True(t, errors.As(err, &violation))
+	assert.Equal(t, "test-policy", violation.Policy)
+	assert.Equal(t, "DENIED", violation.Code)
+	assert.Equal(t, "not allowed", violation.Reason)
+}
+
+func TestPolicyEngineEnforce(t *testing.T) {
+	engine := policy.NewPolicyEngine[User]()
+	engine.Register(newAgePolicy(18))
+	engine.Register(newActiveUserPolicy())
+
+	ctx := context.Background()
+
+	t.Run("should allow when all policies pass", func(t *testing.T) {
+		require.NoError(t, engine.Enforce(ctx, User{Age: 25, IsActive: true}))
+	})
+
+	t.Run("should return the first denial sorted by name", func(t *testing.T) {
+		err := engine.Enforce(ctx, User{Age: 16, IsActive: false})
+
+		var violation domain.PolicyViolationError
+		require.True(t, errors.As(err, &violation))
+		assert.Equal(t, "active-user-policy", violation.Policy)
+		assert.Equal(t, "INACTIVE_USER", violation.Code)
+	})
+
+	t.Run("should only evaluate the given policies", func(t *testing.T) {
+		err := engine.Enforce(ctx, User{Age: 16, IsActive: false}, "age-policy")
+
+		var violation domain.PolicyViolationError
+		require.True(t, errors.As(err, &violation))
+		assert.Equal(t, "INSUFFICIENT_AGE", violation.Code)
+	})
+
+	t.Run("should fail for unknown policies", func(t *testing.T) {
+		err := engine.Enforce(ctx, User{Age: 25, IsActive: true}, "unknown")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, domain.ErrPolicyViolation)
+	})
+}
+
+func TestAggregatePolicies(t *testing.T) {
+	open := &openAccountPolicy{BasePolicy: policy.NewBasePolicy("open-account")}
+
+	policies := policy.NewAggregatePolicies[*account]()
+	policies.Register(&sufficientFundsPolicy{BasePolicy: policy.NewBasePolicy("sufficient-funds")}, "withdrawn")
+	policies.Register(open)
+
+	acc := newAccount("acc-1", policies)
+
+	t.Run("should record the events allowed by the policies", func(t *testing.T) {
+		err := domain.NextEvent(acc, newWithdrawn(acc, 60))
+		require.NoError(t, err)
+		assert.Equal(t, 40, acc.balance)
+		assert.Len(t, acc.AggregateEvents(), 1)
+	})
+
+	t.Run("should reject the events denied by a policy", func(t *testing.T) {
+		err := domain.NextEvent(acc, newWithdrawn(acc, 60))
+
+		var violation domain.PolicyViolationError
+		require.True(t, errors.As(err, &violation))
+		assert.Equal(t, "sufficient-funds", violation.Policy)
+		assert.Equal(t, "INSUFFICIENT_FUNDS", violation.Code)
+		assert.Equal(t, "Insufficient funds", violation.Reason)
+		assert.Equal(t, 40, acc.balance)
+		assert.Len(t, acc.AggregateEvents(), 1)
+	})
+
+	t.Run("should only evaluate the policies restricted to other events", func(t *testing.T) {
+		err := domain.NextEvent(acc, domain.NewEvent("renamed", domain.CreateEventAggregateRef(acc)))
+		require.NoError(t, err)
+
+		open.closed = true
+		err = domain.NextEvent(acc, domain.NewEvent("renamed", domain.CreateEventAggregateRef(acc)))
+		require.ErrorIs(t, err, domain.ErrPolicyViolation)
+		assert.Len(t, acc.AggregateEvents(), 2)
+	})
+}
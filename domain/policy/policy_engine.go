@@ -3,6 +3,7 @@ package domainpolicy
 import (
 	"context"
 	"fmt"
+	"slices"
 )
 
 // PolicyEngine manages and evaluates policies
@@ -46,6 +47,26 @@ func (pe *PolicyEngine[T]) EvaluateAll(ctx context.Context, subject T) Result {
 	return composite.Evaluate(ctx, subject)
 }
 
+// Enforce evaluates the given policies, or all the registered policies sorted by name if none is given,
+// and returns a domain.PolicyViolationError for the first denied one.
+func (pe *PolicyEngine[T]) Enforce(ctx context.Context, subject T, policyNames ...string) error {
+	if len(policyNames) == 0 {
+		policyNames = pe.GetPolicyNames()
+		slices.Sort(policyNames)
+	}
+
+	for _, name := range policyNames {
+		result, err := pe.Evaluate(ctx, name, subject)
+		if err != nil {
+			return err
+		}
+		if err := result.Err(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetPolicyNames returns all registered policy names
 func (pe *PolicyEngine[T]) GetPolicyNames() []string {
 	var names []string
@@ -1,5 +1,7 @@
 package domainpolicy
 
+import "github.com/xfrr/go-cqrsify/domain"
+
 // Result represents the outcome of a policy evaluation
 type Result struct {
 	Allowed bool
@@ -25,3 +27,12 @@ func Allow(reason string) Result {
 func Deny(reason, code string) Result {
 	return NewResult(false, reason, code)
 }
+
+// Err returns a domain.PolicyViolationError carrying the code and the reason
+// of a denied result, or nil if the result is allowed.
+func (r Result) Err(policyName string) error {
+	if r.Allowed {
+		return nil
+	}
+	return domain.NewPolicyViolationError(policyName, r.Code, r.Reason)
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrPolicyViolation is the sentinel error matched by PolicyViolationError.
+var ErrPolicyViolation = errors.New("policy violation")
+
+// PolicyViolationError is returned when a business rule policy denies an event
+// or a command. It carries the code and the reason of the denial.
+type PolicyViolationError struct {
+	// Policy is the name of the policy denying the operation.
+	Policy string
+	// Code is the machine readable code of the denial.
+	Code string
+	// Reason is the human readable reason of the denial.
+	Reason string
+}
+
+func (e PolicyViolationError) Error() string {
+	return fmt.Sprintf("policy %q violated: %s (code: %s)", e.Policy, e.Reason, e.Code)
+}
+
+// Is reports whether the target is ErrPolicyViolation.
+func (e PolicyViolationError) Is(target error) bool {
+	return target == ErrPolicyViolation
+}
+
+// NewPolicyViolationError creates a PolicyViolationError for an operation denied by the given policy.
+func NewPolicyViolationError(policy, code, reason string) PolicyViolationError {
+	return PolicyViolationError{Policy: policy, Code: code, Reason: reason}
+}
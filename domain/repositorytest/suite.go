@@ -0,0 +1,363 @@
+// Package repositorytest provides a behavioural test suite shared by
+// the event-sourced repository implementations.
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+// Repository is the contract exercised by the suite.
+type Repository interface {
+	domain.SearchableEventSourcedRepository[domain.EventSourcedAggregate[string], string]
+	domain.EventHistoryDiffer[domain.EventSourcedAggregate[string], string]
+
+	// Delete removes the aggregate stream from the repository.
+	Delete(context.Context, domain.EventSourcedAggregate[string]) error
+}
+
+// RunEventSourcedRepositoryTests runs the behavioural test suite against the
+// repositories built by newRepository. A new repository is built for each test.
+func RunEventSourcedRepositoryTests(t *testing.T, newRepository func(t *testing.T) Repository) {
+	t.Helper()
+
+	tests := map[string]func(t *testing.T, repo Repository){
+		"Save and Load":               testSaveAndLoad,
+		"Load not found":              testLoadNotFound,
+		"LoadVersion":                 testLoadVersion,
+		"LoadAsOf":                    testLoadAsOf,
+		"Diff":                        testDiff,
+		"Exists":                      testExists,
+		"ExistsVersion":               testExistsVersion,
+		"Save concurrency conflict":   testSaveConcurrencyConflict,
+		"Search":                      testSearch,
+		"Search filters":              testSearchFilters,
+		"Search pagination":           testSearchPagination,
+		"Delete":                      testDelete,
+		"Save after previous commits": testSaveAfterPreviousCommits,
+		"Save keeps event metadata":   testSaveKeepsEventMetadata,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepository(t))
+		})
+	}
+}
+
+func newAggregate(t *testing.T, id, name string, events ...string) *domain.BaseAggregate[string] {
+	t.Helper()
+
+	agg := domain.NewAggregate(id, name)
+	recordEvents(t, agg, events...)
+	return agg
+}
+
+func recordEvents(t *testing.T, agg *domain.BaseAggregate[string], events ...string) {
+	t.Helper()
+
+	for _, name := range events {
+		err := domain.NextEvent(agg, domain.NewEvent(name, domain.CreateEventAggregateRef(agg)))
+		require.NoError(t, err, "NextEvent() error = %v, want nil", err)
+	}
+}
+
+func testSaveAndLoad(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	agg := newAggregate(t, "1", "test", "test.created", "test.updated")
+	require.NoError(t, repo.Save(ctx, agg))
+	assert.Equal(t, domain.AggregateVersion(2), agg.AggregateVersion())
+	assert.Empty(t, agg.AggregateEvents())
+
+	var applied []string
+	loaded := domain.NewAggregate("1", "test")
+	loaded.HandleEvent("test.created", func(e domain.Event) error { applied = append(applied, e.Name()); return nil })
+	loaded.HandleEvent("test.updated", func(e domain.Event) error { applied = append(applied, e.Name()); return nil })
+
+	require.NoError(t, repo.Load(ctx, loaded))
+	assert.Equal(t, domain.AggregateVersion(2), loaded.AggregateVersion())
+	assert.Equal(t, "test", loaded.AggregateName())
+	assert.Empty(t, loaded.AggregateEvents())
+	assert.Equal(t, []string{"test.created", "test.updated"}, applied)
+}
+
+func testLoadNotFound(t *testing.T, repo Repository) {
+	err := repo.Load(context.Background(), domain.NewAggregate("unknown", "test"))
+
+	var notFoundErr domain.NotFoundError[string]
+	require.ErrorAs(t, err, &notFoundErr)
+	assert.Equal(t, "unknown", notFoundErr.ID)
+}
+
+func testLoadVersion(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	require.NoError(t, repo.Save(ctx, newAggregate(t, "1", "test", "a", "b", "c")))
+
+	loaded := domain.NewAggregate("1", "test")
+	require.NoError(t, repo.LoadVersion(ctx, loaded, 2))
+	assert.Equal(t, domain.AggregateVersion(2), loaded.AggregateVersion())
+	assert.Empty(t, loaded.AggregateEvents())
+}
+
+// recordTimedEvents records one event per timestamp, named after its index.
+func recordTimedEvents(t *testing.T, agg *domain.BaseAggregate[string], timestamps ...time.Time) {
+	t.Helper()
+
+	for i, ts := range timestamps {
+		event := domain.NewEvent(fmt.Sprintf("e%d", i+1), domain.CreateEventAggregateRef(agg), domain.WithEventTimestamp(ts))
+		require.NoError(t, domain.NextEvent(agg, event))
+	}
+}
+
+func testLoadAsOf(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	day := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	agg := domain.NewAggregate("1", "test")
+	recordTimedEvents(t, agg, day, day.AddDate(0, 0, 2), day.AddDate(0, 0, 4))
+	require.NoError(t, repo.Save(ctx, agg))
+
+	loaded := domain.NewAggregate("1", "test")
+	require.NoError(t, repo.LoadAsOf(ctx, loaded, day.AddDate(0, 0, 3)))
+	assert.Equal(t, domain.AggregateVersion(2), loaded.AggregateVersion())
+
+	loaded = domain.NewAggregate("1", "test")
+	require.NoError(t, repo.LoadAsOf(ctx, loaded, day.AddDate(0, 0, 4)))
+	assert.Equal(t, domain.AggregateVersion(3), loaded.AggregateVersion(), "the bound must be inclusive")
+
+	var notFoundErr domain.NotFoundError[string]
+	err := repo.LoadAsOf(ctx, domain.NewAggregate("1", "test"), day.Add(-time.Second))
+	require.ErrorAs(t, err, &notFoundErr)
+}
+
+func testDiff(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	day := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	agg := domain.NewAggregate("1", "test")
+	recordTimedEvents(t, agg, day, day.AddDate(0, 0, 1), day.AddDate(0, 0, 2), day.AddDate(0, 0, 3))
+	require.NoError(t, repo.Save(ctx, agg))
+
+	names := func(history domain.History) []string {
+		result := make([]string, len(history))
+		for i, event := range history {
+			result[i] = event.Name()
+		}
+		return result
+	}
+
+	diff, err := repo.DiffVersions(ctx, agg, 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e2", "e3"}, names(diff))
+
+	diff, err = repo.DiffVersions(ctx, agg, 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e3", "e4"}, names(diff))
+
+	diff, err = repo.DiffTimes(ctx, agg, day, day.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e2", "e3"}, names(diff))
+
+	diff, err = repo.DiffTimes(ctx, agg, day.AddDate(0, 0, 2), time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e4"}, names(diff))
+
+	diff, err = repo.DiffVersions(ctx, domain.NewAggregate("unknown", "test"), 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+}
+
+func testExists(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	require.NoError(t, repo.Save(ctx, newAggregate(t, "1", "test", "a")))
+
+	exists, err := repo.Exists(ctx, domain.NewAggregate("1", "test"))
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = repo.Exists(ctx, domain.NewAggregate("unknown", "test"))
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func testExistsVersion(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	require.NoError(t, repo.Save(ctx, newAggregate(t, "1", "test", "a", "b")))
+
+	exists, err := repo.ExistsVersion(ctx, domain.NewAggregate("1", "test"), 2)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = repo.ExistsVersion(ctx, domain.NewAggregate("1", "test"), 3)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func testSaveConcurrencyConflict(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	require.NoError(t, repo.Save(ctx, newAggregate(t, "1", "test", "a")))
+
+	writer1 := domain.NewAggregate("1", "test")
+	require.NoError(t, repo.Load(ctx, writer1))
+	writer2 := domain.NewAggregate("1", "test")
+	require.NoError(t, repo.Load(ctx, writer2))
+
+	recordEvents(t, writer1, "b")
+	require.NoError(t, repo.Save(ctx, writer1))
+
+	recordEvents(t, writer2, "c")
+	err := repo.Save(ctx, writer2)
+	require.ErrorIs(t, err, domain.ErrConcurrencyConflict)
+
+	var conflictErr domain.ConcurrencyConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, domain.AggregateVersion(1), conflictErr.ExpectedVersion)
+	assert.Equal(t, domain.AggregateVersion(2), conflictErr.ActualVersion)
+}
+
+func testSearch(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	require.NoError(t, repo.Save(ctx, newAggregate(t, "id-1", "test-1", "a")))
+	require.NoError(t, repo.Save(ctx, newAggregate(t, "id-2", "test-2", "a", "b")))
+	require.NoError(t, repo.Save(ctx, newAggregate(t, "id-3", "test-3", "a")))
+
+	aggs, err := repo.Search(ctx, domain.SearchCriteria())
+	require.NoError(t, err)
+	assert.Len(t, aggs, 3)
+
+	aggs, err = repo.Search(ctx, domain.SearchCriteria().WithSearchAggregateIDs("id-1", "id-2"))
+	require.NoError(t, err)
+	require.Len(t, aggs, 2)
+	for _, agg := range aggs {
+		assert.Contains(t, []string{"id-1", "id-2"}, agg.AggregateID())
+	}
+
+	aggs, err = repo.Search(ctx, domain.SearchCriteria().WithSearchAggregateNames("test-2"))
+	require.NoError(t, err)
+	require.Len(t, aggs, 1)
+	assert.Equal(t, "id-2", aggs[0].AggregateID())
+	assert.Equal(t, domain.AggregateVersion(2), aggs[0].AggregateVersion())
+}
+
+func testSearchFilters(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	require.NoError(t, repo.Save(ctx, newAggregate(t, "id-1", "order", "order.placed", "order.paid")))
+	require.NoError(t, repo.Save(ctx, newAggregate(t, "id-2", "order", "order.placed")))
+
+	aggs, err := repo.Search(ctx, domain.SearchCriteria().WithSearchEventNames("order.paid"))
+	require.NoError(t, err)
+	require.Len(t, aggs, 1)
+	assert.Equal(t, "id-1", aggs[0].AggregateID())
+	assert.Equal(t, domain.AggregateVersion(2), aggs[0].AggregateVersion(), "the aggregate is restored from its whole stream")
+
+	aggs, err = repo.Search(ctx, domain.SearchCriteria().WithSearchVersionRange(2, 0))
+	require.NoError(t, err)
+	require.Len(t, aggs, 1)
+	assert.Equal(t, "id-1", aggs[0].AggregateID())
+
+	aggs, err = repo.Search(ctx, domain.SearchCriteria().WithSearchTimestampRange(time.Now().Add(time.Hour), time.Time{}))
+	require.NoError(t, err)
+	assert.Empty(t, aggs)
+
+	aggs, err = repo.Search(ctx, domain.SearchCriteria().
+		WithSearchCriteria(domain.AggregateIDCriteria("id-2")).
+		WithSearchEventNames("order.placed"))
+	require.NoError(t, err)
+	require.Len(t, aggs, 1)
+	assert.Equal(t, "id-2", aggs[0].AggregateID())
+}
+
+func testSearchPagination(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	require.NoError(t, repo.Save(ctx, newAggregate(t, "id-1", "test", "a")))
+	require.NoError(t, repo.Save(ctx, newAggregate(t, "id-2", "test", "a", "b", "c")))
+	require.NoError(t, repo.Save(ctx, newAggregate(t, "id-3", "test", "a", "b")))
+
+	ids := func(aggs []domain.EventSourcedAggregate[string]) []string {
+		result := make([]string, len(aggs))
+		for i, agg := range aggs {
+			result[i] = agg.AggregateID()
+		}
+		return result
+	}
+
+	criteria := domain.SearchCriteria().WithSearchSort(domain.SearchSortByVersion, true).WithSearchLimit(2)
+	page, err := repo.Search(ctx, criteria)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id-2", "id-3"}, ids(page))
+
+	page, err = repo.Search(ctx, criteria.WithSearchAfter(domain.NewAggregateSearchCursor(page[len(page)-1])))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id-1"}, ids(page))
+
+	page, err = repo.Search(ctx, domain.SearchCriteria().
+		WithSearchSort(domain.SearchSortByAggregateID, false).
+		WithSearchOffset(1).
+		WithSearchLimit(1))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id-2"}, ids(page))
+}
+
+func testDelete(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	agg := newAggregate(t, "1", "test", "a", "b")
+	require.NoError(t, repo.Save(ctx, agg))
+	require.NoError(t, repo.Save(ctx, newAggregate(t, "2", "test", "a")))
+
+	require.NoError(t, repo.Delete(ctx, agg))
+
+	exists, err := repo.Exists(ctx, domain.NewAggregate("1", "test"))
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	var notFoundErr domain.NotFoundError[string]
+	require.ErrorAs(t, repo.Load(ctx, domain.NewAggregate("1", "test")), &notFoundErr)
+
+	aggs, err := repo.Search(ctx, domain.SearchCriteria())
+	require.NoError(t, err)
+	require.Len(t, aggs, 1)
+	assert.Equal(t, "2", aggs[0].AggregateID())
+}
+
+func testSaveAfterPreviousCommits(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	agg := newAggregate(t, "1", "test", "a")
+	require.NoError(t, repo.Save(ctx, agg))
+
+	recordEvents(t, agg, "b", "c")
+	require.NoError(t, repo.Save(ctx, agg))
+	assert.Equal(t, domain.AggregateVersion(3), agg.AggregateVersion())
+
+	loaded := domain.NewAggregate("1", "test")
+	require.NoError(t, repo.Load(ctx, loaded))
+	assert.Equal(t, domain.AggregateVersion(3), loaded.AggregateVersion())
+}
+
+func testSaveKeepsEventMetadata(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	agg := domain.NewAggregate("1", "test")
+	err := domain.NextEvent(agg, domain.NewEvent("a", domain.CreateEventAggregateRef(agg),
+		domain.WithCausationID("cmd-1"),
+		domain.WithCorrelationID("flow-1"),
+		domain.WithActor("alice"),
+	))
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(ctx, agg))
+
+	var loadedEvent domain.Event
+	loaded := domain.NewAggregate("1", "test")
+	loaded.HandleEvent("a", func(e domain.Event) error { loadedEvent = e; return nil })
+	require.NoError(t, repo.Load(ctx, loaded))
+
+	require.NotNil(t, loadedEvent)
+	assert.Equal(t, "cmd-1", domain.EventCausationID(loadedEvent))
+	assert.Equal(t, "flow-1", domain.EventCorrelationID(loadedEvent))
+	assert.Equal(t, "alice", domain.EventActor(loadedEvent))
+}
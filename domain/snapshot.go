@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+)
+
+// Snapshot represents the serialized state of an aggregate at a given version.
+type Snapshot struct {
+	// AggregateID is the ID of the snapshotted aggregate.
+	AggregateID any
+	// AggregateName is the name of the snapshotted aggregate.
+	AggregateName string
+	// AggregateVersion is the aggregate version at which the snapshot was taken.
+	AggregateVersion AggregateVersion
+	// Timestamp is the time at which the snapshot was taken.
+	Timestamp time.Time
+	// State is the serialized aggregate state.
+	State []byte
+	// Closed reports whether the aggregate was closed. See ClosableAggregate.
+	Closed bool
+	// Deleted reports whether the aggregate stream was soft-deleted at AggregateVersion.
+	// A deleted snapshot carries no state, and the aggregate can no longer be loaded.
+	Deleted bool
+	// DataSubjectID is the ID of the data subject whose key encrypts State, if any.
+	// See WithCryptoShredding and PersonalDataAggregate.
+	DataSubjectID string
+}
+
+// Snapshotter is implemented by aggregates that can serialize and restore their state.
+type Snapshotter interface {
+	// SnapshotState serializes the aggregate state.
+	SnapshotState() ([]byte, error)
+	// RestoreSnapshotState restores the aggregate state from the given serialized state.
+	RestoreSnapshotState(state []byte) error
+}
+
+// AggregateVersionRestorer is implemented by aggregates whose version
+// can be restored without replaying events, e.g. when loading from a snapshot.
+type AggregateVersionRestorer interface {
+	// RestoreAggregateVersion sets the aggregate version.
+	RestoreAggregateVersion(AggregateVersion)
+}
+
+// SnapshotStore represents a store that can save and retrieve aggregate snapshots.
+type SnapshotStore[ID comparable] interface {
+	// SaveSnapshot saves the given snapshot, replacing any older snapshot of the same aggregate.
+	SaveSnapshot(ctx context.Context, snapshot Snapshot) error
+	// LatestSnapshot retrieves the latest snapshot of the given aggregate.
+	// It returns ErrSnapshotNotFound if the aggregate has no snapshot.
+	LatestSnapshot(ctx context.Context, aggregateID ID) (Snapshot, error)
+}
+
+// SnapshotDeleter represents a snapshot store that can remove the snapshot of an aggregate.
+type SnapshotDeleter[ID comparable] interface {
+	// DeleteSnapshot removes the snapshot of the given aggregate.
+	DeleteSnapshot(ctx context.Context, aggregateID ID) error
+}
+
+// NewDeletedSnapshot creates the snapshot recording that the stream of the given aggregate
+// was soft-deleted at its current version. It replaces the snapshot of the live aggregate.
+func NewDeletedSnapshot[ID comparable](agg EventSourcedAggregate[ID]) Snapshot {
+	return Snapshot{
+		AggregateID:      agg.AggregateID(),
+		AggregateName:    agg.AggregateName(),
+		AggregateVersion: agg.AggregateVersion(),
+		Timestamp:        time.Now(),
+		Deleted:          true,
+	}
+}
+
+// TakeSnapshot creates a snapshot of the given aggregate at its current version.
+// The aggregate must implement the Snapshotter interface.
+func TakeSnapshot[ID comparable](agg EventSourcedAggregate[ID]) (Snapshot, error) {
+	s, ok := agg.(Snapshotter)
+	if !ok {
+		return Snapshot{}, fmt.Errorf("aggregate %T does not implement Snapshotter", agg)
+	}
+
+	state, err := s.SnapshotState()
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("could not serialize aggregate state: %w", err)
+	}
+
+	return Snapshot{
+		AggregateID:      agg.AggregateID(),
+		AggregateName:    agg.AggregateName(),
+		AggregateVersion: agg.AggregateVersion(),
+		Timestamp:        time.Now(),
+		State:            state,
+		Closed:           IsAggregateClosed(agg),
+	}, nil
+}
+
+// RestoreAggregateFromSnapshot restores the state and version of the given aggregate from the snapshot.
+// The aggregate must implement the Snapshotter and AggregateVersionRestorer interfaces.
+// A closed aggregate is closed again if it implements AggregateCloser.
+func RestoreAggregateFromSnapshot[ID comparable](agg EventSourcedAggregate[ID], snapshot Snapshot) error {
+	if agg == nil {
+		return ErrNilAggregate
+	}
+
+	if snapshot.AggregateID != agg.AggregateID() {
+		return NewHistoryIntegrityError("snapshot has different aggregate ID").
+			WithDetails(0, agg.AggregateID(), snapshot.AggregateID, "ID_MISMATCH")
+	}
+
+	if snapshot.AggregateName != agg.AggregateName() {
+		return NewHistoryIntegrityError("snapshot has different aggregate name").
+			WithDetails(0, agg.AggregateName(), snapshot.AggregateName, "TYPE_MISMATCH")
+	}
+
+	s, ok := agg.(Snapshotter)
+	if !ok {
+		return fmt.Errorf("aggregate %T does not implement Snapshotter", agg)
+	}
+
+	r, ok := agg.(AggregateVersionRestorer)
+	if !ok {
+		return fmt.Errorf("aggregate %T does not implement AggregateVersionRestorer", agg)
+	}
+
+	if err := s.RestoreSnapshotState(snapshot.State); err != nil {
+		return fmt.Errorf("could not restore aggregate state: %w", err)
+	}
+
+	r.RestoreAggregateVersion(snapshot.AggregateVersion)
+
+	if c, ok := agg.(AggregateCloser); ok && snapshot.Closed {
+		c.CloseAggregate()
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package domain
+
+import "time"
+
+// SnapshotPolicyState holds the information used by a SnapshotPolicy
+// to decide whether a new snapshot must be taken.
+type SnapshotPolicyState struct {
+	// AggregateVersion is the aggregate version after the last save.
+	AggregateVersion AggregateVersion
+	// LastSnapshot is the latest snapshot of the aggregate, nil if there is none.
+	LastSnapshot *Snapshot
+	// Now is the time at which the policy is evaluated.
+	Now time.Time
+}
+
+// SnapshotPolicy decides when an aggregate snapshot must be taken.
+type SnapshotPolicy interface {
+	ShouldSnapshot(state SnapshotPolicyState) bool
+}
+
+// SnapshotPolicyFunc is a function adapter for SnapshotPolicy.
+type SnapshotPolicyFunc func(state SnapshotPolicyState) bool
+
+func (f SnapshotPolicyFunc) ShouldSnapshot(state SnapshotPolicyState) bool {
+	return f(state)
+}
+
+// SnapshotEveryNEvents returns a policy that takes a snapshot every n events
+// since the last snapshot.
+func SnapshotEveryNEvents(n int) SnapshotPolicy {
+	return SnapshotPolicyFunc(func(state SnapshotPolicyState) bool {
+		if n <= 0 {
+			return false
+		}
+
+		var lastVersion AggregateVersion
+		if state.LastSnapshot != nil {
+			lastVersion = state.LastSnapshot.AggregateVersion
+		}
+
+		return state.AggregateVersion-lastVersion >= AggregateVersion(n)
+	})
+}
+
+// SnapshotEvery returns a policy that takes a snapshot when the last snapshot
+// is older than the given interval, or when there is no snapshot yet.
+func SnapshotEvery(interval time.Duration) SnapshotPolicy {
+	return SnapshotPolicyFunc(func(state SnapshotPolicyState) bool {
+		if state.LastSnapshot == nil {
+			return true
+		}
+
+		if state.LastSnapshot.AggregateVersion >= state.AggregateVersion {
+			return false
+		}
+
+		return state.Now.Sub(state.LastSnapshot.Timestamp) >= interval
+	})
+}
+
+// SnapshotAnyOf returns a policy that takes a snapshot when any of the given policies does.
+func SnapshotAnyOf(policies ...SnapshotPolicy) SnapshotPolicy {
+	return SnapshotPolicyFunc(func(state SnapshotPolicyState) bool {
+		for _, p := range policies {
+			if p.ShouldSnapshot(state) {
+				return true
+			}
+		}
+		return false
+	})
+}
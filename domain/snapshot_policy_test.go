@@ -0,0 +1,52 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+func TestSnapshotEveryNEvents(t *testing.T) {
+	sut := domain.SnapshotEveryNEvents(10)
+
+	assert.False(t, sut.ShouldSnapshot(domain.SnapshotPolicyState{AggregateVersion: 9}))
+	assert.True(t, sut.ShouldSnapshot(domain.SnapshotPolicyState{AggregateVersion: 10}))
+	assert.False(t, sut.ShouldSnapshot(domain.SnapshotPolicyState{
+		AggregateVersion: 15,
+		LastSnapshot:     &domain.Snapshot{AggregateVersion: 10},
+	}))
+	assert.True(t, sut.ShouldSnapshot(domain.SnapshotPolicyState{
+		AggregateVersion: 21,
+		LastSnapshot:     &domain.Snapshot{AggregateVersion: 10},
+	}))
+	assert.False(t, domain.SnapshotEveryNEvents(0).ShouldSnapshot(domain.SnapshotPolicyState{AggregateVersion: 100}))
+}
+
+func TestSnapshotEvery(t *testing.T) {
+	now := time.Now()
+	sut := domain.SnapshotEvery(time.Hour)
+
+	assert.True(t, sut.ShouldSnapshot(domain.SnapshotPolicyState{AggregateVersion: 1, Now: now}))
+	assert.False(t, sut.ShouldSnapshot(domain.SnapshotPolicyState{
+		AggregateVersion: 5,
+		LastSnapshot:     &domain.Snapshot{AggregateVersion: 1, Timestamp: now.Add(-time.Minute)},
+		Now:              now,
+	}))
+	assert.True(t, sut.ShouldSnapshot(domain.SnapshotPolicyState{
+		AggregateVersion: 5,
+		LastSnapshot:     &domain.Snapshot{AggregateVersion: 1, Timestamp: now.Add(-2 * time.Hour)},
+		Now:              now,
+	}))
+	assert.False(t, sut.ShouldSnapshot(domain.SnapshotPolicyState{
+		AggregateVersion: 5,
+		LastSnapshot:     &domain.Snapshot{AggregateVersion: 5, Timestamp: now.Add(-2 * time.Hour)},
+		Now:              now,
+	}))
+}
+
+func TestSnapshotAnyOf(t *testing.T) {
+	sut := domain.SnapshotAnyOf(domain.SnapshotEveryNEvents(100), domain.SnapshotEvery(time.Hour))
+	assert.True(t, sut.ShouldSnapshot(domain.SnapshotPolicyState{AggregateVersion: 1, Now: time.Now()}))
+}
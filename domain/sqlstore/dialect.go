@@ -0,0 +1,107 @@
+package sqlstore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Dialect abstracts the SQL differences between database engines.
+type Dialect interface {
+	// Name returns the dialect name.
+	Name() string
+	// Placeholder returns the bind parameter placeholder for the given 1-based position.
+	Placeholder(n int) string
+	// MigrationStatements returns the statements creating the events table and its indexes.
+	MigrationStatements(table string) []string
+	// IsUniqueViolation reports whether the error is a unique constraint violation.
+	IsUniqueViolation(err error) bool
+	// AppendLockStatement returns the statement run inside the append transaction, before any
+	// insert, so that appends commit in global position order. It returns an empty string when
+	// the engine already serializes writers.
+	AppendLockStatement(table string) string
+}
+
+// sqlStateError is implemented by the errors of most PostgreSQL drivers (pgx, pq).
+type sqlStateError interface {
+	SQLState() string
+}
+
+type postgresDialect struct{}
+
+// PostgreSQL returns the PostgreSQL dialect.
+func PostgreSQL() Dialect { return postgresDialect{} }
+
+func (postgresDialect) Name() string { return "postgres" }
+
+func (postgresDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+func (postgresDialect) MigrationStatements(table string) []string {
+	return []string{
+		`CREATE TABLE IF NOT EXISTS ` + table + ` (
+	global_position BIGSERIAL PRIMARY KEY,
+	aggregate_id TEXT NOT NULL,
+	aggregate_name TEXT NOT NULL,
+	aggregate_version BIGINT NOT NULL,
+	event_name TEXT NOT NULL,
+	event_revision INTEGER NOT NULL DEFAULT 1,
+	occurred_at TIMESTAMPTZ NOT NULL,
+	metadata TEXT,
+	payload BYTEA,
+	CONSTRAINT ` + table + `_aggregate_version_key UNIQUE (aggregate_id, aggregate_version)
+)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_aggregate_name_idx ON ` + table + ` (aggregate_name)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_event_name_idx ON ` + table + ` (event_name)`,
+	}
+}
+
+func (postgresDialect) IsUniqueViolation(err error) bool {
+	var stateErr sqlStateError
+	if errors.As(err, &stateErr) {
+		return stateErr.SQLState() == "23505"
+	}
+	return err != nil && strings.Contains(err.Error(), "duplicate key value violates unique constraint")
+}
+
+// AppendLockStatement takes a transaction-scoped advisory lock keyed by the table name.
+// BIGSERIAL values are assigned at insert time, not at commit time, so without it a slow
+// transaction could commit a lower position after a reader has already moved past it.
+func (postgresDialect) AppendLockStatement(table string) string {
+	return "SELECT pg_advisory_xact_lock(hashtext('" + table + "'))"
+}
+
+type sqliteDialect struct{}
+
+// SQLite returns the SQLite dialect.
+func SQLite() Dialect { return sqliteDialect{} }
+
+func (sqliteDialect) Name() string { return "sqlite" }
+
+func (sqliteDialect) Placeholder(_ int) string { return "?" }
+
+func (sqliteDialect) MigrationStatements(table string) []string {
+	return []string{
+		`CREATE TABLE IF NOT EXISTS ` + table + ` (
+	global_position INTEGER PRIMARY KEY AUTOINCREMENT,
+	aggregate_id TEXT NOT NULL,
+	aggregate_name TEXT NOT NULL,
+	aggregate_version INTEGER NOT NULL,
+	event_name TEXT NOT NULL,
+	event_revision INTEGER NOT NULL DEFAULT 1,
+	occurred_at TIMESTAMP NOT NULL,
+	metadata TEXT,
+	payload BLOB,
+	UNIQUE (aggregate_id, aggregate_version)
+)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_aggregate_name_idx ON ` + table + ` (aggregate_name)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_event_name_idx ON ` + table + ` (event_name)`,
+	}
+}
+
+// AppendLockStatement returns an empty string: SQLite allows a single writer at a time, so
+// positions are always committed in order.
+func (sqliteDialect) AppendLockStatement(_ string) string { return "" }
+
+func (sqliteDialect) IsUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
@@ -0,0 +1,582 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+const defaultTableName = "events"
+
+var (
+	_ domain.EventStore[string]             = (*EventStore[string])(nil)
+	_ domain.VersionedEventSaver            = (*EventStore[string])(nil)
+	_ domain.EventStreamReader              = (*EventStore[string])(nil)
+	_ domain.EventStreamer[string]          = (*EventStore[string])(nil)
+	_ domain.StreamExistenceChecker[string] = (*EventStore[string])(nil)
+	_ domain.StreamDeleter[string]          = (*EventStore[string])(nil)
+	_ domain.StreamTruncater[string]        = (*EventStore[string])(nil)
+	_ querier                               = (*sql.DB)(nil)
+	_ querier                               = (*sql.Tx)(nil)
+)
+
+var (
+	ErrNilDialect             = errors.New("sql event store dialect is nil")
+	ErrMixedAggregateEvents   = errors.New("events belong to different aggregates")
+	ErrInvalidAggregateIDType = errors.New("cannot parse aggregate ID: configure Config.ParseAggregateID")
+)
+
+// querier is the subset of methods shared by *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type Config[ID comparable] struct {
+	// Dialect is the SQL dialect of the database. Required.
+	Dialect Dialect
+	// TableName is the name of the events table. Defaults to "events".
+	TableName string
+	// Codec encodes and decodes the event payloads. Defaults to BaseEventCodec.
+	Codec EventCodec
+	// FormatAggregateID formats the aggregate ID to be stored. Defaults to fmt.Sprint.
+	FormatAggregateID func(ID) string
+	// ParseAggregateID parses a stored aggregate ID.
+	// It is required when ID is not a string.
+	ParseAggregateID func(string) (ID, error)
+}
+
+// EventStore is a database/sql implementation of domain.EventStore.
+//
+// Events are appended with a unique (aggregate_id, aggregate_version) constraint,
+// so concurrent writers of the same aggregate version are rejected
+// with a domain.ConcurrencyConflictError.
+type EventStore[ID comparable] struct {
+	db  *sql.DB
+	tx  *sql.Tx
+	cfg Config[ID]
+}
+
+// NewEventStore creates a new EventStore for the given database.
+func NewEventStore[ID comparable](db *sql.DB, cfg Config[ID]) *EventStore[ID] {
+	if cfg.TableName == "" {
+		cfg.TableName = defaultTableName
+	}
+	if cfg.Codec == nil {
+		cfg.Codec = BaseEventCodec{}
+	}
+	if cfg.FormatAggregateID == nil {
+		cfg.FormatAggregateID = func(id ID) string { return fmt.Sprint(id) }
+	}
+	if cfg.ParseAggregateID == nil {
+		cfg.ParseAggregateID = func(s string) (ID, error) {
+			id, ok := any(s).(ID)
+			if !ok {
+				return id, ErrInvalidAggregateIDType
+			}
+			return id, nil
+		}
+	}
+
+	return &EventStore[ID]{db: db, cfg: cfg}
+}
+
+// WithTx returns a copy of the store bound to the given transaction.
+// It allows the store to take part in a uow.UnitOfWork, e.g. binding
+// the *sql.Tx unwrapped from the uow/postgres transaction.
+func (s *EventStore[ID]) WithTx(tx *sql.Tx) *EventStore[ID] {
+	return &EventStore[ID]{db: s.db, tx: tx, cfg: s.cfg}
+}
+
+// Migrate creates the events table and its indexes if they do not exist.
+func (s *EventStore[ID]) Migrate(ctx context.Context) error {
+	if s.cfg.Dialect == nil {
+		return ErrNilDialect
+	}
+
+	for _, stmt := range s.cfg.Dialect.MigrationStatements(s.cfg.TableName) {
+		if _, err := s.querier().ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("could not migrate events table: %w", err)
+		}
+	}
+	return nil
+}
+
+// Save appends the given events to the store.
+func (s *EventStore[ID]) Save(ctx context.Context, events []domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	return s.inTx(ctx, func(q querier) error {
+		return s.insert(ctx, q, events)
+	})
+}
+
+// SaveVersioned appends the given events to the store only if the current aggregate
+// version matches the expected version.
+func (s *EventStore[ID]) SaveVersioned(ctx context.Context, expectedVersion domain.AggregateVersion, events []domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	aggregateID := events[0].AggregateRef().ID()
+	for _, event := range events[1:] {
+		if event.AggregateRef().ID() != aggregateID {
+			return ErrMixedAggregateEvents
+		}
+	}
+
+	return s.inTx(ctx, func(q querier) error {
+		current, err := s.currentVersion(ctx, q, aggregateID)
+		if err != nil {
+			return err
+		}
+
+		if current != expectedVersion {
+			return domain.NewConcurrencyConflictError(aggregateID, expectedVersion, current)
+		}
+
+		return s.insert(ctx, q, events)
+	})
+}
+
+// RetrieveMany retrieves the events of the given aggregate ordered by version.
+// When a batch size is given, the events are fetched from the database in pages of that size.
+func (s *EventStore[ID]) RetrieveMany(ctx context.Context, aggregateID ID, opts ...domain.RetrieveEventsOption) ([]domain.Event, error) {
+	options := domain.NewRetrieveEventsOptions(opts...)
+	if options.BatchSize <= 0 {
+		return s.fetchPage(aggregateID, options.ToVersion)(ctx, options.FromVersion, 0)
+	}
+
+	result := make([]domain.Event, 0)
+	for event, err := range s.StreamEvents(ctx, aggregateID, opts...) {
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, event)
+	}
+	return result, nil
+}
+
+// StreamEvents streams the events of the given aggregate ordered by version,
+// fetching them from the database in pages of the given batch size.
+// It implements the domain.EventStreamer interface.
+func (s *EventStore[ID]) StreamEvents(ctx context.Context, aggregateID ID, opts ...domain.RetrieveEventsOption) iter.Seq2[domain.Event, error] {
+	options := domain.NewRetrieveEventsOptions(opts...)
+	return domain.PaginateEvents(ctx, options, s.fetchPage(aggregateID, options.ToVersion))
+}
+
+// fetchPage returns a function fetching a page of at most limit events of the given aggregate,
+// up to toVersion. A limit lower than or equal to 0 fetches all the events.
+func (s *EventStore[ID]) fetchPage(aggregateID ID, toVersion int) func(ctx context.Context, fromVersion, limit int) ([]domain.Event, error) {
+	return func(ctx context.Context, fromVersion, limit int) ([]domain.Event, error) {
+		q := s.newQuery()
+		q.where("aggregate_id = " + q.arg(s.cfg.FormatAggregateID(aggregateID)))
+		if fromVersion > 0 {
+			q.where("aggregate_version >= " + q.arg(fromVersion))
+		}
+		if toVersion > 0 {
+			q.where("aggregate_version <= " + q.arg(toVersion))
+		}
+		q.orderBy("aggregate_version")
+		q.limit(limit)
+
+		records, err := s.query(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.decode(records)
+	}
+}
+
+// Search searches the events matching the given criteria ordered by global position,
+// or by the sort field of the criteria, and paginated.
+//
+// The filters on the event columns are applied by the database; the timestamp, metadata
+// and custom criteria, the sorting and the cursor are applied to the fetched events.
+// The limit and offset are pushed to the database when no filter nor sort is left.
+func (s *EventStore[ID]) Search(ctx context.Context, criteria *domain.SearchCriteriaOptions) ([]domain.Event, error) {
+	q := s.newQuery()
+	q.orderBy("global_position")
+	if criteria != nil {
+		q.whereIn("aggregate_id", toAny(criteria.AggregateIDs()))
+		q.whereIn("aggregate_name", toAny(criteria.AggregateNames()))
+		q.whereIn("aggregate_version", toAny(criteria.AggregateVersions()))
+		q.whereIn("event_name", toAny(criteria.EventNames()))
+		minVersion, maxVersion := criteria.VersionRange()
+		if minVersion > 0 {
+			q.where("aggregate_version >= " + q.arg(int(minVersion)))
+		}
+		if maxVersion > 0 {
+			q.where("aggregate_version <= " + q.arg(int(maxVersion)))
+		}
+	}
+
+	if criteria == nil || canPushPagination(criteria) {
+		if criteria != nil {
+			if _, desc := criteria.Sort(); desc {
+				q.orderBy("global_position DESC")
+			}
+			q.limit(criteria.Limit())
+			q.offset(criteria.Offset())
+		}
+
+		records, err := s.query(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		return s.decode(records)
+	}
+
+	records, err := s.query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make([]domain.StoredEvent, len(records))
+	for i, record := range records {
+		event, err := s.cfg.Codec.Decode(record)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode event %s: %w", record.Name, err)
+		}
+		stored[i] = domain.StoredEvent{Position: record.Position, Event: event}
+	}
+
+	// the aggregate IDs have been matched in their stored format
+	remaining := *criteria
+	stored, err = domain.SearchStoredEvents(stored, remaining.WithSearchAggregateIDs())
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]domain.Event, len(stored))
+	for i, se := range stored {
+		events[i] = se.Event
+	}
+	return events, nil
+}
+
+// ReadAll reads up to batchSize events, in commit order, starting from the given position (inclusive).
+// Appends are serialized by the dialect append lock, so a position is never committed after a
+// higher one and readers checkpointing on the last position read do not skip events.
+// It implements the domain.EventStreamReader interface.
+func (s *EventStore[ID]) ReadAll(
+	ctx context.Context,
+	fromPosition domain.GlobalPosition,
+	batchSize int,
+	opts ...domain.ReadAllOption,
+) ([]domain.StoredEvent, error) {
+	options := domain.NewReadAllOptions(opts...)
+
+	q := s.newQuery()
+	q.where("global_position >= " + q.arg(int64(fromPosition)))
+	q.whereIn("aggregate_name", toAny(options.AggregateNames))
+	q.whereIn("event_name", toAny(options.EventNames))
+	q.orderBy("global_position")
+	q.limit(batchSize)
+
+	records, err := s.query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]domain.StoredEvent, len(records))
+	for i, record := range records {
+		event, err := s.cfg.Codec.Decode(record)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode event %s: %w", record.Name, err)
+		}
+		result[i] = domain.StoredEvent{Position: record.Position, Event: event}
+	}
+
+	return result, nil
+}
+
+// StreamExists reports whether the given aggregate has any event, without reading them.
+// It implements the domain.StreamExistenceChecker interface.
+func (s *EventStore[ID]) StreamExists(ctx context.Context, aggregateID ID) (bool, error) {
+	if s.cfg.Dialect == nil {
+		return false, ErrNilDialect
+	}
+
+	var exists int
+	err := s.querier().QueryRowContext(ctx,
+		fmt.Sprintf("SELECT 1 FROM %s WHERE aggregate_id = %s LIMIT 1", s.cfg.TableName, s.cfg.Dialect.Placeholder(1)),
+		s.cfg.FormatAggregateID(aggregateID),
+	).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not check stream: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteStream deletes the events of the given aggregate.
+// It implements the domain.StreamDeleter interface.
+func (s *EventStore[ID]) DeleteStream(ctx context.Context, aggregateID ID) error {
+	if s.cfg.Dialect == nil {
+		return ErrNilDialect
+	}
+
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE aggregate_id = %s",
+		s.cfg.TableName, s.cfg.Dialect.Placeholder(1))
+	if _, err := s.querier().ExecContext(ctx, stmt, s.cfg.FormatAggregateID(aggregateID)); err != nil {
+		return fmt.Errorf("could not delete stream: %w", err)
+	}
+	return nil
+}
+
+// TruncateStream deletes the events of the given aggregate with a version lower than beforeVersion.
+// It implements the domain.StreamTruncater interface.
+func (s *EventStore[ID]) TruncateStream(ctx context.Context, aggregateID ID, beforeVersion domain.AggregateVersion) error {
+	if s.cfg.Dialect == nil {
+		return ErrNilDialect
+	}
+
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE aggregate_id = %s AND aggregate_version < %s",
+		s.cfg.TableName, s.cfg.Dialect.Placeholder(1), s.cfg.Dialect.Placeholder(2))
+	if _, err := s.querier().ExecContext(ctx, stmt, s.cfg.FormatAggregateID(aggregateID), int64(beforeVersion)); err != nil {
+		return fmt.Errorf("could not truncate stream: %w", err)
+	}
+	return nil
+}
+
+func (s *EventStore[ID]) querier() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// inTx runs fn inside the bound transaction, or inside a new one if the store is not bound.
+func (s *EventStore[ID]) inTx(ctx context.Context, fn func(q querier) error) error {
+	if s.cfg.Dialect == nil {
+		return ErrNilDialect
+	}
+
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *EventStore[ID]) insert(ctx context.Context, q querier, events []domain.Event) error {
+	d := s.cfg.Dialect
+	if lock := d.AppendLockStatement(s.cfg.TableName); lock != "" {
+		if _, err := q.ExecContext(ctx, lock); err != nil {
+			return fmt.Errorf("could not lock events table for append: %w", err)
+		}
+	}
+
+	stmt := fmt.Sprintf(
+		"INSERT INTO %s (aggregate_id, aggregate_name, aggregate_version, event_name, event_revision, occurred_at, metadata, payload) VALUES (%s, %s, %s, %s, %s, %s, %s, %s)",
+		s.cfg.TableName,
+		d.Placeholder(1), d.Placeholder(2), d.Placeholder(3), d.Placeholder(4),
+		d.Placeholder(5), d.Placeholder(6), d.Placeholder(7), d.Placeholder(8),
+	)
+
+	for _, event := range events {
+		ref := event.AggregateRef()
+		if ref == nil {
+			return fmt.Errorf("event %s has nil aggregate reference", event.Name())
+		}
+
+		id, ok := ref.ID().(ID)
+		if !ok {
+			return fmt.Errorf("invalid aggregate ID type: %T", ref.ID())
+		}
+
+		payload, err := s.cfg.Codec.Encode(event)
+		if err != nil {
+			return fmt.Errorf("could not encode event %s: %w", event.Name(), err)
+		}
+
+		metadata, err := encodeMetadata(domain.EventMetadata(event))
+		if err != nil {
+			return fmt.Errorf("could not encode event %s metadata: %w", event.Name(), err)
+		}
+
+		_, err = q.ExecContext(ctx, stmt,
+			s.cfg.FormatAggregateID(id),
+			ref.Name(),
+			int64(ref.Version()),
+			event.Name(),
+			domain.EventRevision(event),
+			event.Timestamp().UTC(),
+			metadata,
+			payload,
+		)
+		if d.IsUniqueViolation(err) {
+			return s.conflictError(ctx, ref)
+		}
+		if err != nil {
+			return fmt.Errorf("could not insert event %s: %w", event.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// conflictError builds the concurrency conflict error of a rejected insert.
+// The current version is read outside the failed transaction, as some engines abort it.
+func (s *EventStore[ID]) conflictError(ctx context.Context, ref *domain.EventAggregateReference) error {
+	expected := ref.Version() - 1
+	actual, err := s.currentVersion(ctx, s.db, ref.ID())
+	if err != nil {
+		actual = expected
+	}
+	return domain.NewConcurrencyConflictError(ref.ID(), expected, actual)
+}
+
+func (s *EventStore[ID]) currentVersion(ctx context.Context, q querier, aggregateID any) (domain.AggregateVersion, error) {
+	id, ok := aggregateID.(ID)
+	if !ok {
+		return 0, fmt.Errorf("invalid aggregate ID type: %T", aggregateID)
+	}
+
+	var version sql.NullInt64
+	err := q.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT MAX(aggregate_version) FROM %s WHERE aggregate_id = %s", s.cfg.TableName, s.cfg.Dialect.Placeholder(1)),
+		s.cfg.FormatAggregateID(id),
+	).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("could not retrieve aggregate version: %w", err)
+	}
+
+	return domain.AggregateVersion(version.Int64), nil
+}
+
+func (s *EventStore[ID]) query(ctx context.Context, q *selectQuery) ([]EventRecord, error) {
+	if s.cfg.Dialect == nil {
+		return nil, ErrNilDialect
+	}
+
+	rows, err := s.querier().QueryContext(ctx, q.String(), q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query events: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]EventRecord, 0)
+	for rows.Next() {
+		var (
+			record      EventRecord
+			position    int64
+			aggregateID string
+			name        string
+			version     int64
+			metadata    sql.NullString
+		)
+
+		err := rows.Scan(&position, &aggregateID, &name, &version, &record.Name, &record.Revision, &record.Timestamp, &metadata, &record.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan event: %w", err)
+		}
+
+		record.Metadata, err = decodeMetadata(metadata)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode event %s metadata: %w", record.Name, err)
+		}
+
+		id, err := s.cfg.ParseAggregateID(aggregateID)
+		if err != nil {
+			return nil, err
+		}
+
+		record.Position = domain.GlobalPosition(position)
+		record.AggregateRef = domain.NewEventAggregateReference(id, name, domain.AggregateVersion(version))
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read events: %w", err)
+	}
+
+	return records, nil
+}
+
+func (s *EventStore[ID]) decode(records []EventRecord) ([]domain.Event, error) {
+	events := make([]domain.Event, len(records))
+	for i, record := range records {
+		event, err := s.cfg.Codec.Decode(record)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode event %s: %w", record.Name, err)
+		}
+		events[i] = event
+	}
+	return events, nil
+}
+
+func (s *EventStore[ID]) newQuery() *selectQuery {
+	return &selectQuery{table: s.cfg.TableName, dialect: s.cfg.Dialect}
+}
+
+// canPushPagination reports whether the database can paginate the search results,
+// i.e. the events are sorted by position and the remaining filters are applied by the database.
+func canPushPagination(criteria *domain.SearchCriteriaOptions) bool {
+	from, to := criteria.TimestampRange()
+	field, _ := criteria.Sort()
+	return field == domain.SearchSortByPosition &&
+		criteria.After() == "" &&
+		from.IsZero() && to.IsZero() &&
+		len(criteria.Metadata()) == 0 &&
+		criteria.Criteria() == nil &&
+		(criteria.Offset() == 0 || criteria.Limit() > 0)
+}
+
+func toAny[T any](values []T) []any {
+	result := make([]any, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
+
+// encodeMetadata encodes the event metadata as a JSON object, or NULL if empty.
+func encodeMetadata(metadata map[string]string) (sql.NullString, error) {
+	if len(metadata) == 0 {
+		return sql.NullString{}, nil
+	}
+
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func decodeMetadata(metadata sql.NullString) (map[string]string, error) {
+	if !metadata.Valid || metadata.String == "" {
+		return nil, nil
+	}
+
+	decoded := make(map[string]string)
+	if err := json.Unmarshal([]byte(metadata.String), &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
@@ -0,0 +1,382 @@
+package sqlstore_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xfrr/go-cqrsify/domain"
+	"github.com/xfrr/go-cqrsify/domain/sqlstore"
+)
+
+var dbCounter atomic.Int64
+
+func newSQLiteEventStore(t *testing.T) (*sqlstore.EventStore[string], *sql.DB) {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:events_%d?mode=memory&cache=shared&_txlock=immediate", dbCounter.Add(1))
+	db, err := sql.Open("sqlite3", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	store := sqlstore.NewEventStore(db, sqlstore.Config[string]{Dialect: sqlstore.SQLite()})
+	require.NoError(t, store.Migrate(context.Background()))
+	require.NoError(t, store.Migrate(context.Background()), "migration must be idempotent")
+	return store, db
+}
+
+func recordEvents(t *testing.T, agg *domain.BaseAggregate[string], names ...string) []domain.Event {
+	t.Helper()
+
+	for _, name := range names {
+		require.NoError(t, domain.NextEvent(agg, domain.NewEvent(name, domain.CreateEventAggregateRef(agg))))
+	}
+	events := append([]domain.Event(nil), agg.AggregateEvents()...)
+	return events
+}
+
+func TestEventStore_SaveAndRetrieve(t *testing.T) {
+	ctx := context.Background()
+	sut, _ := newSQLiteEventStore(t)
+
+	agg := domain.NewAggregate("order-1", "order")
+	events := recordEvents(t, agg, "order.placed", "order.paid", "order.shipped", "order.delivered")
+	require.NoError(t, sut.Save(ctx, events))
+
+	t.Run("should retrieve all the events", func(t *testing.T) {
+		retrieved, err := sut.RetrieveMany(ctx, "order-1")
+		require.NoError(t, err)
+		require.Len(t, retrieved, 4)
+
+		for i, evt := range retrieved {
+			assert.Equal(t, events[i].Name(), evt.Name())
+			assert.Equal(t, "order-1", evt.AggregateRef().ID())
+			assert.Equal(t, "order", evt.AggregateRef().Name())
+			assert.Equal(t, domain.AggregateVersion(i+1), evt.AggregateRef().Version())
+			assert.True(t, events[i].Timestamp().Equal(evt.Timestamp()))
+		}
+
+		restored := domain.NewAggregate("order-1", "order")
+		require.NoError(t, domain.RestoreAggregateFromHistory(restored, retrieved))
+		assert.Equal(t, domain.AggregateVersion(4), restored.AggregateVersion())
+	})
+
+	t.Run("should retrieve events by version range", func(t *testing.T) {
+		retrieved, err := sut.RetrieveMany(ctx, "order-1",
+			domain.RetrieveEventsFromVersion(2),
+			domain.RetrieveEventsToVersion(3),
+		)
+		require.NoError(t, err)
+		require.Len(t, retrieved, 2)
+		assert.Equal(t, "order.paid", retrieved[0].Name())
+		assert.Equal(t, "order.shipped", retrieved[1].Name())
+	})
+
+	t.Run("should page through events using the batch size", func(t *testing.T) {
+		retrieved, err := sut.RetrieveMany(ctx, "order-1", domain.RetrieveEventsBatchSize(3))
+		require.NoError(t, err)
+		require.Len(t, retrieved, 4)
+		assert.Equal(t, domain.AggregateVersion(4), retrieved[3].AggregateRef().Version())
+	})
+
+	t.Run("should return no events for unknown aggregates", func(t *testing.T) {
+		retrieved, err := sut.RetrieveMany(ctx, "unknown")
+		require.NoError(t, err)
+		assert.Empty(t, retrieved)
+	})
+}
+
+func TestEventStore_ConcurrencyConflict(t *testing.T) {
+	ctx := context.Background()
+	sut, _ := newSQLiteEventStore(t)
+
+	writer1 := domain.NewAggregate("order-1", "order")
+	writer2 := domain.NewAggregate("order-1", "order")
+
+	require.NoError(t, sut.SaveVersioned(ctx, 0, recordEvents(t, writer1, "order.placed")))
+
+	t.Run("should reject versioned saves with a stale expected version", func(t *testing.T) {
+		err := sut.SaveVersioned(ctx, 0, recordEvents(t, writer2, "order.placed"))
+		require.ErrorIs(t, err, domain.ErrConcurrencyConflict)
+
+		var conflictErr domain.ConcurrencyConflictError
+		require.ErrorAs(t, err, &conflictErr)
+		assert.Equal(t, "order-1", conflictErr.AggregateID)
+		assert.Equal(t, domain.AggregateVersion(0), conflictErr.ExpectedVersion)
+		assert.Equal(t, domain.AggregateVersion(1), conflictErr.ActualVersion)
+	})
+
+	t.Run("should reject duplicated versions through the unique constraint", func(t *testing.T) {
+		err := sut.Save(ctx, writer2.AggregateEvents())
+		require.ErrorIs(t, err, domain.ErrConcurrencyConflict)
+
+		retrieved, err := sut.RetrieveMany(ctx, "order-1")
+		require.NoError(t, err)
+		assert.Len(t, retrieved, 1)
+	})
+
+	t.Run("should work with the event source repository", func(t *testing.T) {
+		repo := domain.NewEventSourceRepository[string](sut)
+
+		loaded1 := domain.NewAggregate("order-1", "order")
+		require.NoError(t, repo.Load(ctx, loaded1))
+		loaded2 := domain.NewAggregate("order-1", "order")
+		require.NoError(t, repo.Load(ctx, loaded2))
+
+		recordEvents(t, loaded1, "order.paid")
+		require.NoError(t, repo.Save(ctx, loaded1))
+
+		recordEvents(t, loaded2, "order.cancelled")
+		require.ErrorIs(t, repo.Save(ctx, loaded2), domain.ErrConcurrencyConflict)
+	})
+}
+
+func TestEventStore_Search(t *testing.T) {
+	ctx := context.Background()
+	sut, _ := newSQLiteEventStore(t)
+
+	require.NoError(t, sut.Save(ctx, recordEvents(t, domain.NewAggregate("order-1", "order"), "order.placed", "order.paid")))
+	require.NoError(t, sut.Save(ctx, recordEvents(t, domain.NewAggregate("order-2", "order"), "order.placed")))
+	require.NoError(t, sut.Save(ctx, recordEvents(t, domain.NewAggregate("customer-1", "customer"), "customer.registered")))
+
+	events, err := sut.Search(ctx, domain.SearchCriteria())
+	require.NoError(t, err)
+	assert.Len(t, events, 4)
+
+	events, err = sut.Search(ctx, domain.SearchCriteria().WithSearchAggregateNames("order"))
+	require.NoError(t, err)
+	assert.Len(t, events, 3)
+
+	events, err = sut.Search(ctx, domain.SearchCriteria().WithSearchAggregateIDs("order-1", "customer-1").WithSearchAggregateVersions(1))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "order.placed", events[0].Name())
+	assert.Equal(t, "customer.registered", events[1].Name())
+
+	t.Run("should filter by event names and version range", func(t *testing.T) {
+		events, err := sut.Search(ctx, domain.SearchCriteria().
+			WithSearchEventNames("order.placed", "order.paid").
+			WithSearchVersionRange(2, 0))
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "order.paid", events[0].Name())
+	})
+
+	t.Run("should paginate in the database", func(t *testing.T) {
+		events, err := sut.Search(ctx, domain.SearchCriteria().WithSearchLimit(2).WithSearchOffset(1))
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, "order.paid", events[0].Name())
+		assert.Equal(t, "order.placed", events[1].Name())
+	})
+
+	t.Run("should sort and paginate with a cursor", func(t *testing.T) {
+		criteria := domain.SearchCriteria().WithSearchSort(domain.SearchSortByAggregateID, true).WithSearchLimit(2)
+		page, err := sut.Search(ctx, criteria)
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		assert.Equal(t, "order-2", page[0].AggregateRef().ID())
+		assert.Equal(t, "order-1", page[1].AggregateRef().ID())
+		assert.Equal(t, domain.AggregateVersion(2), page[1].AggregateRef().Version())
+
+		page, err = sut.Search(ctx, criteria.WithSearchAfter(domain.NewSearchCursor(page[1])))
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		assert.Equal(t, "order.placed", page[0].Name())
+		assert.Equal(t, "customer.registered", page[1].Name())
+	})
+}
+
+func TestEventStore_ReadAll(t *testing.T) {
+	ctx := context.Background()
+	sut, _ := newSQLiteEventStore(t)
+
+	require.NoError(t, sut.Save(ctx, recordEvents(t, domain.NewAggregate("order-1", "order"), "order.placed", "order.paid")))
+	require.NoError(t, sut.Save(ctx, recordEvents(t, domain.NewAggregate("customer-1", "customer"), "customer.registered")))
+
+	events, err := sut.ReadAll(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	for i, se := range events {
+		assert.Equal(t, domain.GlobalPosition(i+1), se.Position)
+	}
+
+	events, err = sut.ReadAll(ctx, 2, 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "order.paid", events[0].Event.Name())
+
+	events, err = sut.ReadAll(ctx, 0, 0, domain.ReadAllAggregateNames("customer"))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, domain.GlobalPosition(3), events[0].Position)
+}
+
+func TestEventStore_StreamEvents(t *testing.T) {
+	ctx := context.Background()
+	sut, _ := newSQLiteEventStore(t)
+
+	agg := domain.NewAggregate("order-1", "order")
+	require.NoError(t, sut.Save(ctx, recordEvents(t, agg, "order.placed", "order.paid", "order.shipped", "order.delivered")))
+
+	var names []string
+	for evt, err := range sut.StreamEvents(ctx, "order-1", domain.RetrieveEventsToVersion(3), domain.RetrieveEventsBatchSize(2)) {
+		require.NoError(t, err)
+		names = append(names, evt.Name())
+	}
+	assert.Equal(t, []string{"order.placed", "order.paid", "order.shipped"}, names)
+
+	loaded := domain.NewAggregate("order-1", "order")
+	require.NoError(t, domain.RestoreAggregateFromStream(loaded, sut.StreamEvents(ctx, "order-1", domain.RetrieveEventsBatchSize(3))))
+	assert.Equal(t, domain.AggregateVersion(4), loaded.AggregateVersion())
+}
+
+func TestEventStore_StreamLifecycle(t *testing.T) {
+	ctx := context.Background()
+	sut, _ := newSQLiteEventStore(t)
+
+	order1 := domain.NewAggregate("order-1", "order")
+	order2 := domain.NewAggregate("order-2", "order")
+	require.NoError(t, sut.Save(ctx, recordEvents(t, order1, "order.placed", "order.paid", "order.shipped")))
+	require.NoError(t, sut.Save(ctx, recordEvents(t, order2, "order.placed", "order.paid")))
+
+	t.Run("should truncate the stream", func(t *testing.T) {
+		require.NoError(t, sut.TruncateStream(ctx, "order-1", 3))
+
+		retrieved, err := sut.RetrieveMany(ctx, "order-1")
+		require.NoError(t, err)
+		require.Len(t, retrieved, 1)
+		assert.Equal(t, "order.shipped", retrieved[0].Name())
+	})
+
+	t.Run("should delete the stream", func(t *testing.T) {
+		require.NoError(t, sut.DeleteStream(ctx, "order-2"))
+
+		retrieved, err := sut.RetrieveMany(ctx, "order-2")
+		require.NoError(t, err)
+		assert.Empty(t, retrieved)
+
+		retrieved, err = sut.RetrieveMany(ctx, "order-1")
+		require.NoError(t, err)
+		assert.Len(t, retrieved, 1)
+	})
+
+	t.Run("should check whether the stream exists", func(t *testing.T) {
+		exists, err := sut.StreamExists(ctx, "order-1")
+		require.NoError(t, err)
+		assert.True(t, exists, "a truncated stream still exists")
+
+		exists, err = sut.StreamExists(ctx, "order-2")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+func TestEventStore_WithTx(t *testing.T) {
+	ctx := context.Background()
+	sut, db := newSQLiteEventStore(t)
+
+	t.Run("should discard events on rollback", func(t *testing.T) {
+		tx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, sut.WithTx(tx).Save(ctx, recordEvents(t, domain.NewAggregate("order-1", "order"), "order.placed")))
+		require.NoError(t, tx.Rollback())
+
+		events, err := sut.RetrieveMany(ctx, "order-1")
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("should persist events on commit", func(t *testing.T) {
+		tx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, sut.WithTx(tx).SaveVersioned(ctx, 0, recordEvents(t, domain.NewAggregate("order-1", "order"), "order.placed")))
+		require.NoError(t, tx.Commit())
+
+		events, err := sut.RetrieveMany(ctx, "order-1")
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+	})
+}
+
+func TestEventStore_CustomAggregateID(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:events_%d?mode=memory&cache=shared", dbCounter.Add(1)))
+	require.NoError(t, err)
+	defer db.Close()
+
+	sut := sqlstore.NewEventStore(db, sqlstore.Config[int]{
+		Dialect:   sqlstore.SQLite(),
+		TableName: "int_events",
+		ParseAggregateID: func(s string) (int, error) {
+			var id int
+			_, err := fmt.Sscan(s, &id)
+			return id, err
+		},
+	})
+	require.NoError(t, sut.Migrate(ctx))
+
+	agg := domain.NewAggregate(42, "counter")
+	require.NoError(t, domain.NextEvent(agg, domain.NewEvent("counter.incremented", domain.CreateEventAggregateRef(agg), domain.WithEventTimestamp(time.Now()))))
+	require.NoError(t, sut.Save(ctx, agg.AggregateEvents()))
+
+	events, err := sut.RetrieveMany(ctx, 42)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, 42, events[0].AggregateRef().ID())
+}
+
+type orderPlaced struct {
+	domain.BaseEvent
+	Amount int `json:"amount"`
+}
+
+func TestEventStore_RegistryCodec(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:events_%d?mode=memory&cache=shared", dbCounter.Add(1)))
+	require.NoError(t, err)
+	defer db.Close()
+
+	registry := domain.NewEventRegistry(domain.JSONEventCodec{})
+	require.NoError(t, domain.RegisterEvent[orderPlaced](registry, "order.placed"))
+
+	sut := sqlstore.NewEventStore(db, sqlstore.Config[string]{
+		Dialect: sqlstore.SQLite(),
+		Codec:   sqlstore.NewRegistryCodec(registry),
+	})
+	require.NoError(t, sut.Migrate(ctx))
+
+	ref := domain.NewEventAggregateReference("order-1", "order", 1)
+	require.NoError(t, sut.Save(ctx, []domain.Event{
+		orderPlaced{BaseEvent: domain.NewEvent("order.placed", ref, domain.WithCausationID("cmd-1")), Amount: 42},
+	}))
+
+	events, err := sut.RetrieveMany(ctx, "order-1")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	placed, ok := events[0].(orderPlaced)
+	require.True(t, ok, "retrieved event type = %T, want orderPlaced", events[0])
+	assert.Equal(t, 42, placed.Amount)
+	assert.Equal(t, "order-1", placed.AggregateRef().ID())
+	assert.Equal(t, "cmd-1", domain.EventCausationID(placed))
+}
+
+func TestDialects(t *testing.T) {
+	assert.Equal(t, "$3", sqlstore.PostgreSQL().Placeholder(3))
+	assert.Equal(t, "?", sqlstore.SQLite().Placeholder(3))
+	assert.NotEmpty(t, sqlstore.PostgreSQL().MigrationStatements("events"))
+	assert.True(t, sqlstore.PostgreSQL().IsUniqueViolation(fmt.Errorf("pq: duplicate key value violates unique constraint \"events_aggregate_version_key\"")))
+	assert.False(t, sqlstore.SQLite().IsUniqueViolation(nil))
+	assert.Equal(t, "SELECT pg_advisory_xact_lock(hashtext('events'))", sqlstore.PostgreSQL().AppendLockStatement("events"))
+	assert.Empty(t, sqlstore.SQLite().AppendLockStatement("events"))
+}
@@ -0,0 +1,71 @@
+package sqlstore
+
+import (
+	"strconv"
+	"strings"
+)
+
+const selectColumns = "global_position, aggregate_id, aggregate_name, aggregate_version, event_name, event_revision, occurred_at, metadata, payload"
+
+// selectQuery builds the SELECT statements over the events table.
+type selectQuery struct {
+	table      string
+	dialect    Dialect
+	conditions []string
+	order      string
+	limitN     int
+	offsetN    int
+	args       []any
+}
+
+// arg binds the given value and returns its placeholder.
+func (q *selectQuery) arg(v any) string {
+	q.args = append(q.args, v)
+	return q.dialect.Placeholder(len(q.args))
+}
+
+func (q *selectQuery) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+func (q *selectQuery) whereIn(column string, values []any) {
+	if len(values) == 0 {
+		return
+	}
+
+	placeholders := make([]string, len(values))
+	for i, v := range values {
+		placeholders[i] = q.arg(v)
+	}
+	q.where(column + " IN (" + strings.Join(placeholders, ", ") + ")")
+}
+
+func (q *selectQuery) orderBy(column string) {
+	q.order = column
+}
+
+func (q *selectQuery) limit(n int) {
+	q.limitN = n
+}
+
+func (q *selectQuery) offset(n int) {
+	q.offsetN = n
+}
+
+func (q *selectQuery) String() string {
+	var sb strings.Builder
+	sb.WriteString("SELECT " + selectColumns + " FROM " + q.table)
+	if len(q.conditions) > 0 {
+		sb.WriteString(" WHERE " + strings.Join(q.conditions, " AND "))
+	}
+	if q.order != "" {
+		sb.WriteString(" ORDER BY " + q.order)
+	}
+	if q.limitN > 0 {
+		sb.WriteString(" LIMIT " + strconv.Itoa(q.limitN))
+	}
+	if q.offsetN > 0 {
+		sb.WriteString(" OFFSET " + strconv.Itoa(q.offsetN))
+	}
+	return sb.String()
+}
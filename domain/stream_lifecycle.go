@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// StreamDeletedEventName is the name of the tombstone event closing a soft-deleted stream.
+const StreamDeletedEventName = "$stream.deleted"
+
+var (
+	// ErrStreamDeleted is the sentinel error matched by StreamDeletedError.
+	ErrStreamDeleted = errors.New("aggregate stream deleted")
+	// ErrStreamOperationNotSupported is returned when the event store does not support
+	// the requested stream lifecycle operation.
+	ErrStreamOperationNotSupported = errors.New("stream operation not supported by the event store")
+	// ErrInvalidTruncateVersion is returned when a stream is truncated before an invalid version.
+	ErrInvalidTruncateVersion = errors.New("invalid truncate version")
+)
+
+// StreamDeleter represents an event store that can physically remove the stream of an aggregate.
+type StreamDeleter[ID comparable] interface {
+	// DeleteStream removes all the events of the given aggregate.
+	DeleteStream(ctx context.Context, aggregateID ID) error
+}
+
+// StreamTruncater represents an event store that can physically remove the head of the stream of an aggregate.
+type StreamTruncater[ID comparable] interface {
+	// TruncateStream removes the events of the given aggregate with a version lower than beforeVersion.
+	TruncateStream(ctx context.Context, aggregateID ID, beforeVersion AggregateVersion) error
+}
+
+// StreamExistenceChecker represents an event store that can check whether the stream
+// of an aggregate exists without reading its events.
+type StreamExistenceChecker[ID comparable] interface {
+	// StreamExists reports whether the given aggregate has any event in the store.
+	StreamExists(ctx context.Context, aggregateID ID) (bool, error)
+}
+
+// StreamArchiver represents an event store that can move the stream of an aggregate to an archive.
+type StreamArchiver[ID comparable] interface {
+	// ArchiveStream moves all the events of the given aggregate to the archive.
+	ArchiveStream(ctx context.Context, aggregateID ID) error
+}
+
+// StreamDeletedError is returned when loading an aggregate whose stream has been soft-deleted.
+type StreamDeletedError[ID comparable] struct {
+	ID ID
+	// Version is the version of the tombstone event.
+	Version AggregateVersion
+}
+
+func (e StreamDeletedError[ID]) Error() string {
+	return fmt.Sprintf("aggregate stream deleted (id: %v, version: %d)", e.ID, e.Version)
+}
+
+// Is reports whether the target is ErrStreamDeleted.
+func (e StreamDeletedError[ID]) Is(target error) bool {
+	return target == ErrStreamDeleted
+}
+
+func NewStreamDeletedError[ID comparable](id ID, version AggregateVersion) StreamDeletedError[ID] {
+	return StreamDeletedError[ID]{ID: id, Version: version}
+}
+
+// IsStreamDeletedEvent reports whether the given event is a stream tombstone.
+func IsStreamDeletedEvent(event Event) bool {
+	return event != nil && event.Name() == StreamDeletedEventName
+}
+
+// SoftDelete records a tombstone event on the aggregate.
+// The stream is kept, as required by append-only stores, but the aggregate
+// can no longer be loaded once the tombstone has been saved.
+func SoftDelete[ID comparable](agg EventSourcedAggregate[ID], opts ...EventOption) error {
+	if agg == nil {
+		return ErrNilAggregate
+	}
+
+	return NextEvent(agg, NewEvent(StreamDeletedEventName, CreateEventAggregateRef(agg), opts...))
+}
+
+// checkStreamDeleted returns a StreamDeletedError if the last event of the history is a tombstone.
+func checkStreamDeleted[ID comparable](id ID, history History) error {
+	if len(history) == 0 {
+		return nil
+	}
+
+	return checkStreamDeletedEvent(id, history[len(history)-1])
+}
+
+// checkStreamDeletedEvent returns a StreamDeletedError if the given last event of a stream is a tombstone.
+func checkStreamDeletedEvent[ID comparable](id ID, last Event) error {
+	if !IsStreamDeletedEvent(last) {
+		return nil
+	}
+
+	return NewStreamDeletedError(id, last.AggregateRef().Version())
+}
+
+// StreamExists reports whether the given aggregate has any event in the store.
+//
+// If the retriever implements StreamExistenceChecker the check is delegated to it,
+// otherwise the first event of the stream is read.
+func StreamExists[ID comparable](ctx context.Context, retriever EventRetriever[ID], aggregateID ID) (bool, error) {
+	if c, ok := retriever.(StreamExistenceChecker[ID]); ok {
+		return c.StreamExists(ctx, aggregateID)
+	}
+
+	for _, err := range StreamEvents(ctx, retriever, aggregateID, RetrieveEventsBatchSize(1)) {
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func archiveStream[ID comparable](ctx context.Context, store EventStore[ID], aggregateID ID) error {
+	archiver, ok := store.(StreamArchiver[ID])
+	if !ok {
+		return ErrStreamOperationNotSupported
+	}
+	return archiver.ArchiveStream(ctx, aggregateID)
+}
+
+func deleteStream[ID comparable](ctx context.Context, store EventStore[ID], aggregateID ID) error {
+	deleter, ok := store.(StreamDeleter[ID])
+	if !ok {
+		return ErrStreamOperationNotSupported
+	}
+	return deleter.DeleteStream(ctx, aggregateID)
+}
+
+func truncateStream[ID comparable](ctx context.Context, store EventStore[ID], aggregateID ID, beforeVersion AggregateVersion) error {
+	truncater, ok := store.(StreamTruncater[ID])
+	if !ok {
+		return ErrStreamOperationNotSupported
+	}
+	return truncater.TruncateStream(ctx, aggregateID, beforeVersion)
+}
@@ -0,0 +1,191 @@
+package domain_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xfrr/go-cqrsify/domain"
+	"github.com/xfrr/go-cqrsify/domain/inmemory"
+)
+
+type lifecycleEventStoreMock struct {
+	eventStoreMock
+}
+
+func (m *lifecycleEventStoreMock) DeleteStream(_ context.Context, id string) error {
+	m.events = slices.DeleteFunc(m.events, func(evt domain.Event) bool {
+		return evt.AggregateRef().ID() == id
+	})
+	return nil
+}
+
+func (m *lifecycleEventStoreMock) TruncateStream(_ context.Context, id string, before domain.AggregateVersion) error {
+	m.events = slices.DeleteFunc(m.events, func(evt domain.Event) bool {
+		return evt.AggregateRef().ID() == id && evt.AggregateRef().Version() < before
+	})
+	return nil
+}
+
+func TestEventSourceRepository_SoftDelete(t *testing.T) {
+	ctx := context.Background()
+	store := &eventStoreMock{}
+	sut := domain.NewEventSourceRepository[string](store)
+
+	agg := newCounterAggregate("agg-1")
+	agg.increment(t)
+	require.NoError(t, sut.Save(ctx, agg))
+
+	require.NoError(t, sut.SoftDelete(ctx, agg))
+	require.Len(t, store.events, 2)
+	require.True(t, domain.IsStreamDeletedEvent(store.events[1]))
+
+	t.Run("should not load a deleted aggregate", func(t *testing.T) {
+		err := sut.Load(ctx, newCounterAggregate("agg-1"))
+		require.ErrorIs(t, err, domain.ErrStreamDeleted)
+
+		var deletedErr domain.StreamDeletedError[string]
+		require.ErrorAs(t, err, &deletedErr)
+		require.Equal(t, "agg-1", deletedErr.ID)
+		require.Equal(t, domain.AggregateVersion(2), deletedErr.Version)
+	})
+
+	t.Run("should load the versions before the deletion", func(t *testing.T) {
+		loaded := newCounterAggregate("agg-1")
+		require.NoError(t, sut.LoadVersion(ctx, loaded, 1))
+		require.Equal(t, 1, loaded.count)
+	})
+
+	t.Run("should exclude a deleted aggregate from the search", func(t *testing.T) {
+		aggs, err := sut.Search(ctx, domain.SearchCriteria().WithSearchAggregateIDs("agg-1"))
+		require.NoError(t, err)
+		require.Empty(t, aggs)
+	})
+}
+
+func TestEventSourceRepository_SoftDelete_Snapshots(t *testing.T) {
+	ctx := context.Background()
+	snapshots := inmemory.NewSnapshotStore[string]()
+	sut := domain.NewEventSourceRepository[string](&eventStoreMock{},
+		domain.WithSnapshots[string](snapshots, domain.SnapshotEveryNEvents(1)),
+	)
+
+	agg := newCounterAggregate("agg-1")
+	agg.increment(t)
+	require.NoError(t, sut.Save(ctx, agg))
+	require.NoError(t, sut.SoftDelete(ctx, agg))
+
+	t.Run("should not snapshot the state at the tombstone", func(t *testing.T) {
+		snapshot, err := snapshots.LatestSnapshot(ctx, "agg-1")
+		require.NoError(t, err)
+		require.True(t, snapshot.Deleted)
+		require.Equal(t, domain.AggregateVersion(2), snapshot.AggregateVersion)
+		require.Empty(t, snapshot.State)
+	})
+
+	t.Run("should not load a deleted aggregate from its snapshot", func(t *testing.T) {
+		err := sut.Load(ctx, newCounterAggregate("agg-1"))
+
+		var deletedErr domain.StreamDeletedError[string]
+		require.ErrorAs(t, err, &deletedErr)
+		require.Equal(t, domain.AggregateVersion(2), deletedErr.Version)
+	})
+}
+
+// snapshotStoreWithoutDeleter hides the SnapshotDeleter implementation of the wrapped store.
+type snapshotStoreWithoutDeleter struct {
+	domain.SnapshotStore[string]
+}
+
+func TestEventSourceRepository_HardDelete(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should fail if the store cannot delete streams", func(t *testing.T) {
+		sut := domain.NewEventSourceRepository[string](&eventStoreMock{})
+		err := sut.HardDelete(ctx, newCounterAggregate("agg-1"))
+		require.ErrorIs(t, err, domain.ErrStreamOperationNotSupported)
+	})
+
+	t.Run("should fail if the snapshot store cannot delete snapshots", func(t *testing.T) {
+		store := &lifecycleEventStoreMock{}
+		snapshots := inmemory.NewSnapshotStore[string]()
+		sut := domain.NewEventSourceRepository[string](store,
+			domain.WithSnapshots[string](snapshotStoreWithoutDeleter{snapshots}, domain.SnapshotEveryNEvents(1)),
+		)
+
+		agg := newCounterAggregate("agg-1")
+		agg.increment(t)
+		require.NoError(t, sut.Save(ctx, agg))
+
+		err := sut.HardDelete(ctx, agg)
+		require.ErrorIs(t, err, domain.ErrStreamOperationNotSupported)
+		require.Len(t, store.events, 1, "the stream must be kept along with its snapshot")
+	})
+
+	t.Run("should delete the stream and the snapshot", func(t *testing.T) {
+		store := &lifecycleEventStoreMock{}
+		snapshots := inmemory.NewSnapshotStore[string]()
+		sut := domain.NewEventSourceRepository(
+			store,
+			domain.WithSnapshots[string](snapshots, domain.SnapshotEveryNEvents(1)),
+			domain.WithUpcasters[string](domain.NewUpcasterRegistry()),
+		)
+
+		agg := newCounterAggregate("agg-1")
+		agg.increment(t)
+		require.NoError(t, sut.Save(ctx, agg))
+
+		require.NoError(t, sut.HardDelete(ctx, agg))
+		require.Empty(t, store.events)
+
+		_, err := snapshots.LatestSnapshot(ctx, "agg-1")
+		require.ErrorIs(t, err, domain.ErrSnapshotNotFound)
+
+		var notFoundErr domain.NotFoundError[string]
+		require.ErrorAs(t, sut.Load(ctx, newCounterAggregate("agg-1")), &notFoundErr)
+	})
+}
+
+func TestEventSourceRepository_Truncate(t *testing.T) {
+	ctx := context.Background()
+	store := &lifecycleEventStoreMock{}
+	snapshots := inmemory.NewSnapshotStore[string]()
+	sut := domain.NewEventSourceRepository(
+		store,
+		domain.WithSnapshots[string](snapshots, domain.SnapshotEveryNEvents(3)),
+	)
+
+	agg := newCounterAggregate("agg-1")
+	for range 4 {
+		agg.increment(t)
+	}
+	require.NoError(t, sut.Save(ctx, agg))
+	agg.increment(t)
+	require.NoError(t, sut.Save(ctx, agg))
+
+	t.Run("should reject versions not covered by the snapshot", func(t *testing.T) {
+		err := sut.Truncate(ctx, agg, 6)
+		require.ErrorIs(t, err, domain.ErrInvalidTruncateVersion)
+
+		err = sut.Truncate(ctx, agg, 1)
+		require.ErrorIs(t, err, domain.ErrInvalidTruncateVersion)
+		require.Len(t, store.events, 5)
+	})
+
+	t.Run("should truncate the events covered by the snapshot", func(t *testing.T) {
+		require.NoError(t, sut.Truncate(ctx, agg, 5))
+		require.Len(t, store.events, 1)
+
+		loaded := newCounterAggregate("agg-1")
+		require.NoError(t, sut.Load(ctx, loaded))
+		require.Equal(t, 5, loaded.count)
+		require.Equal(t, domain.AggregateVersion(5), loaded.AggregateVersion())
+	})
+
+	t.Run("should fail without snapshots", func(t *testing.T) {
+		sut := domain.NewEventSourceRepository[string](store)
+		err := sut.Truncate(ctx, agg, 2)
+		require.ErrorIs(t, err, domain.ErrInvalidTruncateVersion)
+	})
+}
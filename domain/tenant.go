@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+)
+
+// EventMetadataTenantID is the event metadata key holding the ID of the tenant owning the event.
+const EventMetadataTenantID = "tenant_id"
+
+// ErrTenantMismatch is the sentinel error matched by TenantMismatchError.
+var ErrTenantMismatch = errors.New("cross-tenant access rejected")
+
+// TenantMismatchError is returned when a tenant-scoped context accesses
+// an aggregate, a stream or an event owned by another tenant.
+type TenantMismatchError struct {
+	// Expected is the tenant of the context.
+	Expected string
+	// Actual is the tenant owning the accessed data, empty if not owned by any tenant.
+	Actual string
+}
+
+func (e TenantMismatchError) Error() string {
+	return fmt.Sprintf("cross-tenant access rejected (expected tenant: %q, actual tenant: %q)", e.Expected, e.Actual)
+}
+
+// Is reports whether the target is ErrTenantMismatch.
+func (e TenantMismatchError) Is(target error) bool {
+	return target == ErrTenantMismatch
+}
+
+func NewTenantMismatchError(expected, actual string) TenantMismatchError {
+	return TenantMismatchError{Expected: expected, Actual: actual}
+}
+
+type contextKeyTenant struct{}
+
+// ContextWithTenant returns a copy of ctx scoped to the given tenant.
+//
+// The tenant is also added to the event metadata carried by ctx, so that the events
+// created with WithEventMetadataFromContext are stamped with it. See WithEventTenant.
+//
+// Repositories and event stores enforcing tenant isolation only give a scoped context access
+// to the data of its tenant. A context without tenant is not scoped and has access to all the data.
+func ContextWithTenant(ctx context.Context, tenantID string) context.Context {
+	ctx = ContextWithEventMetadata(ctx, map[string]string{EventMetadataTenantID: tenantID})
+	return context.WithValue(ctx, contextKeyTenant{}, tenantID)
+}
+
+// TenantFromContext returns the tenant the given context is scoped to, if any.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(contextKeyTenant{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// WithEventTenant stamps the event with the given tenant ID.
+func WithEventTenant(tenantID string) EventOption {
+	return WithEventMetadataKeyValue(EventMetadataTenantID, tenantID)
+}
+
+// EventTenantID returns the ID of the tenant owning the given event, if any.
+func EventTenantID(event Event) string {
+	return eventMetadataValue(event, EventMetadataTenantID)
+}
+
+// CheckTenant returns a TenantMismatchError if the given context is scoped to a tenant other than tenantID.
+func CheckTenant(ctx context.Context, tenantID string) error {
+	expected, ok := TenantFromContext(ctx)
+	if !ok || expected == tenantID {
+		return nil
+	}
+	return NewTenantMismatchError(expected, tenantID)
+}
+
+// CheckEventTenant returns a TenantMismatchError if the given context is scoped to a tenant
+// other than the one the event is stamped with.
+func CheckEventTenant(ctx context.Context, event Event) error {
+	return CheckTenant(ctx, EventTenantID(event))
+}
+
+// TenantSearchCriteria returns a copy of the given search criteria restricted to the events of the tenant
+// the context is scoped to. The criteria is returned as is if the context is not scoped.
+func TenantSearchCriteria(ctx context.Context, criteria *SearchCriteriaOptions) *SearchCriteriaOptions {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		return criteria
+	}
+
+	scoped := SearchCriteria()
+	if criteria != nil {
+		*scoped = *criteria
+		scoped.metadata = maps.Clone(criteria.metadata)
+	}
+	return scoped.WithSearchMetadata(EventMetadataTenantID, tenantID)
+}
@@ -0,0 +1,137 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xfrr/go-cqrsify/domain"
+	"github.com/xfrr/go-cqrsify/domain/filestore"
+	"github.com/xfrr/go-cqrsify/domain/inmemory"
+)
+
+func TestContextWithTenant(t *testing.T) {
+	ctx := domain.ContextWithTenant(context.Background(), "acme")
+
+	tenantID, ok := domain.TenantFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "acme", tenantID)
+
+	event := domain.NewEvent("order.placed", domain.NewEventAggregateReference("order-1", "order", 1), domain.WithEventMetadataFromContext(ctx))
+	assert.Equal(t, "acme", domain.EventTenantID(event), "the events created from the context are stamped")
+
+	require.NoError(t, domain.CheckEventTenant(ctx, event))
+	require.NoError(t, domain.CheckTenant(context.Background(), "globex"), "contexts without tenant are not scoped")
+
+	var mismatchErr domain.TenantMismatchError
+	require.ErrorAs(t, domain.CheckTenant(ctx, "globex"), &mismatchErr)
+	assert.Equal(t, "acme", mismatchErr.Expected)
+	assert.Equal(t, "globex", mismatchErr.Actual)
+	require.ErrorIs(t, mismatchErr, domain.ErrTenantMismatch)
+}
+
+func TestTenantEventStore(t *testing.T) {
+	store, err := filestore.Open(filestore.Config[string]{Dir: t.TempDir()})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	acme := domain.ContextWithTenant(context.Background(), "acme")
+	globex := domain.ContextWithTenant(context.Background(), "globex")
+	repo := domain.NewTypedEventSourcedRepository(store, newCounterAggregate, domain.WithTenantIsolation[string]())
+
+	increment := func(ctx context.Context, agg *counterAggregate) {
+		require.NoError(t, domain.NextEvent(agg, domain.NewEvent("counter.incremented", domain.CreateEventAggregateRef(agg), domain.WithEventMetadataFromContext(ctx))))
+	}
+
+	acmeCounter := newCounterAggregate("counter-1")
+	increment(acme, acmeCounter)
+	require.NoError(t, repo.Save(acme, acmeCounter))
+
+	globexCounter := newCounterAggregate("counter-2")
+	increment(globex, globexCounter)
+	increment(globex, globexCounter)
+	require.NoError(t, repo.Save(globex, globexCounter))
+
+	t.Run("should load the aggregates of the tenant", func(t *testing.T) {
+		agg, err := repo.Get(acme, "counter-1")
+		require.NoError(t, err)
+		assert.Equal(t, 1, agg.count)
+	})
+
+	t.Run("should reject loading the aggregates of other tenants", func(t *testing.T) {
+		_, err := repo.Get(acme, "counter-2")
+		require.ErrorIs(t, err, domain.ErrTenantMismatch)
+	})
+
+	t.Run("should reject appending to the streams of other tenants", func(t *testing.T) {
+		agg, err := repo.Get(globex, "counter-2")
+		require.NoError(t, err)
+
+		increment(acme, agg)
+		require.ErrorIs(t, repo.Save(acme, agg), domain.ErrTenantMismatch)
+	})
+
+	t.Run("should reject events stamped with another tenant", func(t *testing.T) {
+		agg := newCounterAggregate("counter-3")
+		increment(globex, agg)
+		require.ErrorIs(t, repo.Save(acme, agg), domain.ErrTenantMismatch)
+	})
+
+	t.Run("should search the aggregates of the tenant", func(t *testing.T) {
+		aggs, err := repo.Search(acme, domain.SearchCriteria())
+		require.NoError(t, err)
+		require.Len(t, aggs, 1)
+		assert.Equal(t, "counter-1", aggs[0].AggregateID())
+
+		aggs, err = repo.Search(context.Background(), domain.SearchCriteria())
+		require.NoError(t, err)
+		assert.Len(t, aggs, 2, "contexts without tenant are not scoped")
+	})
+
+	t.Run("should read the events of the tenant", func(t *testing.T) {
+		sut := domain.NewTenantEventStore[string](store)
+
+		events, err := sut.ReadAll(globex, 0, 1)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "counter-2", events[0].Event.AggregateRef().ID(), "the events of other tenants do not count in the batch")
+
+		events, err = sut.ReadAll(acme, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+	})
+
+	t.Run("should reject deleting the streams of other tenants", func(t *testing.T) {
+		sut := domain.NewTenantEventStore[string](store)
+		require.ErrorIs(t, sut.DeleteStream(acme, "counter-2"), domain.ErrTenantMismatch)
+	})
+
+	t.Run("should reject restoring the snapshots of other tenants", func(t *testing.T) {
+		repo := domain.NewTypedEventSourcedRepository(store, newCounterAggregate,
+			domain.WithSnapshots[string](inmemory.NewSnapshotStore[string](), domain.SnapshotEveryNEvents(1)),
+			domain.WithTenantIsolation[string](),
+		)
+
+		agg := newCounterAggregate("counter-4")
+		increment(globex, agg)
+		require.NoError(t, repo.Save(globex, agg))
+
+		_, err := repo.Get(acme, "counter-4")
+		require.ErrorIs(t, err, domain.ErrTenantMismatch)
+	})
+
+	t.Run("should stamp the saved events with the tenant of the context", func(t *testing.T) {
+		agg := newCounterAggregate("counter-5")
+		increment(context.Background(), agg)
+		require.NoError(t, repo.Save(acme, agg))
+
+		loaded, err := repo.Get(acme, "counter-5")
+		require.NoError(t, err)
+		assert.Equal(t, 1, loaded.count)
+
+		_, err = repo.Get(globex, "counter-5")
+		require.ErrorIs(t, err, domain.ErrTenantMismatch)
+	})
+}
@@ -6,6 +6,7 @@ import (
 )
 
 var _ CommandBus = (*InMemoryCommandBus)(nil)
+var _ Shutdowner = (*InMemoryCommandBus)(nil)
 
 // InMemoryCommandBus is an in-memory implementation of CommandBus.
 type InMemoryCommandBus struct {
@@ -43,3 +44,8 @@ func (b *InMemoryCommandBus) Use(mws ...MessageHandlerMiddleware) {
 func (b *InMemoryCommandBus) Close() error {
 	return b.bus.Close()
 }
+
+// Shutdown shuts down the underlying message bus gracefully. See InMemoryMessageBus.Shutdown.
+func (b *InMemoryCommandBus) Shutdown(ctx context.Context) error {
+	return b.bus.Shutdown(ctx)
+}
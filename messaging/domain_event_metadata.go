@@ -0,0 +1,42 @@
+package messaging
+
+import (
+	"context"
+	"maps"
+
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+// DomainEventMetadataFromMessage derives the metadata of the domain events
+// raised while handling the given message.
+//
+// The message metadata is copied as is, the message ID becomes the causation ID,
+// and the correlation ID defaults to the message ID when the message has none.
+func DomainEventMetadataFromMessage(msg Message) map[string]string {
+	metadata := maps.Clone(msg.MessageMetadata())
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+
+	if id := msg.MessageID(); id != "" {
+		metadata[domain.EventMetadataCausationID] = id
+		if metadata[domain.EventMetadataCorrelationID] == "" {
+			metadata[domain.EventMetadataCorrelationID] = id
+		}
+	}
+
+	return metadata
+}
+
+// DomainEventMetadataMiddleware bridges the metadata of the handled message to the
+// domain events raised by the handler. The metadata is stored in the handler context,
+// inherited by the events created with domain.WithEventMetadataFromContext(ctx) and
+// stamped by the repositories on the events saved with that context.
+func DomainEventMetadataMiddleware() MessageHandlerMiddleware {
+	return func(next MessageHandler[Message]) MessageHandler[Message] {
+		return MessageHandlerFn[Message](func(ctx context.Context, msg Message) error {
+			ctx = domain.ContextWithEventMetadata(ctx, DomainEventMetadataFromMessage(msg))
+			return next.Handle(ctx, msg)
+		})
+	}
+}
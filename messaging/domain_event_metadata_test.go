@@ -0,0 +1,71 @@
+package messaging_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xfrr/go-cqrsify/domain"
+	"github.com/xfrr/go-cqrsify/messaging"
+)
+
+func TestDomainEventMetadataFromMessage(t *testing.T) {
+	t.Run("should use the message ID as causation and correlation ID", func(t *testing.T) {
+		cmd := messaging.NewBaseCommand("order.place", messaging.WithID("cmd-1"))
+
+		metadata := messaging.DomainEventMetadataFromMessage(cmd)
+		assert.Equal(t, "cmd-1", metadata[domain.EventMetadataCausationID])
+		assert.Equal(t, "cmd-1", metadata[domain.EventMetadataCorrelationID])
+	})
+
+	t.Run("should keep the message correlation ID and metadata", func(t *testing.T) {
+		cmd := messaging.NewBaseCommand("order.place",
+			messaging.WithID("cmd-2"),
+			messaging.WithMetadataKeyValue(domain.EventMetadataCorrelationID, "flow-1"),
+			messaging.WithMetadataKeyValue(domain.EventMetadataActor, "alice"),
+		)
+
+		metadata := messaging.DomainEventMetadataFromMessage(cmd)
+		assert.Equal(t, "cmd-2", metadata[domain.EventMetadataCausationID])
+		assert.Equal(t, "flow-1", metadata[domain.EventMetadataCorrelationID])
+		assert.Equal(t, "alice", metadata[domain.EventMetadataActor])
+		assert.Empty(t, cmd.MessageMetadata()[domain.EventMetadataCausationID], "message metadata must not be modified")
+	})
+}
+
+func TestDomainEventMetadataMiddleware(t *testing.T) {
+	const subject = "order.place"
+	bus := messaging.NewInMemoryCommandBus(messaging.ConfigureInMemoryMessageBusSubjects(subject))
+	bus.Use(messaging.DomainEventMetadataMiddleware())
+
+	raised := make(chan domain.Event, 1)
+	_, err := bus.Subscribe(
+		context.Background(),
+		messaging.MessageHandlerFn[messaging.Command](func(ctx context.Context, _ messaging.Command) error {
+			agg := domain.NewAggregate("order-1", "order")
+			raised <- domain.NewEvent("order.placed", domain.CreateEventAggregateRef(agg), domain.WithEventMetadataFromContext(ctx))
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	cmd := messaging.NewBaseCommand(subject,
+		messaging.WithID("cmd-1"),
+		messaging.WithMetadataKeyValue(domain.EventMetadataActor, "alice"),
+	)
+	require.NoError(t, bus.Dispatch(ctx, cmd))
+
+	select {
+	case event := <-raised:
+		assert.Equal(t, "cmd-1", domain.EventCausationID(event))
+		assert.Equal(t, "cmd-1", domain.EventCorrelationID(event))
+		assert.Equal(t, "alice", domain.EventActor(event))
+	case <-ctx.Done():
+		t.Fatal("handler was not invoked")
+	}
+}
@@ -0,0 +1,217 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+// Message metadata keys set by DefaultDomainEventMapper.
+const (
+	MetadataAggregateID      = "aggregate_id"
+	MetadataAggregateName    = "aggregate_name"
+	MetadataAggregateVersion = "aggregate_version"
+)
+
+// ErrEventsNotPublished is the sentinel error matched by PublishDomainEventsError.
+var ErrEventsNotPublished = errors.New("committed domain events not published")
+
+// PublishDomainEventsError is returned in strict mode when the committed domain events
+// could not be published. The aggregate events have been saved anyway.
+type PublishDomainEventsError struct {
+	// Published is the number of events published before the failure.
+	Published int
+	// Unpublished are the committed domain events that were not published, in commit order.
+	Unpublished []domain.Event
+	// Err is the mapping or publishing error.
+	Err error
+}
+
+func (e PublishDomainEventsError) Error() string {
+	return fmt.Sprintf("%d of %d committed domain events not published: %v",
+		len(e.Unpublished), e.Published+len(e.Unpublished), e.Err)
+}
+
+func (e PublishDomainEventsError) Unwrap() error { return e.Err }
+
+func (e PublishDomainEventsError) Is(target error) bool {
+	return target == ErrEventsNotPublished
+}
+
+// DomainEventMapper maps a committed domain event into the event published on the bus.
+type DomainEventMapper func(ctx context.Context, event domain.Event) (Event, error)
+
+// DomainEventMessage is the event published by DefaultDomainEventMapper.
+// It carries the original domain event so in-process subscribers can access its payload.
+type DomainEventMessage struct {
+	BaseEvent
+
+	DomainEvent domain.Event
+}
+
+// DefaultDomainEventMapper maps a domain event into a DomainEventMessage.
+//
+// The message type is the event name and its ID is derived from the aggregate
+// reference, so re-publishing the same committed event yields the same message ID.
+// The event metadata and the aggregate reference are copied into the message metadata.
+func DefaultDomainEventMapper(_ context.Context, event domain.Event) (Event, error) {
+	metadata := domain.EventMetadata(event)
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+
+	id := uuid.Nil
+	if ref := event.AggregateRef(); ref != nil {
+		aggregateID := fmt.Sprint(ref.ID())
+		version := strconv.Itoa(int(ref.Version()))
+
+		metadata[MetadataAggregateID] = aggregateID
+		metadata[MetadataAggregateName] = ref.Name()
+		metadata[MetadataAggregateVersion] = version
+		id = uuid.NewV5(uuid.NamespaceURL, ref.Name()+"/"+aggregateID+"/"+version)
+	}
+
+	modifiers := []BaseEventModifier{
+		WithTimestamp(event.Timestamp()),
+		WithMetadata(metadata),
+	}
+	if id != uuid.Nil {
+		modifiers = append(modifiers, WithID(id.String()))
+	}
+
+	return DomainEventMessage{
+		BaseEvent:   NewBaseEvent(event.Name(), modifiers...),
+		DomainEvent: event,
+	}, nil
+}
+
+// EventPublishingRepositoryConfig configures an EventPublishingRepository.
+type EventPublishingRepositoryConfig struct {
+	// Mapper maps the committed domain events. Defaults to DefaultDomainEventMapper.
+	Mapper DomainEventMapper
+	// Strict makes Save return a PublishDomainEventsError when the events could not be published.
+	// Events are then published one by one, stopping at the first failure.
+	Strict bool
+	// ErrorHandler handles the publish failures in non-strict mode.
+	// If nil, they are dropped.
+	ErrorHandler func(err error)
+}
+
+// EventPublishingRepositoryConfiger is the functional option pattern.
+type EventPublishingRepositoryConfiger func(*EventPublishingRepositoryConfig)
+
+// ConfigureEventPublishingRepositoryMapper sets the domain event mapper.
+func ConfigureEventPublishingRepositoryMapper(mapper DomainEventMapper) EventPublishingRepositoryConfiger {
+	return func(c *EventPublishingRepositoryConfig) { c.Mapper = mapper }
+}
+
+// ConfigureEventPublishingRepositoryStrict enables the strict mode.
+func ConfigureEventPublishingRepositoryStrict(strict bool) EventPublishingRepositoryConfiger {
+	return func(c *EventPublishingRepositoryConfig) { c.Strict = strict }
+}
+
+// ConfigureEventPublishingRepositoryErrorHandler sets the non-strict publish error handler.
+func ConfigureEventPublishingRepositoryErrorHandler(fn func(err error)) EventPublishingRepositoryConfiger {
+	return func(c *EventPublishingRepositoryConfig) { c.ErrorHandler = fn }
+}
+
+// EventPublishingRepository decorates an event-sourced repository to publish
+// the aggregate events on an EventPublisher once they have been saved.
+//
+// The uncommitted events are captured before the underlying repository commits
+// them (see domain.EventCommitter) and published only after a successful save,
+// so events rejected by the store, e.g. on a concurrency conflict, are never published.
+type EventPublishingRepository[T domain.EventSourcedAggregate[ID], ID comparable] struct {
+	domain.EventSourcedRepository[T, ID]
+
+	publisher EventPublisher
+	cfg       EventPublishingRepositoryConfig
+}
+
+// NewEventPublishingRepository creates a new EventPublishingRepository.
+func NewEventPublishingRepository[T domain.EventSourcedAggregate[ID], ID comparable](
+	repository domain.EventSourcedRepository[T, ID],
+	publisher EventPublisher,
+	opts ...EventPublishingRepositoryConfiger,
+) *EventPublishingRepository[T, ID] {
+	cfg := EventPublishingRepositoryConfig{
+		Mapper: DefaultDomainEventMapper,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.Mapper == nil {
+		cfg.Mapper = DefaultDomainEventMapper
+	}
+
+	return &EventPublishingRepository[T, ID]{
+		EventSourcedRepository: repository,
+		publisher:              publisher,
+		cfg:                    cfg,
+	}
+}
+
+// Save saves the aggregate and publishes its committed events.
+func (r *EventPublishingRepository[T, ID]) Save(ctx context.Context, agg T) error {
+	events := append([]domain.Event(nil), agg.AggregateEvents()...)
+
+	if err := r.EventSourcedRepository.Save(ctx, agg); err != nil {
+		return err
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	err := r.publish(ctx, events)
+	if err == nil {
+		return nil
+	}
+
+	if r.cfg.Strict {
+		return err
+	}
+
+	if r.cfg.ErrorHandler != nil {
+		r.cfg.ErrorHandler(err)
+	}
+	return nil
+}
+
+func (r *EventPublishingRepository[T, ID]) publish(ctx context.Context, events []domain.Event) error {
+	messages := make([]Event, len(events))
+	for i, event := range events {
+		msg, err := r.cfg.Mapper(ctx, event)
+		if err != nil {
+			return PublishDomainEventsError{
+				Unpublished: events,
+				Err:         fmt.Errorf("could not map domain event %s: %w", event.Name(), err),
+			}
+		}
+		messages[i] = msg
+	}
+
+	if !r.cfg.Strict {
+		if err := r.publisher.Publish(ctx, messages...); err != nil {
+			return PublishDomainEventsError{Unpublished: events, Err: err}
+		}
+		return nil
+	}
+
+	for i, msg := range messages {
+		if err := r.publisher.Publish(ctx, msg); err != nil {
+			return PublishDomainEventsError{
+				Published:   i,
+				Unpublished: events[i:],
+				Err:         err,
+			}
+		}
+	}
+
+	return nil
+}
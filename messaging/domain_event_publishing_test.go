@@ -0,0 +1,174 @@
+package messaging_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xfrr/go-cqrsify/domain"
+	"github.com/xfrr/go-cqrsify/domain/inmemory"
+	"github.com/xfrr/go-cqrsify/messaging"
+	messagingmock "github.com/xfrr/go-cqrsify/messaging/mock"
+)
+
+func newOrder(t *testing.T, id string, events ...string) *domain.BaseAggregate[string] {
+	t.Helper()
+
+	agg := domain.NewAggregate(id, "order")
+	for _, name := range events {
+		require.NoError(t, domain.NextEvent(agg, domain.NewEvent(name, domain.CreateEventAggregateRef(agg), domain.WithActor("alice"))))
+	}
+	return agg
+}
+
+func TestEventPublishingRepository_Save(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should publish the committed events", func(t *testing.T) {
+		var published []messaging.Event
+		publisher := &messagingmock.EventPublisher{
+			PublishFunc: func(_ context.Context, events ...messaging.Event) error {
+				published = append(published, events...)
+				return nil
+			},
+		}
+		sut := messaging.NewEventPublishingRepository(inmemory.NewEventSourcedAggregateRepository(), publisher)
+
+		agg := newOrder(t, "order-1", "order.placed", "order.paid")
+		require.NoError(t, sut.Save(ctx, agg))
+		assert.Empty(t, agg.AggregateEvents())
+
+		require.Len(t, published, 2)
+		assert.Equal(t, "order.placed", published[0].MessageType())
+		assert.Equal(t, "order.paid", published[1].MessageType())
+
+		msg, ok := published[1].(messaging.DomainEventMessage)
+		require.True(t, ok, "published event type = %T, want DomainEventMessage", published[1])
+		assert.Equal(t, "order-1", msg.MessageMetadata()[messaging.MetadataAggregateID])
+		assert.Equal(t, "order", msg.MessageMetadata()[messaging.MetadataAggregateName])
+		assert.Equal(t, "2", msg.MessageMetadata()[messaging.MetadataAggregateVersion])
+		assert.Equal(t, "alice", msg.MessageMetadata()[domain.EventMetadataActor])
+		assert.Equal(t, domain.AggregateVersion(2), msg.DomainEvent.AggregateRef().Version())
+		assert.NotEmpty(t, msg.MessageID())
+		assert.NotEqual(t, published[0].MessageID(), msg.MessageID())
+	})
+
+	t.Run("should derive stable message IDs", func(t *testing.T) {
+		ref := domain.NewEventAggregateReference("order-1", "order", 1)
+		first, err := messaging.DefaultDomainEventMapper(ctx, domain.NewEvent("order.placed", ref))
+		require.NoError(t, err)
+		second, err := messaging.DefaultDomainEventMapper(ctx, domain.NewEvent("order.placed", ref))
+		require.NoError(t, err)
+		assert.Equal(t, first.MessageID(), second.MessageID())
+	})
+
+	t.Run("should not publish events rejected by the store", func(t *testing.T) {
+		publisher := &messagingmock.EventPublisher{
+			PublishFunc: func(context.Context, ...messaging.Event) error { return nil },
+		}
+		sut := messaging.NewEventPublishingRepository(inmemory.NewEventSourcedAggregateRepository(), publisher)
+		require.NoError(t, sut.Save(ctx, newOrder(t, "order-1", "order.placed")))
+
+		err := sut.Save(ctx, newOrder(t, "order-1", "order.placed"))
+		require.ErrorIs(t, err, domain.ErrConcurrencyConflict)
+		assert.Len(t, publisher.PublishCalls(), 1)
+	})
+
+	t.Run("should use the configured mapper", func(t *testing.T) {
+		var published []messaging.Event
+		publisher := &messagingmock.EventPublisher{
+			PublishFunc: func(_ context.Context, events ...messaging.Event) error {
+				published = append(published, events...)
+				return nil
+			},
+		}
+		sut := messaging.NewEventPublishingRepository(
+			inmemory.NewEventSourcedAggregateRepository(),
+			publisher,
+			messaging.ConfigureEventPublishingRepositoryMapper(func(_ context.Context, event domain.Event) (messaging.Event, error) {
+				return messaging.NewBaseEvent("com.shop." + event.Name() + ".v1"), nil
+			}),
+		)
+
+		require.NoError(t, sut.Save(ctx, newOrder(t, "order-1", "order.placed")))
+		require.Len(t, published, 1)
+		assert.Equal(t, "com.shop.order.placed.v1", published[0].MessageType())
+	})
+}
+
+func TestEventPublishingRepository_PublishFailures(t *testing.T) {
+	ctx := context.Background()
+	errBroker := errors.New("broker unavailable")
+
+	failingOn := func(failing string) *messagingmock.EventPublisher {
+		return &messagingmock.EventPublisher{
+			PublishFunc: func(_ context.Context, events ...messaging.Event) error {
+				for _, event := range events {
+					if event.MessageType() == failing {
+						return errBroker
+					}
+				}
+				return nil
+			},
+		}
+	}
+
+	t.Run("should report the handled failures in non-strict mode", func(t *testing.T) {
+		var handled error
+		repo := inmemory.NewEventSourcedAggregateRepository()
+		sut := messaging.NewEventPublishingRepository(repo, failingOn("order.paid"),
+			messaging.ConfigureEventPublishingRepositoryErrorHandler(func(err error) { handled = err }),
+		)
+
+		require.NoError(t, sut.Save(ctx, newOrder(t, "order-1", "order.placed", "order.paid")))
+		require.ErrorIs(t, handled, errBroker)
+		require.ErrorIs(t, handled, messaging.ErrEventsNotPublished)
+
+		exists, err := repo.Exists(ctx, domain.NewAggregate("order-1", "order"))
+		require.NoError(t, err)
+		assert.True(t, exists, "events must be saved despite the publish failure")
+	})
+
+	t.Run("should report partial publish failures in strict mode", func(t *testing.T) {
+		publisher := failingOn("order.paid")
+		sut := messaging.NewEventPublishingRepository(inmemory.NewEventSourcedAggregateRepository(), publisher,
+			messaging.ConfigureEventPublishingRepositoryStrict(true),
+		)
+
+		err := sut.Save(ctx, newOrder(t, "order-1", "order.placed", "order.paid", "order.shipped"))
+		require.ErrorIs(t, err, errBroker)
+
+		var publishErr messaging.PublishDomainEventsError
+		require.ErrorAs(t, err, &publishErr)
+		assert.Equal(t, 1, publishErr.Published)
+		require.Len(t, publishErr.Unpublished, 2)
+		assert.Equal(t, "order.paid", publishErr.Unpublished[0].Name())
+		assert.Equal(t, "order.shipped", publishErr.Unpublished[1].Name())
+		assert.Len(t, publisher.PublishCalls(), 2)
+	})
+
+	t.Run("should not publish anything when mapping fails", func(t *testing.T) {
+		errMapping := errors.New("unknown event")
+		publisher := failingOn("")
+		sut := messaging.NewEventPublishingRepository(inmemory.NewEventSourcedAggregateRepository(), publisher,
+			messaging.ConfigureEventPublishingRepositoryStrict(true),
+			messaging.ConfigureEventPublishingRepositoryMapper(func(_ context.Context, event domain.Event) (messaging.Event, error) {
+				if event.Name() == "order.paid" {
+					return nil, errMapping
+				}
+				return messaging.NewBaseEvent(event.Name()), nil
+			}),
+		)
+
+		err := sut.Save(ctx, newOrder(t, "order-1", "order.placed", "order.paid"))
+		require.ErrorIs(t, err, errMapping)
+
+		var publishErr messaging.PublishDomainEventsError
+		require.ErrorAs(t, err, &publishErr)
+		assert.Zero(t, publishErr.Published)
+		assert.Len(t, publishErr.Unpublished, 2)
+		assert.Empty(t, publisher.PublishCalls())
+	})
+}
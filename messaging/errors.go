@@ -11,6 +11,9 @@ var (
 	ErrMessageIsNotCommand = errors.New("message is not a command")
 	ErrMessageIsNotQuery   = errors.New("message is not a query")
 	ErrPublishOnClosedBus  = errors.New("cannot publish on closed bus")
+	ErrQueueFull           = errors.New("message bus queue is full")
+	ErrMessageDropped      = errors.New("message dropped from the full message bus queue")
+	ErrMessageInFlight     = errors.New("message is being processed by another attempt")
 )
 
 type InvalidMessageTypeError struct {
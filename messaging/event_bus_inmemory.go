@@ -6,6 +6,7 @@ import (
 )
 
 var _ EventBus = (*InMemoryEventBus)(nil)
+var _ Shutdowner = (*InMemoryEventBus)(nil)
 
 // InMemoryEventBus is an in-memory implementation of EventBus.
 type InMemoryEventBus struct {
@@ -44,3 +45,8 @@ func (b *InMemoryEventBus) Use(mws ...MessageHandlerMiddleware) {
 func (b *InMemoryEventBus) Close() error {
 	return b.bus.Close()
 }
+
+// Shutdown shuts down the underlying message bus gracefully. See InMemoryMessageBus.Shutdown.
+func (b *InMemoryEventBus) Shutdown(ctx context.Context) error {
+	return b.bus.Shutdown(ctx)
+}
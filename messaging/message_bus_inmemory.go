@@ -15,6 +15,7 @@ const (
 
 var _ MessageBus = (*InMemoryMessageBus)(nil)
 var _ MessageBusReplier = (*InMemoryMessageBus)(nil)
+var _ Shutdowner = (*InMemoryMessageBus)(nil)
 
 // InMemoryMessageBus is a simple, fast, process-local message bus.
 type InMemoryMessageBus struct {
@@ -24,17 +25,27 @@ type InMemoryMessageBus struct {
 	handlers map[string][]handlerEntry // subject -> handlers
 	nextID   uint64                    // atomic incremental id for handlers
 
-	// async pipeline (enabled if opts.AsyncWorkers > 0)
-	queue   chan queued
+	// async pipeline (enabled if opts.AsyncWorkers > 0):
+	// a queue shared by the workers, or one queue per worker when partitioned
+	queues  []chan queued
 	workers []worker
 
+	// backpressure metrics
+	nextPartition atomic.Uint64
+	blocked       atomic.Uint64
+	dropped       atomic.Uint64
+	rejected      atomic.Uint64
+
 	// composed middleware chain applied to handlers
 	mw []MessageHandlerMiddleware
 
 	// lifecycle
-	closed  bool
-	closeMu sync.Mutex
-	wg      sync.WaitGroup
+	closed     bool
+	closeMu    sync.Mutex
+	publishing sync.WaitGroup // publishes in progress
+	draining   chan struct{}  // closed on shutdown, once no more messages are enqueued
+	aborted    chan struct{}  // closed when the shutdown deadline is exceeded
+	wg         sync.WaitGroup
 }
 
 type handlerEntry struct {
@@ -50,7 +61,8 @@ type queued struct {
 }
 
 type worker struct {
-	id int
+	id    int
+	queue chan queued
 }
 
 type replyEnvelope struct {
@@ -60,24 +72,35 @@ type replyEnvelope struct {
 
 func NewInMemoryMessageBus(optFns ...MessageBusConfigConfiger) *InMemoryMessageBus {
 	cfg := MessageBusConfig{
-		AsyncWorkers: 0,
-		QueueSize:    defaultQueueSize,
-		ErrorHandler: nil,
+		AsyncWorkers:   0,
+		QueueSize:      defaultQueueSize,
+		ErrorHandler:   nil,
+		OverflowPolicy: OverflowBlock,
 	}
 	for _, fn := range optFns {
 		fn(&cfg)
 	}
+	if cfg.PartitionKey == nil {
+		cfg.PartitionKey = AggregateIDPartitionKey
+	}
 
 	b := &InMemoryMessageBus{
 		opts:     cfg,
 		handlers: make(map[string][]handlerEntry),
+		draining: make(chan struct{}),
+		aborted:  make(chan struct{}),
 	}
 
 	if cfg.AsyncWorkers > 0 {
 		qSize := max(cfg.QueueSize, 1)
-		b.queue = make(chan queued, qSize)
+		queue := make(chan queued, qSize)
+		b.queues = append(b.queues, queue)
 		for i := range cfg.AsyncWorkers {
-			b.addWorker(i)
+			if cfg.Partitioned && i > 0 {
+				queue = make(chan queued, qSize)
+				b.queues = append(b.queues, queue)
+			}
+			b.addWorker(i, queue)
 		}
 	}
 	return b
@@ -94,6 +117,8 @@ func (b *InMemoryMessageBus) Publish(ctx context.Context, msgs ...Message) error
 		b.mu.RUnlock()
 		return ErrPublishOnClosedBus
 	}
+	b.publishing.Add(1)
+	defer b.publishing.Done()
 	snap := make(map[string][]MessageHandler[Message], len(b.handlers))
 	for mt, entries := range b.handlers {
 		cp := make([]MessageHandler[Message], len(entries))
@@ -110,7 +135,7 @@ func (b *InMemoryMessageBus) Publish(ctx context.Context, msgs ...Message) error
 			return NoHandlersForMessageError{MessageType: msg.MessageType()}
 		}
 		for _, h := range handlers {
-			if b.queue == nil {
+			if len(b.queues) == 0 {
 				if err := b.deliverSync(ctx, h, msg); err != nil {
 					return err
 				}
@@ -130,6 +155,8 @@ func (b *InMemoryMessageBus) PublishRequest(ctx context.Context, msg Message) (M
 		b.mu.RUnlock()
 		return nil, ErrPublishOnClosedBus
 	}
+	b.publishing.Add(1)
+	defer b.publishing.Done()
 	entries := b.handlers[msg.MessageType()]
 	if len(entries) == 0 {
 		b.mu.RUnlock()
@@ -171,7 +198,7 @@ func (b *InMemoryMessageBus) PublishRequest(ctx context.Context, msg Message) (M
 }
 
 func (b *InMemoryMessageBus) deliverSync(ctx context.Context, h MessageHandler[Message], msg Message) error {
-	if err := b.wrap(h).Handle(ctx, msg); err != nil {
+	if err := b.wrap(h).Handle(deliveryContext(ctx, msg), msg); err != nil {
 		if b.opts.ErrorHandler != nil {
 			b.opts.ErrorHandler(msg.MessageType(), err)
 			return nil
@@ -181,16 +208,11 @@ func (b *InMemoryMessageBus) deliverSync(ctx context.Context, h MessageHandler[M
 	return nil
 }
 
-func (b *InMemoryMessageBus) enqueue(ctx context.Context, h MessageHandler[Message], msg Message) error {
-	select {
-	case b.queue <- queued{ctx: ctx, msg: msg, h: h}:
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
+func (b *InMemoryMessageBus) Subscribe(_ context.Context, h MessageHandler[Message]) (UnsubscribeFunc, error) {
+	if b.opts.DeadLetterSink != nil {
+		h = DeadLetterMiddleware(b.opts.DeadLetterSink, b.opts.DeadLetterOptions...)(h)
 	}
-}
 
-func (b *InMemoryMessageBus) Subscribe(_ context.Context, h MessageHandler[Message]) (UnsubscribeFunc, error) {
 	refs := make([]subRef, 0, len(b.opts.Subjects))
 
 	b.mu.Lock()
@@ -199,6 +221,7 @@ func (b *InMemoryMessageBus) Subscribe(_ context.Context, h MessageHandler[Messa
 		refs = append(refs, subRef{subject: subject, id: id})
 	}
 	b.mu.Unlock()
+	b.notifySubscriptions(b.opts.SubscriptionHooks.OnSubscribe, refs)
 
 	return func() error {
 		return b.unsubscribeByRefs(refs)
@@ -219,28 +242,63 @@ func (b *InMemoryMessageBus) SubscribeWithReply(_ context.Context, h MessageHand
 		refs = append(refs, subRef{subject: subject, id: id})
 	}
 	b.mu.Unlock()
+	b.notifySubscriptions(b.opts.SubscriptionHooks.OnSubscribe, refs)
 
 	return func() error {
 		return b.unsubscribeByRefs(refs)
 	}, nil
 }
 
+// Close shuts down the bus, waiting for all the accepted messages to be handled. See Shutdown.
 func (b *InMemoryMessageBus) Close() error {
+	return b.Shutdown(context.Background())
+}
+
+// Shutdown stops accepting new publishes and waits for the publishes in progress and
+// the queued messages to be handled, until the context is done.
+// The handlers publishing on the bus while it shuts down get ErrPublishOnClosedBus.
+//
+// If the context is done first, the workers stop after the message they are handling and
+// an UndeliveredMessagesError is returned with the messages left in the queues.
+// The OnShutdown subscription hook is then called for each active subscription.
+func (b *InMemoryMessageBus) Shutdown(ctx context.Context) error {
 	b.closeMu.Lock()
 	defer b.closeMu.Unlock()
 
 	b.mu.Lock()
-	defer b.mu.Unlock()
-
 	if b.closed {
+		b.mu.Unlock()
 		return nil
 	}
 	b.closed = true
+	b.mu.Unlock()
+
+	// the publishes in progress may still enqueue messages, wait for them before draining
+	err := waitContext(ctx, &b.publishing)
+	close(b.draining)
+	if err == nil {
+		err = waitContext(ctx, &b.wg)
+	}
+
+	var undelivered []Message
+	if err != nil {
+		close(b.aborted)
+		undelivered = b.dequeueAll()
+	}
+
+	b.mu.RLock()
+	refs := make([]subRef, 0, len(b.handlers))
+	for subject, entries := range b.handlers {
+		for _, entry := range entries {
+			refs = append(refs, subRef{subject: subject, id: entry.id})
+		}
+	}
+	b.mu.RUnlock()
+	b.notifySubscriptions(b.opts.SubscriptionHooks.OnShutdown, refs)
 
-	if b.queue != nil {
-		close(b.queue)
+	if err != nil {
+		return UndeliveredMessagesError{Messages: undelivered, Pending: len(undelivered), Err: err}
 	}
-	b.wg.Wait()
 	return nil
 }
 
@@ -248,19 +306,62 @@ func (b *InMemoryMessageBus) Use(mw ...MessageHandlerMiddleware) {
 	b.mw = append(b.mw, mw...)
 }
 
-func (b *InMemoryMessageBus) addWorker(id int) {
-	b.workers = append(b.workers, worker{id: id})
+func (b *InMemoryMessageBus) addWorker(id int, queue chan queued) {
+	b.workers = append(b.workers, worker{id: id, queue: queue})
 
 	b.wg.Go(func() {
-		for q := range b.queue {
-			h := b.wrap(q.h)
-			if err := h.Handle(q.ctx, q.msg); err != nil && b.opts.ErrorHandler != nil {
-				b.opts.ErrorHandler(q.msg.MessageType(), err)
+		for {
+			select {
+			case q := <-queue:
+				b.handleQueued(q)
+			case <-b.draining:
+				b.drain(queue)
+				return
 			}
 		}
 	})
 }
 
+func (b *InMemoryMessageBus) handleQueued(q queued) {
+	h := b.wrap(q.h)
+	if err := h.Handle(deliveryContext(q.ctx, q.msg), q.msg); err != nil && b.opts.ErrorHandler != nil {
+		b.opts.ErrorHandler(q.msg.MessageType(), err)
+	}
+}
+
+// drain handles the messages left in the queue, until it is empty or the shutdown is aborted.
+func (b *InMemoryMessageBus) drain(queue chan queued) {
+	for {
+		select {
+		case <-b.aborted:
+			return
+		default:
+		}
+
+		select {
+		case q := <-queue:
+			b.handleQueued(q)
+		default:
+			return
+		}
+	}
+}
+
+// dequeueAll removes the messages left in the queues and returns them.
+func (b *InMemoryMessageBus) dequeueAll() []Message {
+	var msgs []Message
+	for _, queue := range b.queues {
+		for len(queue) > 0 {
+			select {
+			case q := <-queue:
+				msgs = append(msgs, q.msg)
+			default:
+			}
+		}
+	}
+	return msgs
+}
+
 func (b *InMemoryMessageBus) wrap(h MessageHandler[Message]) MessageHandler[Message] {
 	for i := len(b.mw) - 1; i >= 0; i-- {
 		h = b.mw[i](h)
@@ -268,12 +369,29 @@ func (b *InMemoryMessageBus) wrap(h MessageHandler[Message]) MessageHandler[Mess
 	return h
 }
 
+// deliveryContext returns the handler context of the given message.
+// The bus delivers each message once, on the subject of its type.
+func deliveryContext(ctx context.Context, msg Message) context.Context {
+	return ContextWithDelivery(ctx, Delivery{Subject: msg.MessageType(), Attempt: 1})
+}
+
 // internal subscription reference
 type subRef struct {
 	subject string
 	id      uint64
 }
 
+// notifySubscriptions calls the given subscription hook, if any, for each subscription.
+// It must be called without holding mu.
+func (b *InMemoryMessageBus) notifySubscriptions(hook func(Subscription), refs []subRef) {
+	if hook == nil {
+		return
+	}
+	for _, ref := range refs {
+		hook(Subscription{ID: ref.id, Subject: ref.subject})
+	}
+}
+
 func (b *InMemoryMessageBus) addHandlerLocked(subject string, h MessageHandler[Message]) uint64 {
 	id := atomic.AddUint64(&b.nextID, 1)
 	b.handlers[subject] = append(b.handlers[subject], handlerEntry{id: id, h: h})
@@ -281,6 +399,11 @@ func (b *InMemoryMessageBus) addHandlerLocked(subject string, h MessageHandler[M
 }
 
 func (b *InMemoryMessageBus) unsubscribeByRefs(refs []subRef) error {
+	removed := make([]subRef, 0, len(refs))
+	defer func() {
+		b.notifySubscriptions(b.opts.SubscriptionHooks.OnUnsubscribe, removed)
+	}()
+
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -297,6 +420,7 @@ func (b *InMemoryMessageBus) unsubscribeByRefs(refs []subRef) error {
 				}
 				hs[last] = handlerEntry{}
 				hs = hs[:last]
+				removed = append(removed, ref)
 				if len(hs) == 0 {
 					delete(b.handlers, ref.subject)
 				} else {
@@ -4,11 +4,27 @@ package messaging
 type MessageBusConfig struct {
 	AsyncWorkers int // >0 enables async worker pool
 	QueueSize    int // channel buffer size when async
+	// Partitioned gives each async worker its own queue and routes the messages by their PartitionKey,
+	// so that the messages with the same key are handled in order while the others are handled in parallel.
+	// Otherwise the workers share a single queue and the messages may be handled out of order.
+	Partitioned bool
+	// PartitionKey extracts the partition key of the messages. Defaults to AggregateIDPartitionKey.
+	PartitionKey PartitionKeyFunc
+	// OverflowPolicy decides what a publish does when the queue is full. Defaults to OverflowBlock.
+	OverflowPolicy OverflowPolicy
 	// ErrorHandler handles handler failures (after middleware).
 	// If nil, errors are logged (if Logger exists) and dropped.
 	ErrorHandler func(evtName string, err error)
 	// Subjects is a list of subjects the bus listens to. If empty, subscribes to all messages.
 	Subjects []string
+	// DeadLetterSink receives the messages whose handler exhausted its deliveries.
+	// Each subscribed handler is retried in place and dead-lettered, see DeadLetterMiddleware.
+	// Use DeadLetterMiddleware on a handler to dead-letter a single subscription.
+	DeadLetterSink DeadLetterSink
+	// DeadLetterOptions configure the dead-lettering of the subscriptions.
+	DeadLetterOptions []DeadLetterConfiger
+	// SubscriptionHooks are called on the subscription lifecycle events.
+	SubscriptionHooks SubscriptionHooks
 }
 
 // MessageBusConfigConfiger is the functional option pattern.
@@ -29,3 +45,27 @@ func ConfigureInMemoryMessageBusErrorHandler(fn func(evtName string, err error))
 func ConfigureInMemoryMessageBusSubjects(subjects ...string) MessageBusConfigConfiger {
 	return func(o *MessageBusConfig) { o.Subjects = subjects }
 }
+
+func ConfigureInMemoryMessageBusDeadLetterSink(sink DeadLetterSink, opts ...DeadLetterConfiger) MessageBusConfigConfiger {
+	return func(o *MessageBusConfig) {
+		o.DeadLetterSink = sink
+		o.DeadLetterOptions = opts
+	}
+}
+
+// ConfigureInMemoryMessageBusPartitioning partitions the async workers by the given key,
+// or by AggregateIDPartitionKey if nil.
+func ConfigureInMemoryMessageBusPartitioning(key PartitionKeyFunc) MessageBusConfigConfiger {
+	return func(o *MessageBusConfig) {
+		o.Partitioned = true
+		o.PartitionKey = key
+	}
+}
+
+func ConfigureInMemoryMessageBusOverflowPolicy(policy OverflowPolicy) MessageBusConfigConfiger {
+	return func(o *MessageBusConfig) { o.OverflowPolicy = policy }
+}
+
+func ConfigureInMemoryMessageBusSubscriptionHooks(hooks SubscriptionHooks) MessageBusConfigConfiger {
+	return func(o *MessageBusConfig) { o.SubscriptionHooks = hooks }
+}
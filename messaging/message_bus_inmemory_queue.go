@@ -0,0 +1,146 @@
+package messaging
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+// OverflowPolicy decides what the async InMemoryMessageBus does when a publish finds its queue full.
+type OverflowPolicy string
+
+const (
+	// OverflowBlock blocks the publisher until the queue has room or its context is done.
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest drops the oldest queued message to make room for the new one.
+	// The dropped message is reported to the ErrorHandler with ErrMessageDropped.
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowError rejects the publish with ErrQueueFull.
+	OverflowError OverflowPolicy = "error"
+)
+
+// PartitionKeyFunc returns the partition key of a message.
+// The messages with the same key are handled in publish order by the same worker.
+// An empty key means that the message can be handled by any worker.
+type PartitionKeyFunc func(msg Message) string
+
+// AggregateIDPartitionKey partitions the messages by the aggregate ID of their metadata,
+// so that the events of an aggregate are handled in order. See MetadataAggregateID.
+func AggregateIDPartitionKey(msg Message) string {
+	return msg.MessageMetadata()[MetadataAggregateID]
+}
+
+// InMemoryMessageBusStats are the backpressure metrics of an async InMemoryMessageBus.
+type InMemoryMessageBusStats struct {
+	// QueueDepths is the number of messages waiting in each queue:
+	// one queue per worker when partitioned, a single shared queue otherwise.
+	QueueDepths []int
+	// QueueCapacity is the capacity of each queue.
+	QueueCapacity int
+	// Blocked is the number of publishes that waited for room in a full queue.
+	Blocked uint64
+	// Dropped is the number of messages dropped by the OverflowDropOldest policy.
+	Dropped uint64
+	// Rejected is the number of messages rejected by the OverflowError policy.
+	Rejected uint64
+}
+
+// Stats returns the backpressure metrics of the bus. They are empty if the bus is synchronous.
+func (b *InMemoryMessageBus) Stats() InMemoryMessageBusStats {
+	stats := InMemoryMessageBusStats{
+		Blocked:  b.blocked.Load(),
+		Dropped:  b.dropped.Load(),
+		Rejected: b.rejected.Load(),
+	}
+	for _, q := range b.queues {
+		stats.QueueDepths = append(stats.QueueDepths, len(q))
+		stats.QueueCapacity = cap(q)
+	}
+	return stats
+}
+
+// queueFor returns the queue of the given message: the shared queue, or the queue
+// of the worker the message key hashes to when partitioned.
+func (b *InMemoryMessageBus) queueFor(msg Message) chan queued {
+	if len(b.queues) == 1 {
+		return b.queues[0]
+	}
+
+	key := b.opts.PartitionKey(msg)
+	if key == "" {
+		n := b.nextPartition.Add(1)
+		return b.queues[n%uint64(len(b.queues))]
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return b.queues[h.Sum32()%uint32(len(b.queues))]
+}
+
+// enqueue queues the message for the given handler, applying the overflow policy when the queue is full.
+//
+// Once the shutdown is aborted, the queues are no longer drained nor dequeued, so a message
+// enqueued by a late publish would be lost: ErrPublishOnClosedBus is returned instead.
+// A message enqueued while the shutdown aborts may also be reported as undelivered.
+func (b *InMemoryMessageBus) enqueue(ctx context.Context, h MessageHandler[Message], msg Message) error {
+	q := b.queueFor(msg)
+	if b.isAborted() {
+		return ErrPublishOnClosedBus
+	}
+
+	if err := b.push(ctx, q, queued{ctx: ctx, msg: msg, h: h}); err != nil {
+		return err
+	}
+	if b.isAborted() {
+		return ErrPublishOnClosedBus
+	}
+	return nil
+}
+
+func (b *InMemoryMessageBus) isAborted() bool {
+	select {
+	case <-b.aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+func (b *InMemoryMessageBus) push(ctx context.Context, q chan queued, item queued) error {
+	select {
+	case q <- item:
+		return nil
+	default:
+	}
+
+	switch b.opts.OverflowPolicy {
+	case OverflowError:
+		b.rejected.Add(1)
+		return ErrQueueFull
+	case OverflowDropOldest:
+		for {
+			select {
+			case q <- item:
+				return nil
+			default:
+			}
+			select {
+			case old := <-q:
+				b.dropped.Add(1)
+				if b.opts.ErrorHandler != nil {
+					b.opts.ErrorHandler(old.msg.MessageType(), ErrMessageDropped)
+				}
+			default:
+			}
+		}
+	default:
+		b.blocked.Add(1)
+		select {
+		case q <- item:
+			return nil
+		case <-b.aborted:
+			return ErrPublishOnClosedBus
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -2,7 +2,11 @@ package messaging_test
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 	"github.com/xfrr/go-cqrsify/messaging"
@@ -231,3 +235,311 @@ func (s *InMemoryMessageBusTestSuite) TestNewMessageBus_SubscribeAndPublish_Work
 	err = unsub()
 	s.Require().NoError(err)
 }
+
+func (s *InMemoryMessageBusTestSuite) TestPartitionedWorkers_PreserveOrderPerKey() {
+	const subject = "test.message.type.partitioned"
+	const perKey = 50
+	keys := []string{"order-1", "order-2", "order-3"}
+
+	bus := messaging.NewInMemoryMessageBus(
+		messaging.ConfigureInMemoryMessageBusSubjects(subject),
+		messaging.ConfigureInMemoryMessageBusAsyncWorkers(4),
+		messaging.ConfigureInMemoryMessageBusPartitioning(nil),
+	)
+
+	var mu sync.Mutex
+	seen := make(map[string][]string)
+	done := make(chan struct{})
+	_, err := bus.Subscribe(
+		s.T().Context(),
+		messaging.MessageHandlerFn[messaging.Message](func(_ context.Context, m messaging.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			key := m.MessageMetadata()[messaging.MetadataAggregateID]
+			seen[key] = append(seen[key], m.MessageID())
+			if total := len(seen[keys[0]]) + len(seen[keys[1]]) + len(seen[keys[2]]); total == perKey*len(keys) {
+				close(done)
+			}
+			return nil
+		}),
+	)
+	s.Require().NoError(err)
+
+	expected := make(map[string][]string)
+	for i := range perKey {
+		for _, key := range keys {
+			id := fmt.Sprintf("%s-%d", key, i)
+			expected[key] = append(expected[key], id)
+			s.Require().NoError(bus.Publish(s.T().Context(), messaging.NewMessage(subject,
+				messaging.WithID(id),
+				messaging.WithMetadataKeyValue(messaging.MetadataAggregateID, key),
+			)))
+		}
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		s.T().Fatal("the messages were not handled")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	s.Require().Equal(expected, seen)
+	s.Require().NoError(bus.Close())
+}
+
+func (s *InMemoryMessageBusTestSuite) TestOverflowPolicies() {
+	const subject = "test.message.type.overflow"
+
+	// newBlockedBus returns a bus whose single worker is blocked handling a first message
+	// and whose queue of one message is full.
+	newBlockedBus := func(policy messaging.OverflowPolicy, errHandler func(string, error)) (*messaging.InMemoryMessageBus, chan struct{}, *[]string) {
+		bus := messaging.NewInMemoryMessageBus(
+			messaging.ConfigureInMemoryMessageBusSubjects(subject),
+			messaging.ConfigureInMemoryMessageBusAsyncWorkers(1),
+			messaging.ConfigureInMemoryMessageBusQueueBufferSize(1),
+			messaging.ConfigureInMemoryMessageBusOverflowPolicy(policy),
+			messaging.ConfigureInMemoryMessageBusErrorHandler(errHandler),
+		)
+
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		var handled []string
+		_, err := bus.Subscribe(
+			s.T().Context(),
+			messaging.MessageHandlerFn[messaging.Message](func(_ context.Context, m messaging.Message) error {
+				handled = append(handled, m.MessageID())
+				select {
+				case started <- struct{}{}:
+				default:
+				}
+				<-release
+				return nil
+			}),
+		)
+		s.Require().NoError(err)
+
+		s.Require().NoError(bus.Publish(s.T().Context(), messaging.NewMessage(subject, messaging.WithID("msg-1"))))
+		<-started
+		s.Require().NoError(bus.Publish(s.T().Context(), messaging.NewMessage(subject, messaging.WithID("msg-2"))))
+		return bus, release, &handled
+	}
+
+	s.Run("should reject the message when the queue is full", func() {
+		bus, release, _ := newBlockedBus(messaging.OverflowError, nil)
+
+		err := bus.Publish(s.T().Context(), messaging.NewMessage(subject, messaging.WithID("msg-3")))
+		s.Require().ErrorIs(err, messaging.ErrQueueFull)
+
+		stats := bus.Stats()
+		s.Equal(uint64(1), stats.Rejected)
+		s.Equal([]int{1}, stats.QueueDepths)
+		s.Equal(1, stats.QueueCapacity)
+
+		close(release)
+		s.Require().NoError(bus.Close())
+	})
+
+	s.Run("should drop the oldest queued message when the queue is full", func() {
+		var dropErr error
+		bus, release, handled := newBlockedBus(messaging.OverflowDropOldest, func(_ string, err error) { dropErr = err })
+
+		s.Require().NoError(bus.Publish(s.T().Context(), messaging.NewMessage(subject, messaging.WithID("msg-3"))))
+		s.Require().ErrorIs(dropErr, messaging.ErrMessageDropped)
+		s.Equal(uint64(1), bus.Stats().Dropped)
+
+		close(release)
+		s.Require().NoError(bus.Close())
+		s.Equal([]string{"msg-1", "msg-3"}, *handled)
+	})
+
+	s.Run("should block the publisher until the queue has room", func() {
+		bus, release, _ := newBlockedBus(messaging.OverflowBlock, nil)
+
+		ctx, cancel := context.WithTimeout(s.T().Context(), 20*time.Millisecond)
+		defer cancel()
+		err := bus.Publish(ctx, messaging.NewMessage(subject, messaging.WithID("msg-3")))
+		s.Require().ErrorIs(err, context.DeadlineExceeded)
+		s.Equal(uint64(1), bus.Stats().Blocked)
+
+		close(release)
+		s.Require().NoError(bus.Close())
+	})
+}
+
+func (s *InMemoryMessageBusTestSuite) TestShutdown() {
+	const subject = "test.message.type.shutdown"
+
+	s.Run("should drain the queued messages and reject new publishes", func() {
+		bus := messaging.NewInMemoryMessageBus(
+			messaging.ConfigureInMemoryMessageBusSubjects(subject),
+			messaging.ConfigureInMemoryMessageBusAsyncWorkers(1),
+			messaging.ConfigureInMemoryMessageBusQueueBufferSize(5),
+		)
+
+		var handled atomic.Int32
+		_, err := bus.Subscribe(s.T().Context(), messaging.MessageHandlerFn[messaging.Message](func(context.Context, messaging.Message) error {
+			time.Sleep(time.Millisecond)
+			handled.Add(1)
+			return nil
+		}))
+		s.Require().NoError(err)
+
+		for range 5 {
+			s.Require().NoError(bus.Publish(s.T().Context(), messaging.NewMessage(subject)))
+		}
+
+		ctx, cancel := context.WithTimeout(s.T().Context(), 5*time.Second)
+		defer cancel()
+		s.Require().NoError(bus.Shutdown(ctx))
+		s.Equal(int32(5), handled.Load())
+
+		err = bus.Publish(s.T().Context(), messaging.NewMessage(subject))
+		s.Require().ErrorIs(err, messaging.ErrPublishOnClosedBus)
+		s.Require().NoError(bus.Shutdown(ctx))
+	})
+
+	s.Run("should report the undelivered messages when the deadline is exceeded", func() {
+		bus := messaging.NewInMemoryMessageBus(
+			messaging.ConfigureInMemoryMessageBusSubjects(subject),
+			messaging.ConfigureInMemoryMessageBusAsyncWorkers(1),
+			messaging.ConfigureInMemoryMessageBusQueueBufferSize(5),
+		)
+
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		defer close(release)
+		_, err := bus.Subscribe(s.T().Context(), messaging.MessageHandlerFn[messaging.Message](func(context.Context, messaging.Message) error {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			<-release
+			return nil
+		}))
+		s.Require().NoError(err)
+
+		s.Require().NoError(bus.Publish(s.T().Context(), messaging.NewMessage(subject, messaging.WithID("msg-1"))))
+		<-started
+		s.Require().NoError(bus.Publish(s.T().Context(),
+			messaging.NewMessage(subject, messaging.WithID("msg-2")),
+			messaging.NewMessage(subject, messaging.WithID("msg-3")),
+		))
+
+		ctx, cancel := context.WithTimeout(s.T().Context(), 20*time.Millisecond)
+		defer cancel()
+		err = bus.Shutdown(ctx)
+		s.Require().ErrorIs(err, messaging.ErrMessagesUndelivered)
+		s.Require().ErrorIs(err, context.DeadlineExceeded)
+
+		var undeliveredErr messaging.UndeliveredMessagesError
+		s.Require().ErrorAs(err, &undeliveredErr)
+		s.Equal(2, undeliveredErr.Pending)
+		s.Require().Len(undeliveredErr.Messages, 2)
+		s.Equal("msg-2", undeliveredErr.Messages[0].MessageID())
+		s.Equal("msg-3", undeliveredErr.Messages[1].MessageID())
+	})
+
+	s.Run("should reject the publishes enqueuing after the shutdown is aborted", func() {
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		bus := messaging.NewInMemoryMessageBus(
+			messaging.ConfigureInMemoryMessageBusSubjects(subject),
+			messaging.ConfigureInMemoryMessageBusAsyncWorkers(2),
+			messaging.ConfigureInMemoryMessageBusPartitioning(func(messaging.Message) string {
+				close(entered)
+				<-release
+				return ""
+			}),
+		)
+
+		_, err := bus.Subscribe(s.T().Context(), messaging.MessageHandlerFn[messaging.Message](func(context.Context, messaging.Message) error {
+			return nil
+		}))
+		s.Require().NoError(err)
+
+		publishErr := make(chan error, 1)
+		go func() { publishErr <- bus.Publish(s.T().Context(), messaging.NewMessage(subject)) }()
+		<-entered
+
+		ctx, cancel := context.WithTimeout(s.T().Context(), 20*time.Millisecond)
+		defer cancel()
+		s.Require().ErrorIs(bus.Shutdown(ctx), messaging.ErrMessagesUndelivered)
+
+		close(release)
+		s.Require().ErrorIs(<-publishErr, messaging.ErrPublishOnClosedBus)
+	})
+
+	s.Run("should not deadlock with handlers publishing while the bus closes", func() {
+		const otherSubject = "test.message.type.shutdown.other"
+		bus := messaging.NewInMemoryMessageBus(
+			messaging.ConfigureInMemoryMessageBusSubjects(subject, otherSubject),
+			messaging.ConfigureInMemoryMessageBusAsyncWorkers(1),
+		)
+
+		started := make(chan struct{})
+		closing := make(chan struct{})
+		publishErr := make(chan error, 1)
+		_, err := bus.Subscribe(s.T().Context(), messaging.MessageHandlerFn[messaging.Message](func(ctx context.Context, m messaging.Message) error {
+			if m.MessageType() != subject {
+				return nil
+			}
+			close(started)
+			<-closing
+			publishErr <- bus.Publish(ctx, messaging.NewMessage(otherSubject))
+			return nil
+		}))
+		s.Require().NoError(err)
+
+		s.Require().NoError(bus.Publish(s.T().Context(), messaging.NewMessage(subject)))
+		<-started
+
+		closed := make(chan error, 1)
+		go func() { closed <- bus.Close() }()
+		time.Sleep(10 * time.Millisecond)
+		close(closing)
+
+		select {
+		case err := <-closed:
+			s.Require().NoError(err)
+		case <-time.After(5 * time.Second):
+			s.T().Fatal("the bus did not close")
+		}
+		s.Require().ErrorIs(<-publishErr, messaging.ErrPublishOnClosedBus)
+	})
+
+	s.Run("should call the subscription lifecycle hooks", func() {
+		var events []string
+		hook := func(name string) func(messaging.Subscription) {
+			return func(sub messaging.Subscription) {
+				events = append(events, fmt.Sprintf("%s:%s:%d", name, sub.Subject, sub.ID))
+			}
+		}
+
+		bus := messaging.NewInMemoryMessageBus(
+			messaging.ConfigureInMemoryMessageBusSubjects(subject),
+			messaging.ConfigureInMemoryMessageBusSubscriptionHooks(messaging.SubscriptionHooks{
+				OnSubscribe:   hook("subscribe"),
+				OnUnsubscribe: hook("unsubscribe"),
+				OnShutdown:    hook("shutdown"),
+			}),
+		)
+
+		noop := messaging.MessageHandlerFn[messaging.Message](func(context.Context, messaging.Message) error { return nil })
+		unsub, err := bus.Subscribe(s.T().Context(), noop)
+		s.Require().NoError(err)
+		_, err = bus.Subscribe(s.T().Context(), noop)
+		s.Require().NoError(err)
+
+		s.Require().NoError(unsub())
+		s.Require().NoError(bus.Shutdown(s.T().Context()))
+
+		s.Equal([]string{
+			"subscribe:" + subject + ":1",
+			"subscribe:" + subject + ":2",
+			"unsubscribe:" + subject + ":1",
+			"shutdown:" + subject + ":2",
+		}, events)
+	})
+}
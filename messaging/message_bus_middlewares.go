@@ -3,6 +3,9 @@ package messaging
 import (
 	"context"
 	"time"
+
+	cqrserrors "github.com/xfrr/go-cqrsify/errors"
+	"github.com/xfrr/go-cqrsify/pkg/retry"
 )
 
 // MessageHandlerMiddleware is a middleware for message handlers.
@@ -37,6 +40,9 @@ func TimeoutMiddleware(d time.Duration) MessageHandlerMiddleware {
 }
 
 // RetryBackoffMiddleware retries the handler with exponential backoff on error.
+//
+// Deprecated: it retries every error and ignores the context cancellation while sleeping;
+// use RetryMiddleware instead.
 func RetryBackoffMiddleware(attempts int, initialDelay time.Duration) MessageHandlerMiddleware {
 	return func(next MessageHandler[Message]) MessageHandler[Message] {
 		return MessageHandlerFn[Message](func(ctx context.Context, msg Message) error {
@@ -54,3 +60,76 @@ func RetryBackoffMiddleware(attempts int, initialDelay time.Duration) MessageHan
 		})
 	}
 }
+
+// RetryMiddleware retries the handler with a retry.Retrier configured with the given options,
+// which provide the backoff strategy, jitter, classifier, stopper and hooks.
+// The options left unset default to retry.DefaultOptions, so the retries are bounded
+// unless MaxAttempts is negative.
+// Permanent errors (see errors.IsPermanent) are never retried and the context cancellation
+// interrupts the backoff sleeps.
+//
+// Each attempt is recorded in the Delivery carried by the handler context:
+// the attempt of the delivery received by the middleware is incremented on every retry.
+// Once the retries stop, the returned error wraps the last handler error; see retry.Attempts and retry.Cause.
+//
+// On buses redelivering the failed messages (see Delivery), the handler is called once and
+// its error returned as is, leaving the retries to the transport. To dead-letter the messages
+// whose retries are exhausted, wrap the retrying handler with DeadLetterMiddleware, which then
+// counts the deliveries of the transport, or the in-place retry loops on the other buses:
+//
+//	messaging.DeadLetterMiddleware(sink, messaging.ConfigureDeadLetterMaxDeliveries(1))(messaging.RetryMiddleware(opts)(handler))
+func RetryMiddleware(opts retry.Options) MessageHandlerMiddleware {
+	opts = retryOptionsWithDefaults(opts)
+	classifier := opts.Classifier
+	opts.Classifier = retry.RetryOn{Predicate: func(err error) bool {
+		return !cqrserrors.IsPermanent(err) && classifier.Retryable(err)
+	}}
+	retrier := retry.New(opts)
+
+	return func(next MessageHandler[Message]) MessageHandler[Message] {
+		return MessageHandlerFn[Message](func(ctx context.Context, msg Message) error {
+			delivery, ok := DeliveryFromContext(ctx)
+			if !ok {
+				delivery = Delivery{Subject: msg.MessageType(), Attempt: 1}
+			}
+			if delivery.Redelivered {
+				return next.Handle(ctx, msg)
+			}
+
+			attempt := delivery
+			return retrier.Do(ctx, func(ctx context.Context) error {
+				err := next.Handle(ContextWithDelivery(ctx, attempt), msg)
+				attempt.Attempt++
+				return err
+			})
+		})
+	}
+}
+
+// retryOptionsWithDefaults overlays the fields set in the given options on retry.DefaultOptions.
+func retryOptionsWithDefaults(opts retry.Options) retry.Options {
+	defaults := retry.DefaultOptions()
+	if opts.Strategy != nil {
+		defaults.Strategy = opts.Strategy
+	}
+	if opts.Jitter != nil {
+		defaults.Jitter = opts.Jitter
+	}
+	if opts.Classifier != nil {
+		defaults.Classifier = opts.Classifier
+	}
+	if opts.Stopper != nil {
+		defaults.Stopper = opts.Stopper
+	}
+	if opts.MaxAttempts != 0 {
+		defaults.MaxAttempts = opts.MaxAttempts
+	}
+	if opts.MaxElapsed != 0 {
+		defaults.MaxElapsed = opts.MaxElapsed
+	}
+	if opts.Sleeper != nil {
+		defaults.Sleeper = opts.Sleeper
+	}
+	defaults.Hooks = opts.Hooks
+	return defaults
+}
@@ -0,0 +1,133 @@
+package messaging_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cqrserrors "github.com/xfrr/go-cqrsify/errors"
+	"github.com/xfrr/go-cqrsify/messaging"
+	"github.com/xfrr/go-cqrsify/pkg/retry"
+)
+
+func TestRetryMiddleware(t *testing.T) {
+	ctx := context.Background()
+	errUnavailable := errors.New("inventory unavailable")
+	reserve := messaging.NewBaseCommand("inventory.reserve")
+
+	t.Run("should retry the handler recording the attempts in the delivery", func(t *testing.T) {
+		var attempts []int
+		var retries int
+		sut := messaging.RetryMiddleware(retry.Options{
+			Strategy:    retry.ConstantStrategy{Delay: time.Millisecond},
+			MaxAttempts: 5,
+			Hooks: retry.Hooks{
+				OnRetry: func(int, error, time.Duration) { retries++ },
+			},
+		})(messaging.MessageHandlerFn[messaging.Message](func(ctx context.Context, _ messaging.Message) error {
+			delivery, ok := messaging.DeliveryFromContext(ctx)
+			require.True(t, ok)
+			attempts = append(attempts, delivery.Attempt)
+			if delivery.Attempt < 3 {
+				return errUnavailable
+			}
+			return nil
+		}))
+
+		require.NoError(t, sut.Handle(ctx, reserve))
+		assert.Equal(t, []int{1, 2, 3}, attempts)
+		assert.Equal(t, 2, retries)
+	})
+
+	t.Run("should give up once the attempts are exhausted", func(t *testing.T) {
+		calls := 0
+		sut := messaging.RetryMiddleware(retry.Options{
+			Strategy:    retry.ConstantStrategy{},
+			MaxAttempts: 3,
+		})(messaging.MessageHandlerFn[messaging.Message](func(context.Context, messaging.Message) error {
+			calls++
+			return errUnavailable
+		}))
+
+		err := sut.Handle(ctx, reserve)
+		require.ErrorIs(t, err, errUnavailable)
+		assert.ErrorIs(t, retry.Cause(err), retry.ErrGiveUp)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, 3, retry.Attempts(err))
+	})
+
+	t.Run("should not retry permanent errors", func(t *testing.T) {
+		calls := 0
+		sut := messaging.RetryMiddleware(retry.Options{
+			Strategy:    retry.ConstantStrategy{},
+			MaxAttempts: 3,
+		})(messaging.MessageHandlerFn[messaging.Message](func(context.Context, messaging.Message) error {
+			calls++
+			return cqrserrors.NewPermanentError(errUnavailable)
+		}))
+
+		err := sut.Handle(ctx, reserve)
+		require.Error(t, err)
+		assert.True(t, cqrserrors.IsPermanent(err))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("should bound the retries when the max attempts are not set", func(t *testing.T) {
+		var calls int
+		sut := messaging.RetryMiddleware(retry.Options{
+			Strategy: retry.ConstantStrategy{},
+		})(messaging.MessageHandlerFn[messaging.Message](func(context.Context, messaging.Message) error {
+			calls++
+			return errUnavailable
+		}))
+
+		err := sut.Handle(ctx, reserve)
+		require.ErrorIs(t, err, errUnavailable)
+		assert.Equal(t, retry.DefaultOptions().MaxAttempts, calls)
+	})
+
+	t.Run("should leave the retries to the transport redelivering the messages", func(t *testing.T) {
+		var calls int
+		sut := messaging.RetryMiddleware(retry.Options{
+			Strategy:    retry.ConstantStrategy{},
+			MaxAttempts: 3,
+		})(messaging.MessageHandlerFn[messaging.Message](func(context.Context, messaging.Message) error {
+			calls++
+			return errUnavailable
+		}))
+
+		err := sut.Handle(messaging.ContextWithDelivery(ctx, messaging.Delivery{
+			Subject:     "inventory.reserve",
+			Attempt:     2,
+			Redelivered: true,
+		}), reserve)
+		require.ErrorIs(t, err, errUnavailable)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("should stop sleeping when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		sut := messaging.RetryMiddleware(retry.Options{
+			Strategy: retry.ConstantStrategy{Delay: time.Hour},
+		})(messaging.MessageHandlerFn[messaging.Message](func(context.Context, messaging.Message) error {
+			cancel()
+			return errUnavailable
+		}))
+
+		done := make(chan error, 1)
+		go func() { done <- sut.Handle(ctx, reserve) }()
+
+		select {
+		case err := <-done:
+			require.ErrorIs(t, err, errUnavailable)
+			assert.ErrorIs(t, retry.Cause(err), context.Canceled)
+		case <-time.After(time.Second):
+			t.Fatal("the middleware did not honour the context cancellation")
+		}
+	})
+}
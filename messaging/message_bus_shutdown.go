@@ -0,0 +1,72 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrMessagesUndelivered is the sentinel error matched by UndeliveredMessagesError.
+var ErrMessagesUndelivered = errors.New("messages undelivered on shutdown")
+
+// Shutdowner is implemented by the buses that can be shut down gracefully.
+type Shutdowner interface {
+	// Shutdown stops accepting new messages and waits until the messages already accepted
+	// are handled, or until the context is done. In that case, it returns an UndeliveredMessagesError.
+	Shutdown(ctx context.Context) error
+}
+
+// UndeliveredMessagesError is returned by Shutdown when the context is done before the bus is drained.
+type UndeliveredMessagesError struct {
+	// Messages are the accepted messages that were not handed to their handler, if known.
+	// A message appears once per handler it was not delivered to.
+	Messages []Message
+	// Pending is the number of undelivered messages, which may be known even if the messages are not.
+	Pending int
+	// Err is the context error.
+	Err error
+}
+
+func (e UndeliveredMessagesError) Error() string {
+	return fmt.Sprintf("%d messages undelivered on shutdown: %v", e.Pending, e.Err)
+}
+
+// Is reports whether the target is ErrMessagesUndelivered.
+func (e UndeliveredMessagesError) Is(target error) bool {
+	return target == ErrMessagesUndelivered
+}
+
+func (e UndeliveredMessagesError) Unwrap() error { return e.Err }
+
+// Subscription identifies a handler subscribed to a bus subject.
+type Subscription struct {
+	ID      uint64
+	Subject string
+}
+
+// SubscriptionHooks are the subscription lifecycle callbacks of a bus.
+type SubscriptionHooks struct {
+	// OnSubscribe is called when a handler is subscribed to a subject.
+	OnSubscribe func(sub Subscription)
+	// OnUnsubscribe is called when a handler is unsubscribed from a subject.
+	OnUnsubscribe func(sub Subscription)
+	// OnShutdown is called for each subscription still active once the bus is shut down.
+	OnShutdown func(sub Subscription)
+}
+
+// waitContext waits for the wait group until the context is done.
+func waitContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,240 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	cqrserrors "github.com/xfrr/go-cqrsify/errors"
+	"github.com/xfrr/go-cqrsify/pkg/retry"
+)
+
+const defaultDeadLetterMaxDeliveries = 3
+
+// DeadLetter is a message whose handler kept failing, with its failure metadata.
+type DeadLetter struct {
+	// Message is the original message.
+	Message Message
+	// Error is the error returned by the handler on the last delivery.
+	Error string
+	// Attempts is the number of times the message was delivered to the handler.
+	Attempts int
+	// Handler is the name of the failing handler.
+	Handler string
+	// Subject is the subject the message was delivered on.
+	Subject string
+	// FailedAt is the time of the last failure.
+	FailedAt time.Time
+}
+
+// ID identifies the dead letter by the ID of its message and its handler,
+// as a message may be dead-lettered by several handlers.
+func (l DeadLetter) ID() string {
+	return l.Message.MessageID() + "/" + l.Handler
+}
+
+// DeadLetterSink receives the messages whose handler exhausted its deliveries.
+type DeadLetterSink interface {
+	// Send forwards the given dead letter to the sink.
+	Send(ctx context.Context, letter DeadLetter) error
+}
+
+// DeadLetterSinkFunc is a function that implements the DeadLetterSink interface.
+type DeadLetterSinkFunc func(ctx context.Context, letter DeadLetter) error
+
+// Send calls the DeadLetterSinkFunc with the given dead letter.
+func (f DeadLetterSinkFunc) Send(ctx context.Context, letter DeadLetter) error {
+	return f(ctx, letter)
+}
+
+// DeadLetterStore is a DeadLetterSink keeping the dead letters so that they can be inspected and replayed.
+type DeadLetterStore interface {
+	DeadLetterSink
+	// DeadLetters returns the stored dead letters, oldest first.
+	DeadLetters(ctx context.Context) ([]DeadLetter, error)
+	// Remove removes the dead letters with the given IDs, see DeadLetter.ID.
+	Remove(ctx context.Context, letterIDs ...string) error
+}
+
+// DeadLetterConfig configures the dead-lettering of a subscription.
+type DeadLetterConfig struct {
+	// MaxDeliveries is the number of deliveries after which a failing message is dead-lettered.
+	// Defaults to 3.
+	MaxDeliveries int
+	// Handler is the name of the handler reported in the dead letters.
+	// Defaults to the type of the handler.
+	Handler string
+	// Backoff computes the delay between the in-place retries.
+	// Defaults to the strategy of retry.DefaultOptions.
+	Backoff retry.Strategy
+}
+
+// DeadLetterConfiger is the functional option pattern.
+type DeadLetterConfiger func(*DeadLetterConfig)
+
+// ConfigureDeadLetterMaxDeliveries sets the number of deliveries after which a failing message is dead-lettered.
+func ConfigureDeadLetterMaxDeliveries(n int) DeadLetterConfiger {
+	return func(c *DeadLetterConfig) { c.MaxDeliveries = n }
+}
+
+// ConfigureDeadLetterHandler sets the name of the handler reported in the dead letters.
+func ConfigureDeadLetterHandler(name string) DeadLetterConfiger {
+	return func(c *DeadLetterConfig) { c.Handler = name }
+}
+
+// ConfigureDeadLetterBackoff sets the strategy computing the delay between the in-place retries.
+func ConfigureDeadLetterBackoff(strategy retry.Strategy) DeadLetterConfiger {
+	return func(c *DeadLetterConfig) { c.Backoff = strategy }
+}
+
+func newDeadLetterConfig(opts []DeadLetterConfiger) DeadLetterConfig {
+	cfg := DeadLetterConfig{MaxDeliveries: defaultDeadLetterMaxDeliveries}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.MaxDeliveries <= 0 {
+		cfg.MaxDeliveries = defaultDeadLetterMaxDeliveries
+	}
+	if cfg.Backoff == nil {
+		cfg.Backoff = retry.DefaultOptions().Strategy
+	}
+	return cfg
+}
+
+// DeadLetterMiddleware forwards the messages whose handler keeps failing to the given sink.
+// Apply it to a single handler to configure the dead-lettering per subscription:
+//
+//	bus.Subscribe(ctx, messaging.DeadLetterMiddleware(sink, messaging.ConfigureDeadLetterMaxDeliveries(5))(handler))
+//
+// On buses redelivering the failed messages (see Delivery), the handler error is returned
+// until the delivery attempt reaches MaxDeliveries. Otherwise the handler is retried in place
+// up to MaxDeliveries times, waiting the Backoff delay between the attempts; the message is
+// dead-lettered if the context is done while waiting. Permanent errors (see errors.IsPermanent) are dead-lettered at once.
+//
+// Once forwarded to the sink, the message is reported as handled; if the sink fails,
+// both the handler and the sink errors are returned.
+// Combined with RetryMiddleware, apply it outside the retries; see RetryMiddleware.
+func DeadLetterMiddleware(sink DeadLetterSink, opts ...DeadLetterConfiger) MessageHandlerMiddleware {
+	cfg := newDeadLetterConfig(opts)
+	return func(next MessageHandler[Message]) MessageHandler[Message] {
+		handlerName := cfg.Handler
+		if handlerName == "" {
+			handlerName = fmt.Sprintf("%T", next)
+		}
+
+		return MessageHandlerFn[Message](func(ctx context.Context, msg Message) error {
+			delivery, ok := DeliveryFromContext(ctx)
+			if !ok {
+				delivery = Delivery{Subject: msg.MessageType(), Attempt: 1}
+			}
+
+			for {
+				err := next.Handle(ctx, msg)
+				if err == nil {
+					return nil
+				}
+
+				exhausted := delivery.Attempt >= cfg.MaxDeliveries || cqrserrors.IsPermanent(err)
+				if !exhausted && delivery.Redelivered {
+					return err
+				}
+				if !exhausted && ctx.Err() == nil {
+					delay := cfg.Backoff.NextDelay(delivery.Attempt-1, err)
+					if sleepErr := (retry.RealSleeper{}).Sleep(ctx, delay); sleepErr == nil {
+						delivery.Attempt++
+						continue
+					}
+				}
+
+				letter := DeadLetter{
+					Message:  msg,
+					Error:    err.Error(),
+					Attempts: delivery.Attempt,
+					Handler:  handlerName,
+					Subject:  delivery.Subject,
+					FailedAt: time.Now(),
+				}
+				if sinkErr := sink.Send(ctx, letter); sinkErr != nil {
+					return errors.Join(err, fmt.Errorf("could not send dead letter: %w", sinkErr))
+				}
+				return nil
+			}
+		})
+	}
+}
+
+// ReplayDeadLetters re-publishes the dead letters of the store accepted by the filter,
+// or all of them if the filter is nil, and removes the replayed ones from the store.
+// A message dead-lettered by several handlers is published once.
+// It returns the number of replayed messages.
+//
+// Every subscriber of a replayed message receives it again, including the handlers that
+// already handled it, so they must be idempotent, e.g. see InboxMiddleware.
+// Use ReplayDeadLettersTo to deliver the messages to their failing handler only.
+func ReplayDeadLetters(
+	ctx context.Context,
+	store DeadLetterStore,
+	publisher MessagePublisher,
+	filter func(DeadLetter) bool,
+) (int, error) {
+	letters, err := store.DeadLetters(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not retrieve dead letters: %w", err)
+	}
+
+	replayed := 0
+	published := make(map[string]bool)
+	for _, letter := range letters {
+		if filter != nil && !filter(letter) {
+			continue
+		}
+
+		messageID := letter.Message.MessageID()
+		if !published[messageID] {
+			if err := publisher.Publish(ctx, letter.Message); err != nil {
+				return replayed, fmt.Errorf("could not replay message %s: %w", messageID, err)
+			}
+			published[messageID] = true
+			replayed++
+		}
+		if err := store.Remove(ctx, letter.ID()); err != nil {
+			return replayed, fmt.Errorf("could not remove dead letter %s: %w", letter.ID(), err)
+		}
+	}
+	return replayed, nil
+}
+
+// ReplayDeadLettersTo delivers the dead letters of the store accepted by the filter,
+// or all of them if the filter is nil, to the failing handler only, looked up by
+// DeadLetter.Handler in the given handlers, and removes the replayed ones from the store.
+// The dead letters of unknown handlers are kept. It returns the number of replayed messages.
+func ReplayDeadLettersTo(
+	ctx context.Context,
+	store DeadLetterStore,
+	handlers map[string]MessageHandler[Message],
+	filter func(DeadLetter) bool,
+) (int, error) {
+	letters, err := store.DeadLetters(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not retrieve dead letters: %w", err)
+	}
+
+	replayed := 0
+	for _, letter := range letters {
+		handler, ok := handlers[letter.Handler]
+		if !ok || (filter != nil && !filter(letter)) {
+			continue
+		}
+
+		deliveryCtx := ContextWithDelivery(ctx, Delivery{Subject: letter.Subject, Attempt: 1})
+		if err := handler.Handle(deliveryCtx, letter.Message); err != nil {
+			return replayed, fmt.Errorf("could not replay message %s: %w", letter.Message.MessageID(), err)
+		}
+		if err := store.Remove(ctx, letter.ID()); err != nil {
+			return replayed, fmt.Errorf("could not remove dead letter %s: %w", letter.ID(), err)
+		}
+		replayed++
+	}
+	return replayed, nil
+}
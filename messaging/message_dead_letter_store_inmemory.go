@@ -0,0 +1,45 @@
+package messaging
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+var _ DeadLetterStore = (*InMemoryDeadLetterStore)(nil)
+
+// InMemoryDeadLetterStore is a process-local DeadLetterStore.
+type InMemoryDeadLetterStore struct {
+	mu      sync.Mutex
+	letters []DeadLetter
+}
+
+// NewInMemoryDeadLetterStore creates a new, empty InMemoryDeadLetterStore.
+func NewInMemoryDeadLetterStore() *InMemoryDeadLetterStore {
+	return &InMemoryDeadLetterStore{}
+}
+
+func (s *InMemoryDeadLetterStore) Send(_ context.Context, letter DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.letters = append(s.letters, letter)
+	return nil
+}
+
+func (s *InMemoryDeadLetterStore) DeadLetters(_ context.Context) ([]DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.letters), nil
+}
+
+func (s *InMemoryDeadLetterStore) Remove(_ context.Context, letterIDs ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.letters = slices.DeleteFunc(s.letters, func(letter DeadLetter) bool {
+		return slices.Contains(letterIDs, letter.ID())
+	})
+	return nil
+}
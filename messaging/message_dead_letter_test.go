@@ -0,0 +1,250 @@
+package messaging_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cqrserrors "github.com/xfrr/go-cqrsify/errors"
+	"github.com/xfrr/go-cqrsify/messaging"
+	messagingmock "github.com/xfrr/go-cqrsify/messaging/mock"
+	"github.com/xfrr/go-cqrsify/pkg/retry"
+)
+
+func TestDeadLetterMiddleware(t *testing.T) {
+	ctx := context.Background()
+	errCharge := errors.New("card declined")
+	charge := messaging.NewBaseCommand("payment.charge", messaging.WithID("msg-1"))
+
+	t.Run("should retry in place and dead-letter the message with its failure metadata", func(t *testing.T) {
+		var calls atomic.Int32
+		store := messaging.NewInMemoryDeadLetterStore()
+		sut := messaging.DeadLetterMiddleware(store,
+			messaging.ConfigureDeadLetterMaxDeliveries(3),
+			messaging.ConfigureDeadLetterHandler("charge-handler"),
+		)(countingHandler(&calls, errCharge))
+
+		require.NoError(t, sut.Handle(ctx, charge))
+		assert.Equal(t, int32(3), calls.Load())
+
+		letters, err := store.DeadLetters(ctx)
+		require.NoError(t, err)
+		require.Len(t, letters, 1)
+		assert.Equal(t, "msg-1", letters[0].Message.MessageID())
+		assert.Equal(t, errCharge.Error(), letters[0].Error)
+		assert.Equal(t, 3, letters[0].Attempts)
+		assert.Equal(t, "charge-handler", letters[0].Handler)
+		assert.Equal(t, "payment.charge", letters[0].Subject)
+		assert.False(t, letters[0].FailedAt.IsZero())
+	})
+
+	t.Run("should return the error until the redelivered message exhausts its deliveries", func(t *testing.T) {
+		var calls atomic.Int32
+		store := messaging.NewInMemoryDeadLetterStore()
+		sut := messaging.DeadLetterMiddleware(store, messaging.ConfigureDeadLetterMaxDeliveries(2))(countingHandler(&calls, errCharge))
+
+		deliver := func(attempt int) error {
+			return sut.Handle(messaging.ContextWithDelivery(ctx, messaging.Delivery{
+				Subject:     "payments.charge",
+				Attempt:     attempt,
+				Redelivered: true,
+			}), charge)
+		}
+
+		require.ErrorIs(t, deliver(1), errCharge)
+		require.NoError(t, deliver(2))
+		assert.Equal(t, int32(2), calls.Load())
+
+		letters, err := store.DeadLetters(ctx)
+		require.NoError(t, err)
+		require.Len(t, letters, 1)
+		assert.Equal(t, 2, letters[0].Attempts)
+		assert.Equal(t, "payments.charge", letters[0].Subject)
+	})
+
+	t.Run("should dead-letter the message when the context is done while waiting to retry", func(t *testing.T) {
+		var calls atomic.Int32
+		store := messaging.NewInMemoryDeadLetterStore()
+		sut := messaging.DeadLetterMiddleware(store,
+			messaging.ConfigureDeadLetterMaxDeliveries(3),
+			messaging.ConfigureDeadLetterBackoff(retry.ConstantStrategy{Delay: time.Hour}),
+		)(countingHandler(&calls, errCharge))
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+
+		require.NoError(t, sut.Handle(timeoutCtx, charge))
+		assert.Equal(t, int32(1), calls.Load())
+
+		letters, err := store.DeadLetters(ctx)
+		require.NoError(t, err)
+		require.Len(t, letters, 1)
+		assert.Equal(t, 1, letters[0].Attempts)
+	})
+
+	t.Run("should dead-letter permanent errors at once", func(t *testing.T) {
+		var calls atomic.Int32
+		store := messaging.NewInMemoryDeadLetterStore()
+		sut := messaging.DeadLetterMiddleware(store)(countingHandler(&calls, cqrserrors.NewPermanentError(errCharge)))
+
+		require.NoError(t, sut.Handle(ctx, charge))
+		assert.Equal(t, int32(1), calls.Load())
+
+		letters, err := store.DeadLetters(ctx)
+		require.NoError(t, err)
+		assert.Len(t, letters, 1)
+	})
+
+	t.Run("should return the errors when the sink fails", func(t *testing.T) {
+		var calls atomic.Int32
+		errSink := errors.New("sink unavailable")
+		sink := messaging.DeadLetterSinkFunc(func(context.Context, messaging.DeadLetter) error { return errSink })
+
+		err := messaging.DeadLetterMiddleware(sink, messaging.ConfigureDeadLetterMaxDeliveries(1))(countingHandler(&calls, errCharge)).Handle(ctx, charge)
+		require.ErrorIs(t, err, errCharge)
+		require.ErrorIs(t, err, errSink)
+	})
+}
+
+func TestInMemoryMessageBus_DeadLetters(t *testing.T) {
+	ctx := context.Background()
+	const subject = "payment.charge"
+
+	store := messaging.NewInMemoryDeadLetterStore()
+	bus := messaging.NewInMemoryMessageBus(
+		messaging.ConfigureInMemoryMessageBusSubjects(subject),
+		messaging.ConfigureInMemoryMessageBusDeadLetterSink(store, messaging.ConfigureDeadLetterMaxDeliveries(2)),
+	)
+
+	var calls atomic.Int32
+	var failing atomic.Bool
+	failing.Store(true)
+	_, err := bus.Subscribe(ctx, messaging.MessageHandlerFn[messaging.Message](func(context.Context, messaging.Message) error {
+		calls.Add(1)
+		if failing.Load() {
+			return errors.New("card declined")
+		}
+		return nil
+	}))
+	require.NoError(t, err)
+
+	require.NoError(t, bus.Publish(ctx, messaging.NewBaseCommand(subject, messaging.WithID("msg-1"))))
+	assert.Equal(t, int32(2), calls.Load())
+
+	letters, err := store.DeadLetters(ctx)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.Equal(t, subject, letters[0].Subject)
+
+	t.Run("should replay the dead-lettered messages", func(t *testing.T) {
+		failing.Store(false)
+
+		replayed, err := messaging.ReplayDeadLetters(ctx, store, bus, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, replayed)
+		assert.Equal(t, int32(3), calls.Load())
+
+		letters, err := store.DeadLetters(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, letters)
+	})
+
+	t.Run("should only replay the dead letters accepted by the filter", func(t *testing.T) {
+		require.NoError(t, store.Send(ctx, messaging.DeadLetter{Message: messaging.NewBaseCommand(subject, messaging.WithID("msg-2"))}))
+		require.NoError(t, store.Send(ctx, messaging.DeadLetter{Message: messaging.NewBaseCommand(subject, messaging.WithID("msg-3"))}))
+
+		replayed, err := messaging.ReplayDeadLetters(ctx, store, bus, func(letter messaging.DeadLetter) bool {
+			return letter.Message.MessageID() == "msg-3"
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, replayed)
+
+		letters, err := store.DeadLetters(ctx)
+		require.NoError(t, err)
+		require.Len(t, letters, 1)
+		assert.Equal(t, "msg-2", letters[0].Message.MessageID())
+	})
+}
+
+func TestReplayDeadLettersTo(t *testing.T) {
+	ctx := context.Background()
+
+	store := messaging.NewInMemoryDeadLetterStore()
+	require.NoError(t, store.Send(ctx, messaging.DeadLetter{
+		Message: messaging.NewBaseCommand("payment.charge", messaging.WithID("msg-1")),
+		Handler: "charge-handler",
+		Subject: "payments.charge",
+	}))
+	require.NoError(t, store.Send(ctx, messaging.DeadLetter{
+		Message: messaging.NewBaseCommand("payment.charge", messaging.WithID("msg-2")),
+		Handler: "unknown-handler",
+	}))
+
+	var delivered []messaging.Delivery
+	handlers := map[string]messaging.MessageHandler[messaging.Message]{
+		"charge-handler": messaging.MessageHandlerFn[messaging.Message](func(ctx context.Context, _ messaging.Message) error {
+			delivery, _ := messaging.DeliveryFromContext(ctx)
+			delivered = append(delivered, delivery)
+			return nil
+		}),
+	}
+
+	replayed, err := messaging.ReplayDeadLettersTo(ctx, store, handlers, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	assert.Equal(t, []messaging.Delivery{{Subject: "payments.charge", Attempt: 1}}, delivered)
+
+	letters, err := store.DeadLetters(ctx)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.Equal(t, "msg-2", letters[0].Message.MessageID())
+}
+
+func TestReplayDeadLetters_SeveralHandlers(t *testing.T) {
+	ctx := context.Background()
+	charge := messaging.NewBaseCommand("payment.charge", messaging.WithID("msg-1"))
+
+	newStore := func(t *testing.T) *messaging.InMemoryDeadLetterStore {
+		store := messaging.NewInMemoryDeadLetterStore()
+		require.NoError(t, store.Send(ctx, messaging.DeadLetter{Message: charge, Handler: "charge-handler"}))
+		require.NoError(t, store.Send(ctx, messaging.DeadLetter{Message: charge, Handler: "audit-handler"}))
+		return store
+	}
+
+	t.Run("should publish the message once and remove all its dead letters", func(t *testing.T) {
+		store := newStore(t)
+		publisher := &messagingmock.MessagePublisher{
+			PublishFunc: func(context.Context, ...messaging.Message) error { return nil },
+		}
+
+		replayed, err := messaging.ReplayDeadLetters(ctx, store, publisher, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, replayed)
+		assert.Len(t, publisher.PublishCalls(), 1)
+
+		letters, err := store.DeadLetters(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, letters)
+	})
+
+	t.Run("should keep the dead letters of the other handlers when replaying to one", func(t *testing.T) {
+		store := newStore(t)
+		handlers := map[string]messaging.MessageHandler[messaging.Message]{
+			"charge-handler": messaging.MessageHandlerFn[messaging.Message](func(context.Context, messaging.Message) error { return nil }),
+		}
+
+		replayed, err := messaging.ReplayDeadLettersTo(ctx, store, handlers, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, replayed)
+
+		letters, err := store.DeadLetters(ctx)
+		require.NoError(t, err)
+		require.Len(t, letters, 1)
+		assert.Equal(t, "audit-handler", letters[0].Handler)
+		assert.Equal(t, "msg-1/audit-handler", letters[0].ID())
+	})
+}
@@ -0,0 +1,28 @@
+package messaging
+
+import "context"
+
+// Delivery describes the delivery of a message to a handler.
+type Delivery struct {
+	// Subject is the subject the message was delivered on.
+	Subject string
+	// Attempt is the delivery attempt, starting at 1.
+	Attempt int
+	// Redelivered reports whether the bus redelivers the messages whose handler fails,
+	// as JetStream does. Otherwise the failed messages are only retried by the middlewares.
+	Redelivered bool
+}
+
+type contextKeyDelivery struct{}
+
+// ContextWithDelivery returns a copy of ctx carrying the given delivery.
+// The buses call it before handing a message to its handler.
+func ContextWithDelivery(ctx context.Context, delivery Delivery) context.Context {
+	return context.WithValue(ctx, contextKeyDelivery{}, delivery)
+}
+
+// DeliveryFromContext returns the delivery of the message being handled, if any.
+func DeliveryFromContext(ctx context.Context) (Delivery, bool) {
+	delivery, ok := ctx.Value(contextKeyDelivery{}).(Delivery)
+	return delivery, ok
+}
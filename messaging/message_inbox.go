@@ -0,0 +1,181 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cqrserrors "github.com/xfrr/go-cqrsify/errors"
+	"github.com/xfrr/go-cqrsify/uow"
+)
+
+const defaultInboxLeaseTTL = 30 * time.Second
+
+// InboxStatus is the status of a message ID reported by InboxStore.Begin.
+type InboxStatus int
+
+const (
+	// InboxReserved reports that the message ID has been reserved and the message must be processed.
+	InboxReserved InboxStatus = iota + 1
+	// InboxProcessed reports that the message has already been processed: it is a duplicate.
+	InboxProcessed
+	// InboxInFlight reports that the message ID is reserved by another attempt whose lease has not expired.
+	InboxInFlight
+)
+
+// InboxStore records the IDs of the processed messages.
+//
+// It follows the retry.DedupeStore contract, except that Begin tells the processed
+// messages apart from the ones still being processed:
+//
+// - Begin reserves the message ID for the given lease TTL and reports its InboxStatus.
+//
+// - Commit marks the message as processed. Unlike a plain DedupeStore, an inbox must keep
+// the processed IDs for its retention period so that redeliveries are skipped.
+//
+// - Rollback releases the reservation after a failed processing, so the message can be redelivered.
+type InboxStore interface {
+	Begin(ctx context.Context, key string, ttl time.Duration) (InboxStatus, error)
+	Commit(ctx context.Context, key string) error
+	Rollback(ctx context.Context, key string) error
+}
+
+// InboxConfig configures the inbox middlewares.
+type InboxConfig struct {
+	// LeaseTTL is the time a message ID stays reserved while being processed,
+	// so concurrent redeliveries are skipped. Defaults to 30s.
+	LeaseTTL time.Duration
+	// KeyFunc returns the deduplication key of a message. Defaults to MessageID.
+	// Messages with an empty key are always processed.
+	KeyFunc func(Message) string
+	// OnDuplicate is called when an already processed message is skipped.
+	OnDuplicate func(context.Context, Message)
+}
+
+// InboxConfiger is the functional option pattern.
+type InboxConfiger func(*InboxConfig)
+
+// ConfigureInboxLeaseTTL sets the lease TTL of the messages being processed.
+func ConfigureInboxLeaseTTL(ttl time.Duration) InboxConfiger {
+	return func(c *InboxConfig) { c.LeaseTTL = ttl }
+}
+
+// ConfigureInboxKeyFunc sets the function computing the deduplication key of the messages.
+func ConfigureInboxKeyFunc(fn func(Message) string) InboxConfiger {
+	return func(c *InboxConfig) { c.KeyFunc = fn }
+}
+
+// ConfigureInboxOnDuplicate sets the hook called when an already processed message is skipped.
+func ConfigureInboxOnDuplicate(fn func(context.Context, Message)) InboxConfiger {
+	return func(c *InboxConfig) { c.OnDuplicate = fn }
+}
+
+func newInboxConfig(opts []InboxConfiger) InboxConfig {
+	cfg := InboxConfig{
+		LeaseTTL: defaultInboxLeaseTTL,
+		KeyFunc:  func(msg Message) string { return msg.MessageID() },
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.LeaseTTL <= 0 {
+		cfg.LeaseTTL = defaultInboxLeaseTTL
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = func(msg Message) string { return msg.MessageID() }
+	}
+	return cfg
+}
+
+// InboxMiddleware makes handlers idempotent by recording the processed messages in the
+// given InboxStore. Redelivered messages already processed are skipped. Messages being
+// processed by another attempt fail with a retryable ErrMessageInFlight error, so that
+// they are redelivered if that attempt fails.
+func InboxMiddleware(store InboxStore, opts ...InboxConfiger) MessageHandlerMiddleware {
+	cfg := newInboxConfig(opts)
+	return func(next MessageHandler[Message]) MessageHandler[Message] {
+		return MessageHandlerFn[Message](func(ctx context.Context, msg Message) error {
+			return handleInbox(ctx, store, cfg, msg, next.Handle)
+		})
+	}
+}
+
+// TransactionalInboxMiddleware is an InboxMiddleware that runs the handler and the inbox write
+// within the same unit of work transaction, so a message is recorded as processed
+// if and only if the handler changes are committed.
+//
+// newUnit is called for each message, as units of work are not safe for concurrent use.
+// inbox returns the InboxStore bound to the transaction repositories. The handler can
+// access the transaction repositories with UnitOfWorkRepos.
+func TransactionalInboxMiddleware[T any](
+	newUnit func() *uow.UnitOfWork[T],
+	inbox func(repos T) InboxStore,
+	opts ...InboxConfiger,
+) MessageHandlerMiddleware {
+	cfg := newInboxConfig(opts)
+	return func(next MessageHandler[Message]) MessageHandler[Message] {
+		return MessageHandlerFn[Message](func(ctx context.Context, msg Message) error {
+			return newUnit().Do(ctx, func(ctx context.Context, repos T) error {
+				ctx = context.WithValue(ctx, contextKeyUnitOfWorkRepos{}, repos)
+				return handleInbox(ctx, inbox(repos), cfg, msg, next.Handle)
+			})
+		})
+	}
+}
+
+type contextKeyUnitOfWorkRepos struct{}
+
+// UnitOfWorkRepos returns the unit of work repositories of the transaction
+// started by TransactionalInboxMiddleware.
+func UnitOfWorkRepos[T any](ctx context.Context) (T, bool) {
+	repos, ok := ctx.Value(contextKeyUnitOfWorkRepos{}).(T)
+	return repos, ok
+}
+
+func handleInbox(
+	ctx context.Context,
+	store InboxStore,
+	cfg InboxConfig,
+	msg Message,
+	handle func(context.Context, Message) error,
+) error {
+	key := cfg.KeyFunc(msg)
+	if key == "" {
+		return handle(ctx, msg)
+	}
+
+	status, err := store.Begin(ctx, key, cfg.LeaseTTL)
+	if err != nil {
+		return fmt.Errorf("inbox: could not reserve message %s: %w", key, err)
+	}
+
+	switch status {
+	case InboxReserved:
+	case InboxProcessed:
+		if cfg.OnDuplicate != nil {
+			cfg.OnDuplicate(ctx, msg)
+		}
+		return nil
+	case InboxInFlight:
+		return cqrserrors.NewRetryableError(fmt.Errorf("inbox: message %s: %w", key, ErrMessageInFlight))
+	default:
+		return fmt.Errorf("inbox: could not reserve message %s: unknown status %d", key, status)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = store.Rollback(ctx, key)
+			panic(r)
+		}
+	}()
+
+	if err := handle(ctx, msg); err != nil {
+		_ = store.Rollback(ctx, key)
+		return err
+	}
+
+	if err := store.Commit(ctx, key); err != nil {
+		return fmt.Errorf("inbox: could not record message %s: %w", key, err)
+	}
+	return nil
+}
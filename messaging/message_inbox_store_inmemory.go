@@ -0,0 +1,98 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ InboxStore = (*InMemoryInboxStore)(nil)
+
+const defaultInboxRetention = 24 * time.Hour
+
+type inboxEntry struct {
+	processed bool
+	expiresAt time.Time
+}
+
+// InMemoryInboxStore is a process-local InboxStore.
+// Processed message IDs are kept for the configured retention.
+type InMemoryInboxStore struct {
+	mu        sync.Mutex
+	entries   map[string]inboxEntry
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewInMemoryInboxStore creates a new InMemoryInboxStore keeping the processed
+// message IDs for the given retention. If zero or negative, a default of 24h is used.
+func NewInMemoryInboxStore(retention time.Duration) *InMemoryInboxStore {
+	if retention <= 0 {
+		retention = defaultInboxRetention
+	}
+
+	return &InMemoryInboxStore{
+		entries:   make(map[string]inboxEntry),
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+func (s *InMemoryInboxStore) Begin(_ context.Context, token string, ttl time.Duration) (InboxStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if entry, ok := s.entries[token]; ok && entry.expiresAt.After(now) {
+		if entry.processed {
+			return InboxProcessed, nil
+		}
+		return InboxInFlight, nil
+	}
+
+	s.entries[token] = inboxEntry{expiresAt: now.Add(ttl)}
+	return InboxReserved, nil
+}
+
+func (s *InMemoryInboxStore) Commit(_ context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[token] = inboxEntry{processed: true, expiresAt: s.now().Add(s.retention)}
+	return nil
+}
+
+func (s *InMemoryInboxStore) Rollback(_ context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[token]; ok && !entry.processed {
+		delete(s.entries, token)
+	}
+	return nil
+}
+
+// Processed reports whether the given message ID has been processed and is still retained.
+func (s *InMemoryInboxStore) Processed(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[token]
+	return ok && entry.processed && entry.expiresAt.After(s.now())
+}
+
+// Purge removes the expired entries and returns the number of removed entries.
+func (s *InMemoryInboxStore) Purge() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	n := 0
+	for token, entry := range s.entries {
+		if !entry.expiresAt.After(now) {
+			delete(s.entries, token)
+			n++
+		}
+	}
+	return n
+}
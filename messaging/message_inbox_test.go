@@ -0,0 +1,208 @@
+package messaging_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cqrserrors "github.com/xfrr/go-cqrsify/errors"
+	"github.com/xfrr/go-cqrsify/messaging"
+	"github.com/xfrr/go-cqrsify/uow"
+)
+
+func countingHandler(calls *atomic.Int32, err error) messaging.MessageHandler[messaging.Message] {
+	return messaging.MessageHandlerFn[messaging.Message](func(context.Context, messaging.Message) error {
+		calls.Add(1)
+		return err
+	})
+}
+
+func TestInboxMiddleware(t *testing.T) {
+	ctx := context.Background()
+	charge := messaging.NewBaseCommand("payment.charge", messaging.WithID("msg-1"))
+
+	t.Run("should skip the duplicated messages", func(t *testing.T) {
+		var calls, duplicates atomic.Int32
+		store := messaging.NewInMemoryInboxStore(time.Hour)
+		sut := messaging.InboxMiddleware(store,
+			messaging.ConfigureInboxOnDuplicate(func(context.Context, messaging.Message) { duplicates.Add(1) }),
+		)(countingHandler(&calls, nil))
+
+		require.NoError(t, sut.Handle(ctx, charge))
+		require.NoError(t, sut.Handle(ctx, charge))
+		require.NoError(t, sut.Handle(ctx, messaging.NewBaseCommand("payment.charge", messaging.WithID("msg-2"))))
+
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Equal(t, int32(1), duplicates.Load())
+		assert.True(t, store.Processed("msg-1"))
+	})
+
+	t.Run("should process again the failed messages", func(t *testing.T) {
+		var calls atomic.Int32
+		errCharge := errors.New("card declined")
+		store := messaging.NewInMemoryInboxStore(time.Hour)
+
+		err := messaging.InboxMiddleware(store)(countingHandler(&calls, errCharge)).Handle(ctx, charge)
+		require.ErrorIs(t, err, errCharge)
+		assert.False(t, store.Processed("msg-1"))
+
+		require.NoError(t, messaging.InboxMiddleware(store)(countingHandler(&calls, nil)).Handle(ctx, charge))
+		assert.Equal(t, int32(2), calls.Load())
+		assert.True(t, store.Processed("msg-1"))
+	})
+
+	t.Run("should retry the messages being processed", func(t *testing.T) {
+		var calls, duplicates atomic.Int32
+		started, release := make(chan struct{}), make(chan struct{})
+		store := messaging.NewInMemoryInboxStore(time.Hour)
+		sut := messaging.InboxMiddleware(store,
+			messaging.ConfigureInboxOnDuplicate(func(context.Context, messaging.Message) { duplicates.Add(1) }),
+		)(messaging.MessageHandlerFn[messaging.Message](func(context.Context, messaging.Message) error {
+			if calls.Add(1) == 1 {
+				close(started)
+				<-release
+			}
+			return nil
+		}))
+
+		var wg sync.WaitGroup
+		wg.Go(func() { assert.NoError(t, sut.Handle(ctx, charge)) })
+
+		<-started
+		err := sut.Handle(ctx, charge)
+		require.ErrorIs(t, err, messaging.ErrMessageInFlight)
+		assert.True(t, cqrserrors.IsRetryable(err), "in-flight messages must be retried")
+		assert.Zero(t, duplicates.Load(), "in-flight messages are not duplicates")
+
+		close(release)
+		wg.Wait()
+
+		require.NoError(t, sut.Handle(ctx, charge))
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, int32(1), duplicates.Load())
+	})
+
+	t.Run("should process again the messages after the retention", func(t *testing.T) {
+		var calls atomic.Int32
+		store := messaging.NewInMemoryInboxStore(10 * time.Millisecond)
+		sut := messaging.InboxMiddleware(store)(countingHandler(&calls, nil))
+
+		require.NoError(t, sut.Handle(ctx, charge))
+		require.Eventually(t, func() bool { return !store.Processed("msg-1") }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, 1, store.Purge())
+
+		require.NoError(t, sut.Handle(ctx, charge))
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("should always process the messages without key", func(t *testing.T) {
+		var calls atomic.Int32
+		sut := messaging.InboxMiddleware(messaging.NewInMemoryInboxStore(time.Hour))(countingHandler(&calls, nil))
+
+		msg := messaging.NewBaseCommand("payment.charge")
+		require.NoError(t, sut.Handle(ctx, msg))
+		require.NoError(t, sut.Handle(ctx, msg))
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("should use the configured key", func(t *testing.T) {
+		var calls atomic.Int32
+		sut := messaging.InboxMiddleware(
+			messaging.NewInMemoryInboxStore(time.Hour),
+			messaging.ConfigureInboxKeyFunc(func(msg messaging.Message) string {
+				return msg.MessageMetadata()["payment_id"]
+			}),
+		)(countingHandler(&calls, nil))
+
+		require.NoError(t, sut.Handle(ctx, messaging.NewBaseCommand("payment.charge",
+			messaging.WithID("msg-1"), messaging.WithMetadataKeyValue("payment_id", "pay-1"))))
+		require.NoError(t, sut.Handle(ctx, messaging.NewBaseCommand("payment.charge",
+			messaging.WithID("msg-2"), messaging.WithMetadataKeyValue("payment_id", "pay-1"))))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
+
+// txInbox is a transactional InboxStore fake: processed messages are recorded on commit.
+type txInbox struct {
+	mu        sync.Mutex
+	processed map[string]bool
+}
+
+func (i *txInbox) Begin(context.Context) (uow.Tx, error) { return &inboxTx{inbox: i}, nil }
+
+func (i *txInbox) isProcessed(token string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.processed[token]
+}
+
+type inboxTx struct {
+	inbox  *txInbox
+	staged []string
+}
+
+func (tx *inboxTx) Commit(context.Context) error {
+	tx.inbox.mu.Lock()
+	defer tx.inbox.mu.Unlock()
+	for _, token := range tx.staged {
+		tx.inbox.processed[token] = true
+	}
+	return nil
+}
+
+func (tx *inboxTx) Rollback(context.Context) error { return nil }
+
+// txInboxStore is the InboxStore bound to an inboxTx.
+type txInboxStore struct{ tx *inboxTx }
+
+func (s txInboxStore) Begin(_ context.Context, token string, _ time.Duration) (messaging.InboxStatus, error) {
+	if s.tx.inbox.isProcessed(token) {
+		return messaging.InboxProcessed, nil
+	}
+	return messaging.InboxReserved, nil
+}
+
+func (s txInboxStore) Commit(_ context.Context, token string) error {
+	s.tx.staged = append(s.tx.staged, token)
+	return nil
+}
+
+func (s txInboxStore) Rollback(context.Context, string) error { return nil }
+
+type inboxRepos struct{ Inbox messaging.InboxStore }
+
+func TestTransactionalInboxMiddleware(t *testing.T) {
+	ctx := context.Background()
+	inbox := &txInbox{processed: map[string]bool{}}
+	newUnit := func() *uow.UnitOfWork[inboxRepos] {
+		return uow.New(inbox, inboxRepos{}, func(tx uow.Tx) inboxRepos {
+			return inboxRepos{Inbox: txInboxStore{tx: tx.(*inboxTx)}}
+		}, uow.Config{})
+	}
+	middleware := messaging.TransactionalInboxMiddleware(newUnit, func(r inboxRepos) messaging.InboxStore { return r.Inbox })
+
+	charge := messaging.NewBaseCommand("payment.charge", messaging.WithID("msg-1"))
+	errCharge := errors.New("card declined")
+
+	var calls atomic.Int32
+	failing := middleware(messaging.MessageHandlerFn[messaging.Message](func(ctx context.Context, _ messaging.Message) error {
+		calls.Add(1)
+		_, ok := messaging.UnitOfWorkRepos[inboxRepos](ctx)
+		assert.True(t, ok, "the handler must access the transaction repositories")
+		return errCharge
+	}))
+	require.ErrorIs(t, failing.Handle(ctx, charge), errCharge)
+	assert.False(t, inbox.isProcessed("msg-1"), "the inbox write must be rolled back with the handler")
+
+	sut := middleware(countingHandler(&calls, nil))
+	require.NoError(t, sut.Handle(ctx, charge))
+	assert.True(t, inbox.isProcessed("msg-1"))
+
+	require.NoError(t, sut.Handle(ctx, charge))
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -0,0 +1,43 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+
+	"github.com/xfrr/go-cqrsify/domain"
+	domainpolicy "github.com/xfrr/go-cqrsify/domain/policy"
+	cqrserrors "github.com/xfrr/go-cqrsify/errors"
+)
+
+// PolicyMiddleware enforces the policies of the engine on the messages of type M
+// before their handler runs. The given policies are evaluated, or all the registered ones
+// if none is given, and the handler is not called if any of them denies the message:
+// a domain.PolicyViolationError carrying the code and the reason of the denial is returned instead.
+// Messages of other types are handled as is.
+func PolicyMiddleware[M Message](engine *domainpolicy.PolicyEngine[M], policyNames ...string) MessageHandlerMiddleware {
+	return func(next MessageHandler[Message]) MessageHandler[Message] {
+		return MessageHandlerFn[Message](func(ctx context.Context, msg Message) error {
+			if subject, ok := msg.(M); ok {
+				if err := engine.Enforce(ctx, subject, policyNames...); err != nil {
+					return err
+				}
+			}
+			return next.Handle(ctx, msg)
+		})
+	}
+}
+
+// PolicyViolationMiddleware classifies the policy violations returned by the handlers,
+// i.e. the errors matching domain.ErrPolicyViolation, as permanent errors,
+// so that the denied messages are not retried.
+func PolicyViolationMiddleware() MessageHandlerMiddleware {
+	return func(next MessageHandler[Message]) MessageHandler[Message] {
+		return MessageHandlerFn[Message](func(ctx context.Context, msg Message) error {
+			err := next.Handle(ctx, msg)
+			if errors.Is(err, domain.ErrPolicyViolation) && !cqrserrors.IsPermanent(err) {
+				return cqrserrors.NewPermanentError(err)
+			}
+			return err
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package messaging_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xfrr/go-cqrsify/domain"
+	domainpolicy "github.com/xfrr/go-cqrsify/domain/policy"
+	cqrserrors "github.com/xfrr/go-cqrsify/errors"
+	"github.com/xfrr/go-cqrsify/messaging"
+)
+
+type knownActorPolicy struct {
+	domainpolicy.BasePolicy
+}
+
+func (p knownActorPolicy) Evaluate(_ context.Context, cmd messaging.Command) domainpolicy.Result {
+	if cmd.MessageMetadata()[domain.EventMetadataActor] == "" {
+		return domainpolicy.Deny("The actor is unknown", "UNKNOWN_ACTOR")
+	}
+	return domainpolicy.Allow("The actor is known")
+}
+
+func TestPolicyMiddleware(t *testing.T) {
+	const subject = "order.place"
+
+	engine := domainpolicy.NewPolicyEngine[messaging.Command]()
+	engine.Register(knownActorPolicy{BasePolicy: domainpolicy.NewBasePolicy("known-actor")})
+
+	bus := messaging.NewInMemoryCommandBus(messaging.ConfigureInMemoryMessageBusSubjects(subject))
+	bus.Use(messaging.PolicyViolationMiddleware(), messaging.PolicyMiddleware(engine))
+
+	handled := 0
+	_, err := bus.Subscribe(
+		context.Background(),
+		messaging.MessageHandlerFn[messaging.Command](func(_ context.Context, _ messaging.Command) error {
+			handled++
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+
+	t.Run("should run the handler when the policies allow the command", func(t *testing.T) {
+		cmd := messaging.NewBaseCommand(subject, messaging.WithMetadataKeyValue(domain.EventMetadataActor, "alice"))
+		require.NoError(t, bus.Dispatch(context.Background(), cmd))
+		assert.Equal(t, 1, handled)
+	})
+
+	t.Run("should not run the handler and fail permanently when a policy denies the command", func(t *testing.T) {
+		err := bus.Dispatch(context.Background(), messaging.NewBaseCommand(subject))
+		require.ErrorIs(t, err, domain.ErrPolicyViolation)
+		assert.True(t, cqrserrors.IsPermanent(err))
+		assert.False(t, cqrserrors.IsRetryable(err))
+
+		var violation domain.PolicyViolationError
+		require.True(t, errors.As(err, &violation))
+		assert.Equal(t, "known-actor", violation.Policy)
+		assert.Equal(t, "UNKNOWN_ACTOR", violation.Code)
+		assert.Equal(t, 1, handled)
+	})
+}
+
+func TestPolicyViolationMiddleware(t *testing.T) {
+	handle := func(err error) error {
+		h := messaging.PolicyViolationMiddleware()(messaging.MessageHandlerFn[messaging.Message](func(context.Context, messaging.Message) error {
+			return err
+		}))
+		return h.Handle(context.Background(), messaging.NewBaseCommand("order.place"))
+	}
+
+	t.Run("should keep other errors as is", func(t *testing.T) {
+		errOther := errors.New("other")
+		err := handle(errOther)
+		assert.Equal(t, errOther, err)
+		assert.NoError(t, handle(nil))
+	})
+
+	t.Run("should classify the policy violations returned by the handler as permanent", func(t *testing.T) {
+		err := handle(domain.NewPolicyViolationError("credit-limit", "LIMIT_EXCEEDED", "Credit limit exceeded"))
+		assert.True(t, cqrserrors.IsPermanent(err))
+		assert.ErrorIs(t, err, domain.ErrPolicyViolation)
+	})
+}
@@ -4,12 +4,16 @@ go 1.26
 
 require (
 	github.com/nats-io/nats.go v1.52.0
+	github.com/stretchr/testify v1.11.1
 	github.com/xfrr/go-cqrsify v0.10.0
 )
 
 require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	go.opentelemetry.io/otel/trace v1.44.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 require (
@@ -10,6 +10,7 @@ import (
 var _ messaging.CommandBus = (*JetStreamCommandBus)(nil)
 var _ messaging.CommandBusReplier = (*JetStreamCommandBus)(nil)
 var _ messaging.CommandConsumerReplier = (*JetStreamCommandBus)(nil)
+var _ messaging.Shutdowner = (*JetStreamCommandBus)(nil)
 
 type JetStreamCommandBus struct {
 	JetStreamMessageBus
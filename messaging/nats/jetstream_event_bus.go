@@ -8,6 +8,7 @@ import (
 )
 
 var _ messaging.MessageBus = (*JetStreamMessageBus)(nil)
+var _ messaging.Shutdowner = (*JetstreamEventBus)(nil)
 
 type JetstreamEventBus struct {
 	JetStreamMessageBus
@@ -1,6 +1,8 @@
 package messagingnats
 
 import (
+	"context"
+	"errors"
 	"strings"
 
 	"github.com/nats-io/nats.go/jetstream"
@@ -11,6 +13,7 @@ const replyHeaderKey = "Nats-Reply-Subject"
 
 // Ensure JetstreamMessageBus implements the MessageBus interface.
 var _ messaging.MessageBus = (*JetStreamMessageBus)(nil)
+var _ messaging.Shutdowner = (*JetStreamMessageBus)(nil)
 
 // JetStreamMessageBus is a NATS-based implementation of the MessageBus interface.
 // It provides methods for publishing and subscribing to messages using NATS JetStream as the underlying message bus.
@@ -29,6 +32,15 @@ func NewJetstreamMessageBus(
 	}
 }
 
+// Shutdown shuts down the publisher and then the consumer gracefully.
+// See JetstreamMessagePublisher.Shutdown and JetStreamMessageConsumer.Shutdown.
+func (b JetStreamMessageBus) Shutdown(ctx context.Context) error {
+	return errors.Join(
+		b.JetstreamMessagePublisher.Shutdown(ctx),
+		b.JetStreamMessageConsumer.Shutdown(ctx),
+	)
+}
+
 // consumerNameFromMessageType generates a consumer name based on the message type.
 func consumerNameFromMessageType(msgType string) string {
 	// normalize the message type to be used as a consumer name
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
@@ -14,6 +16,10 @@ import (
 var _ messaging.MessageConsumer = (*JetStreamMessageConsumer[jetstream.ConsumerConfig])(nil)
 var _ messaging.MessageConsumerReplier = (*JetStreamMessageConsumer[jetstream.ConsumerConfig])(nil)
 var _ messaging.MessageConsumer = (*JetStreamMessageConsumer[jetstream.OrderedConsumerConfig])(nil)
+var _ messaging.Shutdowner = (*JetStreamMessageConsumer[jetstream.ConsumerConfig])(nil)
+
+// defaultConsumerInfoTimeout bounds the retrieval of the consumer info once the shutdown context is done.
+const defaultConsumerInfoTimeout = 5 * time.Second
 
 // JetStreamMessageConsumer is a consumer that uses NATS JetStream.
 type JetStreamMessageConsumer[T jetStreamConsumerConfig] struct {
@@ -23,6 +29,10 @@ type JetStreamMessageConsumer[T jetStreamConsumerConfig] struct {
 	streamName   string
 	cfg          JetStreamMessageConsumerConfig[T]
 	errorHandler messaging.ErrorHandler
+
+	gate   shutdownGate
+	subsMu sync.Mutex
+	subs   []jetstream.ConsumeContext
 }
 
 // NewJetStreamMessageConsumer creates a standard JetStream consumer.
@@ -76,6 +86,14 @@ func (p *JetStreamMessageConsumer[T]) Subscribe(
 	if p.consumer == nil {
 		return nil, errors.New("consumer is not initialized")
 	}
+	if !p.gate.enter() {
+		return nil, errShutdownConsumer
+	}
+	defer p.gate.leave()
+
+	if p.cfg.DeadLetterSink != nil {
+		handler = messaging.DeadLetterMiddleware(p.cfg.DeadLetterSink, p.cfg.DeadLetterOptions...)(handler)
+	}
 
 	cc, err := p.consumer.Consume(func(jmsg jetstream.Msg) {
 		m := p.deserializeMessage(jmsg)
@@ -86,10 +104,13 @@ func (p *JetStreamMessageConsumer[T]) Subscribe(
 		// Extract tracing context from message headers
 		// and create a new context for handling the message
 		msgCtx := p.propagateTracingContext(ctx, jmsg)
+		msgCtx = messaging.ContextWithDelivery(msgCtx, p.delivery(jmsg))
 
-		if err := handler.Handle(msgCtx, m); err != nil {
-			// TODO: decide whether to Nak or Term based on error type
-			p.errAndNak(jmsg, m, fmt.Errorf("failed to handle message: %w", err))
+		stop := p.keepInProgress(msgCtx, jmsg, m)
+		err := handler.Handle(msgCtx, m)
+		stop()
+		if err != nil {
+			p.errAndSettle(jmsg, m, err)
 			return
 		}
 
@@ -102,6 +123,7 @@ func (p *JetStreamMessageConsumer[T]) Subscribe(
 		return nil, fmt.Errorf("failed to subscribe: %w", err)
 	}
 
+	p.track(cc)
 	return p.unsubscribeFn(cc), nil
 }
 
@@ -116,6 +138,10 @@ func (p *JetStreamMessageConsumer[T]) SubscribeWithReply(
 	if p.consumer == nil {
 		return nil, errors.New("consumer is not initialized")
 	}
+	if !p.gate.enter() {
+		return nil, errShutdownConsumer
+	}
+	defer p.gate.leave()
 
 	cc, err := p.consumer.Consume(func(jmsg jetstream.Msg) {
 		m := p.deserializeMessage(jmsg)
@@ -126,11 +152,13 @@ func (p *JetStreamMessageConsumer[T]) SubscribeWithReply(
 		// Extract tracing context from message headers
 		// and create a new context for handling the message
 		msgCtx := p.propagateTracingContext(ctx, jmsg)
+		msgCtx = messaging.ContextWithDelivery(msgCtx, p.delivery(jmsg))
 
+		stop := p.keepInProgress(msgCtx, jmsg, m)
 		replyMsg, handleErr := handler.Handle(msgCtx, m)
+		stop()
 		if handleErr != nil {
-			// TODO: decide whether to Term or Nak based on error type
-			p.errAndTerm(jmsg, m, "message_handling_failed", fmt.Errorf("failed to handle message: %w", handleErr))
+			p.errAndSettle(jmsg, m, handleErr)
 			return
 		}
 		if replyMsg == nil {
@@ -175,9 +203,58 @@ func (p *JetStreamMessageConsumer[T]) SubscribeWithReply(
 		return nil, fmt.Errorf("failed to subscribe with reply: %w", err)
 	}
 
+	p.track(cc)
 	return p.unsubscribeFn(cc), nil
 }
 
+// Shutdown rejects the new subscriptions and drains the active ones, letting them handle
+// the messages already received, until the context is done. The subscriptions are then stopped
+// and the messages left unacknowledged are redelivered by the server; their number is
+// reported as the Pending count of the returned UndeliveredMessagesError.
+func (p *JetStreamMessageConsumer[T]) Shutdown(ctx context.Context) error {
+	if closed, _ := p.gate.close(ctx); closed {
+		return nil
+	}
+
+	p.subsMu.Lock()
+	subs := p.subs
+	p.subs = nil
+	p.subsMu.Unlock()
+
+	closedChs := make([]<-chan struct{}, 0, len(subs))
+	for _, cc := range subs {
+		cc.Drain()
+		closedChs = append(closedChs, cc.Closed())
+	}
+
+	if err := waitAll(ctx, closedChs...); err != nil {
+		for _, cc := range subs {
+			cc.Stop()
+		}
+		return messaging.UndeliveredMessagesError{Pending: p.pendingAcks(ctx), Err: err}
+	}
+	return nil
+}
+
+// pendingAcks returns the number of messages delivered by the consumer and not acknowledged yet,
+// or 0 if it cannot be retrieved. The given context is usually done, so only its values are kept.
+func (p *JetStreamMessageConsumer[T]) pendingAcks(ctx context.Context) int {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultConsumerInfoTimeout)
+	defer cancel()
+
+	info, err := p.consumer.Info(ctx)
+	if err != nil {
+		return 0
+	}
+	return info.NumAckPending
+}
+
+func (p *JetStreamMessageConsumer[T]) track(cc jetstream.ConsumeContext) {
+	p.subsMu.Lock()
+	defer p.subsMu.Unlock()
+	p.subs = append(p.subs, cc)
+}
+
 func (p *JetStreamMessageConsumer[T]) unsubscribeFn(sub jetstream.ConsumeContext) messaging.UnsubscribeFunc {
 	return func() error {
 		sub.Drain()
@@ -208,17 +285,51 @@ func (p *JetStreamMessageConsumer[T]) deserializeMessage(jmsg jetstream.Msg) mes
 	return m
 }
 
+// delivery returns the delivery of the given message, whose attempt is the JetStream delivery count.
+func (p *JetStreamMessageConsumer[T]) delivery(jmsg jetstream.Msg) messaging.Delivery {
+	delivery := messaging.Delivery{Subject: jmsg.Subject(), Attempt: 1, Redelivered: true}
+	if md, err := jmsg.Metadata(); err == nil && md.NumDelivered > 0 {
+		delivery.Attempt = int(md.NumDelivered)
+	}
+	return delivery
+}
+
 func (p *JetStreamMessageConsumer[T]) propagateTracingContext(parent context.Context, jmsg jetstream.Msg) context.Context {
 	return p.cfg.OTELPropagator.Extract(parent, propagation.HeaderCarrier(jmsg.Headers()))
 }
 
-func (p *JetStreamMessageConsumer[T]) errAndNak(jmsg jetstream.Msg, msg messaging.Message, err error) {
+// errAndSettle reports the handler error and naks or terms the message as decided by the failure policy.
+func (p *JetStreamMessageConsumer[T]) errAndSettle(jmsg jetstream.Msg, msg messaging.Message, err error) {
+	err = fmt.Errorf("failed to handle message: %w", err)
+
+	policy := p.cfg.FailurePolicy
+	if policy == nil {
+		policy = DefaultFailurePolicy(nil)
+	}
+
+	decision := policy(err, p.delivery(jmsg))
+	if decision.Action == FailureActionTerm {
+		p.errAndTerm(jmsg, msg, decision.Reason, err)
+		return
+	}
+
 	p.handleErr(msg, err)
-	if nakErr := jmsg.Nak(); nakErr != nil {
+	nak := jmsg.Nak
+	if decision.Delay > 0 {
+		nak = func() error { return jmsg.NakWithDelay(decision.Delay) }
+	}
+	if nakErr := nak(); nakErr != nil {
 		p.handleErr(msg, fmt.Errorf("failed to nak message: %w", nakErr))
 	}
 }
 
+// keepInProgress sends in-progress heartbeats for the message until the returned function is called.
+func (p *JetStreamMessageConsumer[T]) keepInProgress(ctx context.Context, jmsg jetstream.Msg, msg messaging.Message) func() {
+	return keepInProgress(ctx, p.cfg.InProgressInterval, jmsg.InProgress, func(err error) {
+		p.handleErr(msg, fmt.Errorf("failed to signal message in progress: %w", err))
+	})
+}
+
 func (p *JetStreamMessageConsumer[T]) errAndTerm(jmsg jetstream.Msg, msg messaging.Message, reason string, err error) {
 	p.handleErr(msg, err)
 	if termErr := jmsg.TermWithReason(reason); termErr != nil {
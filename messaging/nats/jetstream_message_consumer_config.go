@@ -5,6 +5,7 @@ import (
 
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/xfrr/go-cqrsify/messaging"
+	"github.com/xfrr/go-cqrsify/pkg/retry"
 	"go.opentelemetry.io/otel/propagation"
 )
 
@@ -26,6 +27,20 @@ type JetStreamMessageConsumerConfig[T jetstream.ConsumerConfig | jetstream.Order
 	// OTELPropagator is the OpenTelemetry propagator for trace
 	// propagation using message headers and context.
 	OTELPropagator propagation.TextMapPropagator
+	// DeadLetterSink receives the messages whose handler exhausted its deliveries.
+	// If nil, the failed messages are redelivered until the consumer MaxDeliver is reached.
+	DeadLetterSink messaging.DeadLetterSink
+	// DeadLetterOptions configure the dead-lettering, see messaging.DeadLetterMiddleware.
+	// The consumer MaxDeliver, if set, must not be lower than the dead letter MaxDeliveries.
+	DeadLetterOptions []messaging.DeadLetterConfiger
+	// FailurePolicy decides whether the messages whose handler failed are redelivered or terminated.
+	// Defaults to DefaultFailurePolicy with an exponential backoff.
+	FailurePolicy FailurePolicy
+	// InProgressInterval is the interval at which the server is told that a message is still being handled,
+	// so that long handlers do not exceed the consumer AckWait. If zero, half the AckWait
+	// (15s by default) is used for standard consumers; ordered consumers do not acknowledge the messages.
+	// If negative, no heartbeat is sent.
+	InProgressInterval time.Duration
 }
 
 func NewJetStreamMessageConsumerConfig(opts ...JetStreamMessageConsumerConfiger[jetstream.ConsumerConfig]) JetStreamMessageConsumerConfig[jetstream.ConsumerConfig] {
@@ -36,10 +51,17 @@ func NewJetStreamMessageConsumerConfig(opts ...JetStreamMessageConsumerConfiger[
 		Serializer:     messaging.DefaultJSONSerializer,
 		Deserializer:   messaging.DefaultJSONDeserializer,
 		OTELPropagator: propagation.NewCompositeTextMapPropagator(),
+		FailurePolicy:  DefaultFailurePolicy(nil),
 	}
 	for _, opt := range opts {
 		opt.apply(cfg)
 	}
+	if cfg.InProgressInterval == 0 {
+		cfg.InProgressInterval = defaultInProgressInterval
+		if cfg.ConsumerConfig.AckWait > 0 {
+			cfg.InProgressInterval = cfg.ConsumerConfig.AckWait / 2
+		}
+	}
 	return *cfg
 }
 
@@ -51,6 +73,7 @@ func NewJetStreamOrderedMessageConsumerConfig(opts ...JetStreamMessageConsumerCo
 		Serializer:     messaging.DefaultJSONSerializer,
 		Deserializer:   messaging.DefaultJSONDeserializer,
 		OTELPropagator: propagation.NewCompositeTextMapPropagator(),
+		FailurePolicy:  DefaultFailurePolicy(nil),
 	}
 	for _, opt := range opts {
 		opt.apply(cfg)
@@ -110,3 +133,38 @@ func WithJetStreamConsumerOTELPropagator[T jetStreamConsumerConfig](propagator p
 		cfg.OTELPropagator = propagator
 	})
 }
+
+// WithJetStreamConsumerDeadLetterSink forwards the messages whose handler exhausted its deliveries to the given sink.
+func WithJetStreamConsumerDeadLetterSink[T jetStreamConsumerConfig](
+	sink messaging.DeadLetterSink,
+	opts ...messaging.DeadLetterConfiger,
+) JetStreamMessageConsumerConfiger[T] {
+	return jetStreamMessageConsumerConfigFunc[T](func(cfg *JetStreamMessageConsumerConfig[T]) {
+		cfg.DeadLetterSink = sink
+		cfg.DeadLetterOptions = opts
+	})
+}
+
+// WithJetStreamConsumerFailurePolicy sets the policy deciding whether the messages whose handler failed
+// are redelivered or terminated.
+func WithJetStreamConsumerFailurePolicy[T jetStreamConsumerConfig](policy FailurePolicy) JetStreamMessageConsumerConfiger[T] {
+	return jetStreamMessageConsumerConfigFunc[T](func(cfg *JetStreamMessageConsumerConfig[T]) {
+		cfg.FailurePolicy = policy
+	})
+}
+
+// WithJetStreamConsumerRetryStrategy uses DefaultFailurePolicy with the given strategy
+// to delay the redelivery of the messages failing with retryable errors.
+func WithJetStreamConsumerRetryStrategy[T jetStreamConsumerConfig](strategy retry.Strategy) JetStreamMessageConsumerConfiger[T] {
+	return jetStreamMessageConsumerConfigFunc[T](func(cfg *JetStreamMessageConsumerConfig[T]) {
+		cfg.FailurePolicy = DefaultFailurePolicy(strategy)
+	})
+}
+
+// WithJetStreamConsumerInProgressInterval sets the interval of the in-progress heartbeats sent while a message is handled.
+// A negative interval disables them.
+func WithJetStreamConsumerInProgressInterval[T jetStreamConsumerConfig](interval time.Duration) JetStreamMessageConsumerConfiger[T] {
+	return jetStreamMessageConsumerConfigFunc[T](func(cfg *JetStreamMessageConsumerConfig[T]) {
+		cfg.InProgressInterval = interval
+	})
+}
@@ -12,12 +12,14 @@ import (
 )
 
 var _ messaging.MessagePublisher = (*JetstreamMessagePublisher)(nil)
+var _ messaging.Shutdowner = (*JetstreamMessagePublisher)(nil)
 
 // JetstreamMessagePublisher is a publisher that uses NATS JetStream.
 type JetstreamMessagePublisher struct {
 	streamName string
 	js         jetstream.JetStream
 	cfg        JetStreamMessagePublisherConfig
+	gate       shutdownGate
 }
 
 func NewJetStreamMessagePublisher(
@@ -37,6 +39,11 @@ func NewJetStreamMessagePublisher(
 
 // Publish implements messaging.MessageBus.
 func (p *JetstreamMessagePublisher) Publish(ctx context.Context, msg ...messaging.Message) error {
+	if !p.gate.enter() {
+		return messaging.ErrPublishOnClosedBus
+	}
+	defer p.gate.leave()
+
 	for _, m := range msg {
 		data, err := p.cfg.Serializer.Serialize(m)
 		if err != nil {
@@ -81,6 +88,11 @@ func (p *JetstreamMessagePublisher) Publish(ctx context.Context, msg ...messagin
 
 // PublishRequest sends a request message and waits for a single reply.
 func (p *JetstreamMessagePublisher) PublishRequest(ctx context.Context, msg messaging.Message) (messaging.Message, error) {
+	if !p.gate.enter() {
+		return nil, messaging.ErrPublishOnClosedBus
+	}
+	defer p.gate.leave()
+
 	msgSubject := p.cfg.SubjectBuilder.Build(msg)
 	if msgSubject == "" {
 		return nil, fmt.Errorf("no subject configured for message type '%s'", msg.MessageType())
@@ -199,3 +211,11 @@ func (p *JetstreamMessagePublisher) getMessageTTL(m messaging.Message) time.Dura
 	}
 	return p.cfg.StreamTTL
 }
+
+// Shutdown rejects the new publishes and waits for the ones in progress until the context is done.
+func (p *JetstreamMessagePublisher) Shutdown(ctx context.Context) error {
+	if _, err := p.gate.close(ctx); err != nil {
+		return messaging.UndeliveredMessagesError{Err: err}
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 )
 
 var _ messaging.QueryBus = (*JetstreamQueryBus)(nil)
+var _ messaging.Shutdowner = (*JetstreamQueryBus)(nil)
 
 type JetstreamQueryBus struct {
 	JetStreamMessageBus
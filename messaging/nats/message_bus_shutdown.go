@@ -0,0 +1,67 @@
+package messagingnats
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var errShutdownConsumer = errors.New("consumer is shut down")
+
+// shutdownGate rejects the operations started after the shutdown
+// and lets the shutdown wait for the ones in progress.
+type shutdownGate struct {
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// enter reports whether the operation can start. If so, leave must be called once it is done.
+func (g *shutdownGate) enter() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if g.closed {
+		return false
+	}
+	g.wg.Add(1)
+	return true
+}
+
+func (g *shutdownGate) leave() {
+	g.wg.Done()
+}
+
+// close rejects the new operations and waits for the ones in progress until the context is done.
+// It reports whether the gate was already closed.
+func (g *shutdownGate) close(ctx context.Context) (bool, error) {
+	g.mu.Lock()
+	wasClosed := g.closed
+	g.closed = true
+	g.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return wasClosed, nil
+	case <-ctx.Done():
+		return wasClosed, ctx.Err()
+	}
+}
+
+// waitAll waits for a value or the closing of each channel until the context is done.
+func waitAll[T any](ctx context.Context, chs ...<-chan T) error {
+	for _, ch := range chs {
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
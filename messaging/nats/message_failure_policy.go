@@ -0,0 +1,92 @@
+package messagingnats
+
+import (
+	"context"
+	"time"
+
+	cqrserrors "github.com/xfrr/go-cqrsify/errors"
+	"github.com/xfrr/go-cqrsify/messaging"
+	"github.com/xfrr/go-cqrsify/pkg/retry"
+)
+
+const defaultInProgressInterval = 15 * time.Second
+
+// FailureAction is the acknowledgement of a message whose handler failed.
+type FailureAction string
+
+const (
+	// FailureActionNak redelivers the message after FailureDecision.Delay, or at once if zero.
+	FailureActionNak FailureAction = "nak"
+	// FailureActionTerm stops the redelivery of the message, with FailureDecision.Reason.
+	FailureActionTerm FailureAction = "term"
+)
+
+// FailureDecision tells a consumer how to acknowledge a message whose handler failed.
+type FailureDecision struct {
+	Action FailureAction
+	// Delay is the time to wait before redelivering the message.
+	Delay time.Duration
+	// Reason is the reason reported when the message is terminated.
+	Reason string
+}
+
+// FailurePolicy maps the error returned by a handler, and the delivery of the message,
+// to the acknowledgement of the message.
+type FailurePolicy func(err error, delivery messaging.Delivery) FailureDecision
+
+// DefaultFailurePolicy classifies the handler errors with the errors package:
+//
+// - permanent errors terminate the message, with the error as reason.
+//
+// - retryable and temporary errors redeliver the message after the delay computed by the strategy
+// for the delivery attempt. If the strategy is nil, an exponential backoff is used.
+//
+// - unclassified errors redeliver the message at once.
+func DefaultFailurePolicy(strategy retry.Strategy) FailurePolicy {
+	if strategy == nil {
+		strategy = retry.DefaultOptions().Strategy
+	}
+
+	return func(err error, delivery messaging.Delivery) FailureDecision {
+		switch {
+		case cqrserrors.IsPermanent(err):
+			return FailureDecision{Action: FailureActionTerm, Reason: "permanent_error: " + err.Error()}
+		case cqrserrors.IsRetryable(err):
+			return FailureDecision{Action: FailureActionNak, Delay: strategy.NextDelay(max(delivery.Attempt-1, 0), err)}
+		default:
+			return FailureDecision{Action: FailureActionNak}
+		}
+	}
+}
+
+// keepInProgress signals that the message is being worked on every interval until the returned function is called.
+func keepInProgress(ctx context.Context, interval time.Duration, inProgress func() error, onErr func(error)) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := inProgress(); err != nil {
+					onErr(err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,57 @@
+package messagingnats
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	cqrserrors "github.com/xfrr/go-cqrsify/errors"
+	"github.com/xfrr/go-cqrsify/messaging"
+	"github.com/xfrr/go-cqrsify/pkg/retry"
+)
+
+func TestDefaultFailurePolicy(t *testing.T) {
+	errHandle := errors.New("card declined")
+	strategy := retry.ExponentialStrategy{Base: time.Second, Factor: 2, Cap: time.Minute}
+
+	tests := []struct {
+		name     string
+		err      error
+		attempt  int
+		expected FailureDecision
+	}{
+		{
+			name:     "should term permanent errors",
+			err:      cqrserrors.NewPermanentError(errHandle),
+			attempt:  1,
+			expected: FailureDecision{Action: FailureActionTerm, Reason: "permanent_error: " + cqrserrors.NewPermanentError(errHandle).Error()},
+		},
+		{
+			name:     "should nak retryable errors with the delay of the first attempt",
+			err:      cqrserrors.NewRetryableError(errHandle),
+			attempt:  1,
+			expected: FailureDecision{Action: FailureActionNak, Delay: time.Second},
+		},
+		{
+			name:     "should nak retryable errors with the delay of the delivery attempt",
+			err:      cqrserrors.NewRetryableError(errHandle),
+			attempt:  3,
+			expected: FailureDecision{Action: FailureActionNak, Delay: 4 * time.Second},
+		},
+		{
+			name:     "should nak unclassified errors at once",
+			err:      errHandle,
+			attempt:  2,
+			expected: FailureDecision{Action: FailureActionNak},
+		},
+	}
+
+	policy := DefaultFailurePolicy(strategy)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := policy(tt.err, messaging.Delivery{Subject: "payments.charge", Attempt: tt.attempt})
+			assert.Equal(t, tt.expected, decision)
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 var _ messaging.CommandBus = (*PubSubCommandBus)(nil)
 var _ messaging.CommandBusReplier = (*PubSubCommandBus)(nil)
 var _ messaging.CommandConsumerReplier = (*PubSubCommandBus)(nil)
+var _ messaging.Shutdowner = (*PubSubCommandBus)(nil)
 
 // PubSubMessageBus is a NATS-based implementation of the MessageBus interface.
 // It provides methods for publishing and subscribing to messages using NATS as the underlying message bus.
@@ -1,11 +1,15 @@
 package messagingnats
 
 import (
+	"context"
+	"errors"
+
 	"github.com/xfrr/go-cqrsify/messaging"
 )
 
 // Ensure PubSubMessageBus implements the MessageBus interface.
 var _ messaging.MessageBus = (*PubSubMessageBus)(nil)
+var _ messaging.Shutdowner = (*PubSubMessageBus)(nil)
 
 // PubSubMessageBus is a NATS-based implementation of the MessageBus interface.
 // It provides methods for publishing and subscribing to messages using NATS as the underlying message bus.
@@ -23,3 +27,12 @@ func NewPubSubMessageBus(
 		PubSubMessageConsumer:  pubSubConsumer,
 	}
 }
+
+// Shutdown shuts down the publisher and then the consumer gracefully.
+// See PubSubMessagePublisher.Shutdown and PubSubMessageConsumer.Shutdown.
+func (b PubSubMessageBus) Shutdown(ctx context.Context) error {
+	return errors.Join(
+		b.PubSubMessagePublisher.Shutdown(ctx),
+		b.PubSubMessageConsumer.Shutdown(ctx),
+	)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/xfrr/go-cqrsify/messaging"
@@ -11,11 +13,16 @@ import (
 )
 
 var _ messaging.MessageConsumer = (*PubSubMessageConsumer)(nil)
+var _ messaging.Shutdowner = (*PubSubMessageConsumer)(nil)
 
 // PubSubMessageConsumer consumes messages from a NATS subject (core pub/sub).
 type PubSubMessageConsumer struct {
 	conn *nats.Conn
 	cfg  PubSubMessageConsumerConfig
+
+	gate   shutdownGate
+	subsMu sync.Mutex
+	subs   []*nats.Subscription
 }
 
 func NewPubSubMessageConsumer(
@@ -42,11 +49,20 @@ func (p *PubSubMessageConsumer) Subscribe(
 	if handler == nil {
 		return nil, errors.New("handler cannot be nil")
 	}
+	if !p.gate.enter() {
+		return nil, errShutdownConsumer
+	}
+	defer p.gate.leave()
+
+	if p.cfg.DeadLetterSink != nil {
+		handler = messaging.DeadLetterMiddleware(p.cfg.DeadLetterSink, p.cfg.DeadLetterOptions...)(handler)
+	}
 
 	sub, err := p.conn.Subscribe(p.cfg.Subject, p.handleMessage(ctx, handler))
 	if err != nil {
 		return nil, fmt.Errorf("failed to subscribe to subject %q: %w", p.cfg.Subject, err)
 	}
+	p.track(sub)
 	return p.unsubscribeFn(p.cfg.Subject, sub), nil
 }
 
@@ -58,11 +74,16 @@ func (p *PubSubMessageConsumer) SubscribeWithReply(
 	if handler == nil {
 		return nil, errors.New("handler cannot be nil")
 	}
+	if !p.gate.enter() {
+		return nil, errShutdownConsumer
+	}
+	defer p.gate.leave()
 
 	sub, err := p.conn.Subscribe(p.cfg.Subject, p.handleMessageWithReply(ctx, handler))
 	if err != nil {
 		return nil, fmt.Errorf("failed to subscribe to subject %q: %w", p.cfg.Subject, err)
 	}
+	p.track(sub)
 	return p.unsubscribeFn(p.cfg.Subject, sub), nil
 }
 
@@ -77,9 +98,10 @@ func (p *PubSubMessageConsumer) handleMessage(
 		}
 
 		msgCtx := p.propagateTracingContext(ctx, nm)
-		if err := handler.Handle(msgCtx, m); err != nil {
-			// TODO: decide whether to Term or Nak based on error type
-			p.errHandle(m, fmt.Errorf("failed to handle message: %w", err))
+		if err := p.handleWithPolicy(msgCtx, nm, func(ctx context.Context) error {
+			return handler.Handle(ctx, m)
+		}); err != nil {
+			p.errHandle(m, err)
 			return
 		}
 	}
@@ -96,10 +118,13 @@ func (p *PubSubMessageConsumer) handleMessageWithReply(
 		}
 
 		msgCtx := p.propagateTracingContext(ctx, nm)
-		reply, err := handler.Handle(msgCtx, m)
-		if err != nil {
-			// TODO: decide whether to Term or Nak based on error type
-			p.errHandle(m, fmt.Errorf("failed to handle message: %w", err))
+		var reply messaging.Message
+		if err := p.handleWithPolicy(msgCtx, nm, func(ctx context.Context) error {
+			var handleErr error
+			reply, handleErr = handler.Handle(ctx, m)
+			return handleErr
+		}); err != nil {
+			p.errHandle(m, err)
 			return
 		}
 		if reply == nil {
@@ -114,6 +139,43 @@ func (p *PubSubMessageConsumer) handleMessageWithReply(
 	}
 }
 
+// handleWithPolicy calls handle until it succeeds, the failure policy terms the message
+// or the deliveries are exhausted, waiting the policy delay between the attempts.
+// As core NATS does not redeliver the messages, the deliveries happen in place.
+func (p *PubSubMessageConsumer) handleWithPolicy(ctx context.Context, nm *nats.Msg, handle func(ctx context.Context) error) error {
+	policy := p.cfg.FailurePolicy
+	if policy == nil {
+		policy = DefaultFailurePolicy(nil)
+	}
+
+	for attempt := 1; ; attempt++ {
+		// the message is redelivered by this loop until the last attempt, so the middlewares
+		// retry it in place, or dead-letter it, on the last attempt only
+		delivery := messaging.Delivery{Subject: nm.Subject, Attempt: attempt, Redelivered: attempt < p.cfg.MaxDeliveries}
+		err := handle(messaging.ContextWithDelivery(ctx, delivery))
+		if err == nil {
+			return nil
+		}
+		err = fmt.Errorf("failed to handle message: %w", err)
+
+		decision := policy(err, delivery)
+		if decision.Action == FailureActionTerm {
+			return fmt.Errorf("message terminated (%s): %w", decision.Reason, err)
+		}
+		if attempt >= p.cfg.MaxDeliveries {
+			return err
+		}
+
+		timer := time.NewTimer(decision.Delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
 // sendReply serializes the reply, injects tracing headers and sends the reply message;
 // it reports errors via errAndTerm and returns a non-nil error when something failed.
 func (p *PubSubMessageConsumer) sendReply(msgCtx context.Context, nm *nats.Msg, reply messaging.Message) error {
@@ -143,6 +205,50 @@ func (p *PubSubMessageConsumer) sendReply(msgCtx context.Context, nm *nats.Msg,
 	return nil
 }
 
+// Shutdown rejects the new subscriptions and drains the active ones, letting them handle
+// the messages already received, until the context is done. The subscriptions are then closed
+// and the number of messages left unhandled, which core NATS does not redeliver, is reported.
+func (p *PubSubMessageConsumer) Shutdown(ctx context.Context) error {
+	if closed, _ := p.gate.close(ctx); closed {
+		return nil
+	}
+
+	p.subsMu.Lock()
+	subs := p.subs
+	p.subs = nil
+	p.subsMu.Unlock()
+
+	closedChs := make([]<-chan nats.SubStatus, 0, len(subs))
+	var errs []error
+	for _, sub := range subs {
+		if !sub.IsValid() {
+			continue
+		}
+		closedChs = append(closedChs, sub.StatusChanged(nats.SubscriptionClosed))
+		if err := sub.Drain(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to drain subscription to subject %q: %w", sub.Subject, err))
+		}
+	}
+
+	if err := waitAll(ctx, closedChs...); err != nil {
+		pending := 0
+		for _, sub := range subs {
+			if n, _, err := sub.Pending(); err == nil {
+				pending += n
+			}
+			_ = sub.Unsubscribe()
+		}
+		errs = append(errs, messaging.UndeliveredMessagesError{Pending: pending, Err: err})
+	}
+	return errors.Join(errs...)
+}
+
+func (p *PubSubMessageConsumer) track(sub *nats.Subscription) {
+	p.subsMu.Lock()
+	defer p.subsMu.Unlock()
+	p.subs = append(p.subs, sub)
+}
+
 func (p *PubSubMessageConsumer) unsubscribeFn(subject string, sub *nats.Subscription) messaging.UnsubscribeFunc {
 	return func() error {
 		if err := sub.Drain(); err != nil {
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/xfrr/go-cqrsify/messaging"
+	"github.com/xfrr/go-cqrsify/pkg/retry"
 	"go.opentelemetry.io/otel/propagation"
 )
 
@@ -26,6 +27,21 @@ type PubSubMessageConsumerConfig struct {
 	// OTELPropagator is the OpenTelemetry propagator for trace
 	// propagation using message headers and context.
 	OTELPropagator propagation.TextMapPropagator
+	// DeadLetterSink receives the messages whose handler exhausted its deliveries.
+	// As core NATS does not redeliver the messages, the handler is retried in place.
+	// If nil, the failed messages are reported to the ErrorHandler and dropped.
+	DeadLetterSink messaging.DeadLetterSink
+	// DeadLetterOptions configure the dead-lettering, see messaging.DeadLetterMiddleware.
+	DeadLetterOptions []messaging.DeadLetterConfiger
+	// FailurePolicy decides whether the messages whose handler failed are retried or dropped.
+	// Defaults to DefaultFailurePolicy with an exponential backoff.
+	FailurePolicy FailurePolicy
+	// MaxDeliveries is the maximum number of times a message is handled when the FailurePolicy naks it.
+	// As core NATS does not redeliver the messages, the handler is retried in place after the policy delay.
+	// If zero or one, the messages are not retried.
+	// The last delivery is not reported as redelivered, so that the DeadLetterMiddleware
+	// dead-letters the message whatever its own MaxDeliveries.
+	MaxDeliveries int
 }
 
 func NewPubSubMessageConsumerConfig(opts ...PubSubMessageConsumerConfiger) PubSubMessageConsumerConfig {
@@ -44,6 +60,7 @@ func defaultPubSubMessageConsumerConfig() *PubSubMessageConsumerConfig {
 		Serializer:     messaging.DefaultJSONSerializer,
 		Deserializer:   messaging.DefaultJSONDeserializer,
 		OTELPropagator: propagation.NewCompositeTextMapPropagator(),
+		FailurePolicy:  DefaultFailurePolicy(nil),
 	}
 }
 
@@ -96,3 +113,33 @@ func WithPubSubConsumerOTELPropagator(propagator propagation.TextMapPropagator)
 		cfg.OTELPropagator = propagator
 	}
 }
+
+// WithPubSubConsumerDeadLetterSink forwards the messages whose handler exhausted its deliveries to the given sink.
+func WithPubSubConsumerDeadLetterSink(sink messaging.DeadLetterSink, opts ...messaging.DeadLetterConfiger) PubSubMessageConsumerConfiger {
+	return func(cfg *PubSubMessageConsumerConfig) {
+		cfg.DeadLetterSink = sink
+		cfg.DeadLetterOptions = opts
+	}
+}
+
+// WithPubSubConsumerFailurePolicy sets the policy deciding whether the messages whose handler failed are retried or dropped.
+func WithPubSubConsumerFailurePolicy(policy FailurePolicy) PubSubMessageConsumerConfiger {
+	return func(cfg *PubSubMessageConsumerConfig) {
+		cfg.FailurePolicy = policy
+	}
+}
+
+// WithPubSubConsumerRetryStrategy uses DefaultFailurePolicy with the given strategy
+// to delay the retries of the messages failing with retryable errors.
+func WithPubSubConsumerRetryStrategy(strategy retry.Strategy) PubSubMessageConsumerConfiger {
+	return func(cfg *PubSubMessageConsumerConfig) {
+		cfg.FailurePolicy = DefaultFailurePolicy(strategy)
+	}
+}
+
+// WithPubSubConsumerMaxDeliveries sets the maximum number of times a message is handled when the failure policy naks it.
+func WithPubSubConsumerMaxDeliveries(maxDeliveries int) PubSubMessageConsumerConfiger {
+	return func(cfg *PubSubMessageConsumerConfig) {
+		cfg.MaxDeliveries = maxDeliveries
+	}
+}
@@ -0,0 +1,149 @@
+package messagingnats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cqrserrors "github.com/xfrr/go-cqrsify/errors"
+	"github.com/xfrr/go-cqrsify/messaging"
+	"github.com/xfrr/go-cqrsify/pkg/retry"
+)
+
+func TestPubSubMessageConsumer_handleWithPolicy(t *testing.T) {
+	errHandle := errors.New("card declined")
+	nm := &nats.Msg{Subject: "payments.charge"}
+
+	tests := []struct {
+		name              string
+		maxDeliveries     int
+		errs              []error
+		expectedErr       error
+		expectedTerm      bool
+		expectedDelivered []messaging.Delivery
+	}{
+		{
+			name:          "should handle the message at once",
+			maxDeliveries: 3,
+			errs:          []error{nil},
+			expectedDelivered: []messaging.Delivery{
+				{Subject: "payments.charge", Attempt: 1, Redelivered: true},
+			},
+		},
+		{
+			name:          "should redeliver the message until it is handled",
+			maxDeliveries: 3,
+			errs:          []error{cqrserrors.NewRetryableError(errHandle), errHandle, nil},
+			expectedDelivered: []messaging.Delivery{
+				{Subject: "payments.charge", Attempt: 1, Redelivered: true},
+				{Subject: "payments.charge", Attempt: 2, Redelivered: true},
+				{Subject: "payments.charge", Attempt: 3, Redelivered: false},
+			},
+		},
+		{
+			name:          "should return the error once the deliveries are exhausted",
+			maxDeliveries: 2,
+			errs:          []error{errHandle, errHandle},
+			expectedErr:   errHandle,
+			expectedDelivered: []messaging.Delivery{
+				{Subject: "payments.charge", Attempt: 1, Redelivered: true},
+				{Subject: "payments.charge", Attempt: 2, Redelivered: false},
+			},
+		},
+		{
+			name:          "should term the message on permanent errors",
+			maxDeliveries: 3,
+			errs:          []error{cqrserrors.NewPermanentError(errHandle)},
+			expectedErr:   errHandle,
+			expectedTerm:  true,
+			expectedDelivered: []messaging.Delivery{
+				{Subject: "payments.charge", Attempt: 1, Redelivered: true},
+			},
+		},
+		{
+			name:          "should not report redeliveries when the message is delivered once",
+			maxDeliveries: 1,
+			errs:          []error{errHandle},
+			expectedErr:   errHandle,
+			expectedDelivered: []messaging.Delivery{
+				{Subject: "payments.charge", Attempt: 1, Redelivered: false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sut := &PubSubMessageConsumer{cfg: PubSubMessageConsumerConfig{
+				MaxDeliveries: tt.maxDeliveries,
+				FailurePolicy: DefaultFailurePolicy(retry.ConstantStrategy{}),
+			}}
+
+			var delivered []messaging.Delivery
+			err := sut.handleWithPolicy(context.Background(), nm, func(ctx context.Context) error {
+				delivery, ok := messaging.DeliveryFromContext(ctx)
+				require.True(t, ok)
+				delivered = append(delivered, delivery)
+				return tt.errs[len(delivered)-1]
+			})
+
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			if tt.expectedTerm {
+				assert.ErrorContains(t, err, "message terminated")
+			}
+			assert.Equal(t, tt.expectedDelivered, delivered)
+		})
+	}
+
+	t.Run("should dead-letter the message on the last delivery", func(t *testing.T) {
+		sut := &PubSubMessageConsumer{cfg: PubSubMessageConsumerConfig{
+			MaxDeliveries: 2,
+			FailurePolicy: DefaultFailurePolicy(retry.ConstantStrategy{}),
+		}}
+
+		store := messaging.NewInMemoryDeadLetterStore()
+		handler := messaging.DeadLetterMiddleware(store,
+			messaging.ConfigureDeadLetterMaxDeliveries(3),
+			messaging.ConfigureDeadLetterBackoff(retry.ConstantStrategy{}),
+		)(messaging.MessageHandlerFn[messaging.Message](func(context.Context, messaging.Message) error {
+			return errHandle
+		}))
+
+		msg := messaging.NewBaseCommand("payment.charge", messaging.WithID("msg-1"))
+		err := sut.handleWithPolicy(context.Background(), nm, func(ctx context.Context) error {
+			return handler.Handle(ctx, msg)
+		})
+		require.NoError(t, err)
+
+		letters, err := store.DeadLetters(context.Background())
+		require.NoError(t, err)
+		require.Len(t, letters, 1)
+		assert.Equal(t, 3, letters[0].Attempts)
+	})
+
+	t.Run("should stop waiting to redeliver the message when the context is done", func(t *testing.T) {
+		sut := &PubSubMessageConsumer{cfg: PubSubMessageConsumerConfig{
+			MaxDeliveries: 3,
+			FailurePolicy: DefaultFailurePolicy(retry.ConstantStrategy{Delay: time.Hour}),
+		}}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		calls := 0
+		err := sut.handleWithPolicy(ctx, nm, func(context.Context) error {
+			calls++
+			return cqrserrors.NewRetryableError(errHandle)
+		})
+		require.ErrorIs(t, err, errHandle)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 1, calls)
+	})
+}
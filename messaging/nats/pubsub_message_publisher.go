@@ -9,10 +9,13 @@ import (
 	"go.opentelemetry.io/otel/propagation"
 )
 
+var _ messaging.Shutdowner = (*PubSubMessagePublisher)(nil)
+
 // PubSubMessagePublisher is a publisher that uses NATS JetStream.
 type PubSubMessagePublisher struct {
 	conn *nats.Conn
 	cfg  PubSubMessagePublisherConfig
+	gate shutdownGate
 }
 
 func NewPubSubMessagePublisher(
@@ -31,6 +34,11 @@ func NewPubSubMessagePublisher(
 
 // Publish implements messaging.MessageBus.
 func (p *PubSubMessagePublisher) Publish(ctx context.Context, messages ...messaging.Message) error {
+	if !p.gate.enter() {
+		return messaging.ErrPublishOnClosedBus
+	}
+	defer p.gate.leave()
+
 	for _, msg := range messages {
 		data, err := p.cfg.Serializer.Serialize(msg)
 		if err != nil {
@@ -64,6 +72,11 @@ func (p *PubSubMessagePublisher) Publish(ctx context.Context, messages ...messag
 
 // PublishRequest sends a request message and waits for a single reply.
 func (p *PubSubMessagePublisher) PublishRequest(ctx context.Context, msg messaging.Message) (messaging.Message, error) {
+	if !p.gate.enter() {
+		return nil, messaging.ErrPublishOnClosedBus
+	}
+	defer p.gate.leave()
+
 	msgSubject := p.cfg.SubjectBuilder.Build(msg)
 	if msgSubject == "" {
 		return nil, fmt.Errorf("no subject configured for message type '%s'", msg.MessageType())
@@ -117,3 +130,11 @@ func (p *PubSubMessagePublisher) PublishRequest(ctx context.Context, msg messagi
 
 	return replyMsg, nil
 }
+
+// Shutdown rejects the new publishes and waits for the ones in progress until the context is done.
+func (p *PubSubMessagePublisher) Shutdown(ctx context.Context) error {
+	if _, err := p.gate.close(ctx); err != nil {
+		return messaging.UndeliveredMessagesError{Err: err}
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 
 // Ensure PubSubMessageBus implements the MessageBus interface.
 var _ messaging.QueryBus = (*PubSubQueryBus)(nil)
+var _ messaging.Shutdowner = (*PubSubQueryBus)(nil)
 
 // PubSubMessageBus is a NATS-based implementation of the MessageBus interface.
 // It provides methods for publishing and subscribing to messages using NATS as the underlying message bus.
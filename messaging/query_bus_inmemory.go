@@ -6,6 +6,7 @@ import (
 )
 
 var _ QueryBus = (*InMemoryQueryBus)(nil)
+var _ Shutdowner = (*InMemoryQueryBus)(nil)
 
 // InMemoryQueryBus is an in-memory implementation of QueryBus.
 type InMemoryQueryBus struct {
@@ -42,3 +43,8 @@ func (b *InMemoryQueryBus) Use(mws ...MessageHandlerMiddleware) {
 func (b *InMemoryQueryBus) Close() error {
 	return b.bus.Close()
 }
+
+// Shutdown shuts down the underlying message bus gracefully. See InMemoryMessageBus.Shutdown.
+func (b *InMemoryQueryBus) Shutdown(ctx context.Context) error {
+	return b.bus.Shutdown(ctx)
+}
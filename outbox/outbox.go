@@ -0,0 +1,83 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xfrr/go-cqrsify/messaging"
+)
+
+var _ messaging.MessagePublisher = (*Outbox)(nil)
+
+var ErrNilStore = errors.New("outbox store is nil")
+
+// Config configures an Outbox.
+type Config struct {
+	// Serializer serializes the messages. Defaults to messaging.DefaultJSONSerializer.
+	Serializer messaging.MessageSerializer
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Outbox appends messages to an outbox Store.
+//
+// It implements messaging.MessagePublisher, so it can replace the message bus
+// publisher of the code running inside a unit of work: bind it to a store
+// scoped to the unit of work transaction and the messages are only relayed
+// if the transaction commits.
+type Outbox struct {
+	store Store
+	cfg   Config
+}
+
+// New creates a new Outbox writing into the given store.
+func New(store Store, cfg Config) *Outbox {
+	if cfg.Serializer == nil {
+		cfg.Serializer = messaging.DefaultJSONSerializer
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	return &Outbox{store: store, cfg: cfg}
+}
+
+// Publish implements messaging.MessagePublisher. It is an alias of Add.
+func (o *Outbox) Publish(ctx context.Context, messages ...messaging.Message) error {
+	return o.Add(ctx, messages...)
+}
+
+// Add serializes and appends the given messages to the outbox.
+func (o *Outbox) Add(ctx context.Context, messages ...messaging.Message) error {
+	if o.store == nil {
+		return ErrNilStore
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	now := o.cfg.Now().UTC()
+	records := make([]Record, len(messages))
+	for i, msg := range messages {
+		payload, err := o.cfg.Serializer.Serialize(msg)
+		if err != nil {
+			return fmt.Errorf("outbox: could not serialize message %s: %w", msg.MessageType(), err)
+		}
+
+		records[i] = Record{
+			MessageID:     msg.MessageID(),
+			MessageType:   msg.MessageType(),
+			Payload:       payload,
+			Status:        StatusPending,
+			CreatedAt:     now,
+			NextAttemptAt: now,
+		}
+	}
+
+	if err := o.store.Append(ctx, records...); err != nil {
+		return fmt.Errorf("outbox: could not append messages: %w", err)
+	}
+	return nil
+}
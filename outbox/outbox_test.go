@@ -0,0 +1,96 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xfrr/go-cqrsify/messaging"
+	"github.com/xfrr/go-cqrsify/outbox"
+	"github.com/xfrr/go-cqrsify/uow"
+)
+
+type repos struct {
+	Outbox *outbox.Outbox
+}
+
+func newUnitOfWork(store *outbox.InMemoryStore) *uow.UnitOfWork[repos] {
+	return uow.New(
+		store,
+		repos{Outbox: outbox.New(store, outbox.Config{})},
+		func(tx uow.Tx) repos { return repos{Outbox: outbox.New(store.WithTx(tx), outbox.Config{})} },
+		uow.Config{},
+	)
+}
+
+func TestOutbox_Add(t *testing.T) {
+	ctx := context.Background()
+	store := outbox.NewInMemoryStore()
+	sut := outbox.New(store, outbox.Config{})
+
+	require.NoError(t, sut.Publish(ctx,
+		messaging.NewBaseEvent("order.placed", messaging.WithID("msg-1")),
+		messaging.NewBaseEvent("order.paid", messaging.WithID("msg-2")),
+	))
+
+	records := store.Records()
+	require.Len(t, records, 2)
+	assert.Equal(t, int64(1), records[0].ID)
+	assert.Equal(t, "msg-1", records[0].MessageID)
+	assert.Equal(t, "order.placed", records[0].MessageType)
+	assert.Equal(t, outbox.StatusPending, records[0].Status)
+	assert.NotEmpty(t, records[0].Payload)
+
+	msg, err := messaging.DefaultJSONDeserializer.Deserialize(records[1].Payload)
+	require.NoError(t, err)
+	assert.Equal(t, "msg-2", msg.MessageID())
+	assert.Equal(t, "order.paid", msg.MessageType())
+}
+
+func TestOutbox_UnitOfWork(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should store the messages when the transaction commits", func(t *testing.T) {
+		store := outbox.NewInMemoryStore()
+		sut := newUnitOfWork(store)
+
+		err := sut.Do(ctx, func(ctx context.Context, r repos) error {
+			require.NoError(t, r.Outbox.Add(ctx, messaging.NewBaseEvent("order.placed", messaging.WithID("msg-1"))))
+			assert.Empty(t, store.Records(), "records must not be visible before commit")
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Len(t, store.Records(), 1)
+	})
+
+	t.Run("should discard the messages when the transaction rolls back", func(t *testing.T) {
+		store := outbox.NewInMemoryStore()
+		sut := newUnitOfWork(store)
+		errSave := errors.New("could not save aggregate")
+
+		err := sut.Do(ctx, func(ctx context.Context, r repos) error {
+			require.NoError(t, r.Outbox.Add(ctx, messaging.NewBaseEvent("order.placed", messaging.WithID("msg-1"))))
+			return errSave
+		})
+		require.ErrorIs(t, err, errSave)
+		assert.Empty(t, store.Records())
+	})
+
+	t.Run("should reject appends after the transaction is done", func(t *testing.T) {
+		store := outbox.NewInMemoryStore()
+		tx, err := store.Begin(ctx)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit(ctx))
+
+		err = store.WithTx(tx).Append(ctx, outbox.Record{MessageType: "order.placed"})
+		require.ErrorIs(t, err, outbox.ErrTxDone)
+
+		other, err := outbox.NewInMemoryStore().Begin(ctx)
+		require.NoError(t, err)
+		err = store.WithTx(other).Append(ctx, outbox.Record{MessageType: "order.placed"})
+		require.ErrorIs(t, err, outbox.ErrInvalidTx)
+	})
+}
@@ -0,0 +1,249 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	cqrserrors "github.com/xfrr/go-cqrsify/errors"
+	"github.com/xfrr/go-cqrsify/messaging"
+	"github.com/xfrr/go-cqrsify/pkg/retry"
+)
+
+const (
+	defaultBatchSize    = 100
+	defaultPollInterval = time.Second
+)
+
+var ErrNilPublisher = errors.New("outbox publisher is nil")
+
+// RelayConfig configures a Relay.
+type RelayConfig struct {
+	// BatchSize is the maximum number of records relayed on each iteration.
+	// If zero or negative, a default of 100 is used.
+	BatchSize int
+	// PollInterval is the interval at which the store is polled for pending records.
+	// If zero or negative, a default of 1s is used.
+	PollInterval time.Duration
+	// Deserializer deserializes the stored messages. Defaults to messaging.DefaultJSONDeserializer.
+	Deserializer messaging.MessageDeserializer
+	// Retry configures the immediate publish retries of each record.
+	// If Retry.MaxAttempts is zero, 3 attempts with exponential backoff are made.
+	// Permanent errors (see errors.IsPermanent) are never retried.
+	Retry retry.Options
+	// Backoff computes the delay before relaying again a record whose publish retries were exhausted,
+	// given the number of previous failed relays. Defaults to an exponential backoff from 1s to 5m.
+	Backoff retry.Strategy
+	// MaxDeliveryAttempts is the number of failed relays after which a record is marked as dead.
+	// Zero means unlimited.
+	MaxDeliveryAttempts int
+	// Retention is the time delivered records are kept before being purged.
+	// Zero disables purging.
+	Retention time.Duration
+	// Hooks are the relay lifecycle hooks.
+	Hooks RelayHooks
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// RelayHooks allow observing the relay without coupling it to logging or metrics providers.
+type RelayHooks struct {
+	// OnDelivered is called after a record has been published.
+	OnDelivered func(context.Context, Record)
+	// OnFailed is called when a record could not be published. dead reports whether it will be retried.
+	OnFailed func(ctx context.Context, record Record, err error, dead bool)
+	// OnError is called when the relay loop fails to access the store.
+	OnError func(context.Context, error)
+}
+
+// Relay publishes the pending outbox records and marks them as delivered.
+//
+// Records are published at least once: a crash between publishing and marking
+// a record as delivered makes it to be published again, so consumers must be idempotent.
+// A failing record does not block the following ones, so the publish order is only
+// guaranteed while deliveries succeed.
+type Relay struct {
+	store     Store
+	publisher messaging.MessagePublisher
+	retrier   *retry.Retrier
+	cfg       RelayConfig
+	notifyCh  chan struct{}
+}
+
+// NewRelay creates a new Relay publishing the records of the given store.
+func NewRelay(store Store, publisher messaging.MessagePublisher, cfg RelayConfig) *Relay {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.Deserializer == nil {
+		cfg.Deserializer = messaging.DefaultJSONDeserializer
+	}
+	if cfg.Retry.MaxAttempts == 0 {
+		cfg.Retry.MaxAttempts = 3
+		if cfg.Retry.Strategy == nil {
+			cfg.Retry.Strategy = retry.ExponentialStrategy{Base: 50 * time.Millisecond, Factor: 2, Cap: time.Second}
+		}
+	}
+	if cfg.Retry.Classifier == nil {
+		cfg.Retry.Classifier = retry.RetryOn{Predicate: func(err error) bool { return !cqrserrors.IsPermanent(err) }}
+	}
+	if cfg.Backoff == nil {
+		cfg.Backoff = retry.ExponentialStrategy{Base: time.Second, Factor: 2, Cap: 5 * time.Minute}
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		retrier:   retry.New(cfg.Retry),
+		cfg:       cfg,
+		notifyCh:  make(chan struct{}, 1),
+	}
+}
+
+// Run relays the pending records until the context is done.
+// Store errors are reported through the OnError hook and do not stop the relay.
+func (r *Relay) Run(ctx context.Context) error {
+	if r.store == nil {
+		return ErrNilStore
+	}
+	if r.publisher == nil {
+		return ErrNilPublisher
+	}
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := r.RelayPending(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if r.cfg.Hooks.OnError != nil {
+					r.cfg.Hooks.OnError(ctx, err)
+				}
+				break
+			}
+			if n < r.cfg.BatchSize {
+				break
+			}
+		}
+
+		if err := r.purge(ctx); err != nil && r.cfg.Hooks.OnError != nil {
+			r.cfg.Hooks.OnError(ctx, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-r.notifyCh:
+		}
+	}
+}
+
+// Notify wakes up the relay so new records are relayed without waiting for the next poll.
+func (r *Relay) Notify() {
+	select {
+	case r.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+// RelayPending relays a single batch of pending records.
+// It returns the number of records fetched from the store.
+func (r *Relay) RelayPending(ctx context.Context) (int, error) {
+	records, err := r.store.Pending(ctx, r.cfg.Now().UTC(), r.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("outbox: could not fetch pending records: %w", err)
+	}
+
+	delivered := make([]Record, 0, len(records))
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			break
+		}
+
+		if err := r.relay(ctx, record); err != nil {
+			if markErr := r.fail(ctx, record, err); markErr != nil {
+				return len(records), markErr
+			}
+			continue
+		}
+		delivered = append(delivered, record)
+	}
+
+	if len(delivered) == 0 {
+		return len(records), nil
+	}
+
+	ids := make([]int64, len(delivered))
+	for i, record := range delivered {
+		ids[i] = record.ID
+	}
+	if err := r.store.MarkDelivered(ctx, r.cfg.Now().UTC(), ids...); err != nil {
+		return len(records), fmt.Errorf("outbox: could not mark records as delivered: %w", err)
+	}
+
+	if r.cfg.Hooks.OnDelivered != nil {
+		for _, record := range delivered {
+			r.cfg.Hooks.OnDelivered(ctx, record)
+		}
+	}
+
+	return len(records), nil
+}
+
+func (r *Relay) relay(ctx context.Context, record Record) error {
+	msg, err := r.cfg.Deserializer.Deserialize(record.Payload)
+	if err != nil {
+		return cqrserrors.NewPermanentError(fmt.Errorf("could not deserialize message: %w", err))
+	}
+
+	return r.retrier.Do(ctx, func(ctx context.Context) error {
+		return r.publisher.Publish(ctx, msg)
+	})
+}
+
+func (r *Relay) fail(ctx context.Context, record Record, err error) error {
+	attempts := record.Attempts + 1
+	dead := cqrserrors.IsPermanent(err) ||
+		(r.cfg.MaxDeliveryAttempts > 0 && attempts >= r.cfg.MaxDeliveryAttempts)
+
+	var markErr error
+	if dead {
+		markErr = r.store.MarkDead(ctx, record.ID, err.Error())
+	} else {
+		retryAt := r.cfg.Now().UTC().Add(r.cfg.Backoff.NextDelay(record.Attempts, err))
+		markErr = r.store.MarkFailed(ctx, record.ID, err.Error(), retryAt)
+	}
+	if markErr != nil {
+		return fmt.Errorf("outbox: could not mark record %d as failed: %w", record.ID, markErr)
+	}
+
+	if r.cfg.Hooks.OnFailed != nil {
+		record.Attempts = attempts
+		record.LastError = err.Error()
+		r.cfg.Hooks.OnFailed(ctx, record, err, dead)
+	}
+	return nil
+}
+
+func (r *Relay) purge(ctx context.Context) error {
+	if r.cfg.Retention <= 0 {
+		return nil
+	}
+
+	if _, err := r.store.PurgeDelivered(ctx, r.cfg.Now().UTC().Add(-r.cfg.Retention)); err != nil {
+		return fmt.Errorf("outbox: could not purge delivered records: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cqrserrors "github.com/xfrr/go-cqrsify/errors"
+	"github.com/xfrr/go-cqrsify/messaging"
+	messagingmock "github.com/xfrr/go-cqrsify/messaging/mock"
+	"github.com/xfrr/go-cqrsify/outbox"
+	"github.com/xfrr/go-cqrsify/pkg/retry"
+)
+
+type noSleep struct{}
+
+func (noSleep) Sleep(context.Context, time.Duration) error { return nil }
+func (noSleep) Now() time.Time                             { return time.Now() }
+
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newStoreWith(t *testing.T, messageTypes ...string) *outbox.InMemoryStore {
+	t.Helper()
+
+	store := outbox.NewInMemoryStore()
+	ob := outbox.New(store, outbox.Config{Now: func() time.Time { return time.Unix(0, 0) }})
+	for _, msgType := range messageTypes {
+		require.NoError(t, ob.Add(context.Background(), messaging.NewBaseEvent(msgType, messaging.WithID(msgType))))
+	}
+	return store
+}
+
+func recordingPublisher(fail func(msg messaging.Message, call int) error) (*messagingmock.MessagePublisher, func() []string) {
+	var (
+		mu        sync.Mutex
+		published []string
+		calls     = map[string]int{}
+	)
+
+	publisher := &messagingmock.MessagePublisher{
+		PublishFunc: func(_ context.Context, messages ...messaging.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, msg := range messages {
+				calls[msg.MessageID()]++
+				if fail != nil {
+					if err := fail(msg, calls[msg.MessageID()]); err != nil {
+						return err
+					}
+				}
+				published = append(published, msg.MessageType())
+			}
+			return nil
+		},
+	}
+
+	return publisher, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), published...)
+	}
+}
+
+func TestRelay_RelayPending(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should publish and mark the records as delivered", func(t *testing.T) {
+		store := newStoreWith(t, "order.placed", "order.paid", "order.shipped")
+		publisher, published := recordingPublisher(nil)
+
+		var delivered []int64
+		sut := outbox.NewRelay(store, publisher, outbox.RelayConfig{
+			BatchSize: 2,
+			Hooks: outbox.RelayHooks{
+				OnDelivered: func(_ context.Context, r outbox.Record) { delivered = append(delivered, r.ID) },
+			},
+		})
+
+		n, err := sut.RelayPending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		n, err = sut.RelayPending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		n, err = sut.RelayPending(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		assert.Equal(t, []string{"order.placed", "order.paid", "order.shipped"}, published())
+		assert.Equal(t, []int64{1, 2, 3}, delivered)
+		for _, record := range store.Records() {
+			assert.Equal(t, outbox.StatusDelivered, record.Status)
+			assert.False(t, record.DeliveredAt.IsZero())
+		}
+	})
+
+	t.Run("should retry the publish before failing the record", func(t *testing.T) {
+		store := newStoreWith(t, "order.placed")
+		publisher, published := recordingPublisher(func(_ messaging.Message, call int) error {
+			if call < 3 {
+				return errors.New("broker unavailable")
+			}
+			return nil
+		})
+
+		sut := outbox.NewRelay(store, publisher, outbox.RelayConfig{
+			Retry: retry.Options{MaxAttempts: 3, Sleeper: noSleep{}},
+		})
+
+		_, err := sut.RelayPending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"order.placed"}, published())
+		assert.Equal(t, outbox.StatusDelivered, store.Records()[0].Status)
+	})
+
+	t.Run("should reschedule the failed records with backoff", func(t *testing.T) {
+		store := newStoreWith(t, "order.placed", "order.paid")
+		errBroker := errors.New("broker unavailable")
+		failing := true
+		publisher, published := recordingPublisher(func(msg messaging.Message, _ int) error {
+			if failing && msg.MessageType() == "order.placed" {
+				return errBroker
+			}
+			return nil
+		})
+
+		c := &clock{now: time.Unix(100, 0)}
+		var failures []error
+		sut := outbox.NewRelay(store, publisher, outbox.RelayConfig{
+			Retry:   retry.Options{MaxAttempts: 1},
+			Backoff: retry.ConstantStrategy{Delay: time.Minute},
+			Now:     c.Now,
+			Hooks: outbox.RelayHooks{
+				OnFailed: func(_ context.Context, _ outbox.Record, err error, dead bool) {
+					assert.False(t, dead)
+					failures = append(failures, err)
+				},
+			},
+		})
+
+		_, err := sut.RelayPending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"order.paid"}, published(), "a failing record must not block the next ones")
+		require.Len(t, failures, 1)
+		require.ErrorIs(t, failures[0], errBroker)
+
+		record := store.Records()[0]
+		assert.Equal(t, outbox.StatusPending, record.Status)
+		assert.Equal(t, 1, record.Attempts)
+		assert.Contains(t, record.LastError, errBroker.Error())
+		assert.True(t, c.Now().Add(time.Minute).Equal(record.NextAttemptAt))
+
+		n, err := sut.RelayPending(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n, "the record must wait for its backoff")
+
+		failing = false
+		c.Advance(time.Minute)
+		n, err = sut.RelayPending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, []string{"order.paid", "order.placed"}, published())
+	})
+
+	t.Run("should mark the records as dead", func(t *testing.T) {
+		store := newStoreWith(t, "order.placed", "order.paid")
+		publisher, _ := recordingPublisher(func(msg messaging.Message, _ int) error {
+			if msg.MessageType() == "order.paid" {
+				return cqrserrors.NewPermanentError(errors.New("invalid subject"))
+			}
+			return errors.New("broker unavailable")
+		})
+
+		var dead []string
+		sut := outbox.NewRelay(store, publisher, outbox.RelayConfig{
+			Retry:               retry.Options{MaxAttempts: 1},
+			Backoff:             retry.ConstantStrategy{},
+			MaxDeliveryAttempts: 2,
+			Hooks: outbox.RelayHooks{
+				OnFailed: func(_ context.Context, r outbox.Record, _ error, isDead bool) {
+					if isDead {
+						dead = append(dead, r.MessageType)
+					}
+				},
+			},
+		})
+
+		_, err := sut.RelayPending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"order.paid"}, dead, "permanent errors must not be retried")
+
+		_, err = sut.RelayPending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"order.paid", "order.placed"}, dead)
+
+		for _, record := range store.Records() {
+			assert.Equal(t, outbox.StatusDead, record.Status)
+		}
+
+		n, err := sut.RelayPending(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+}
+
+func TestRelay_Run(t *testing.T) {
+	store := outbox.NewInMemoryStore()
+	publisher, published := recordingPublisher(nil)
+
+	sut := outbox.NewRelay(store, publisher, outbox.RelayConfig{
+		PollInterval: time.Hour,
+		Retention:    time.Nanosecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- sut.Run(ctx) }()
+
+	ob := outbox.New(store, outbox.Config{})
+	require.NoError(t, ob.Add(ctx, messaging.NewBaseEvent("order.placed", messaging.WithID("msg-1"))))
+	sut.Notify()
+
+	require.Eventually(t, func() bool { return len(published()) == 1 }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, ob.Add(ctx, messaging.NewBaseEvent("order.paid", messaging.WithID("msg-2"))))
+	sut.Notify()
+	require.Eventually(t, func() bool {
+		return len(published()) == 2 && len(store.Records()) == 0
+	}, time.Second, 5*time.Millisecond, "delivered records must be purged after retention")
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,77 @@
+package sqloutbox
+
+import "fmt"
+
+// Dialect abstracts the SQL differences between database engines.
+type Dialect interface {
+	// Name returns the dialect name.
+	Name() string
+	// Placeholder returns the bind parameter placeholder for the given 1-based position.
+	Placeholder(n int) string
+	// MigrationStatements returns the statements creating the outbox table and its indexes.
+	MigrationStatements(table string) []string
+	// ClaimClause returns the clause appended to the pending records query so that the rows
+	// being claimed by a transaction are skipped by the others. It returns an empty string
+	// when the engine already serializes writers.
+	ClaimClause() string
+}
+
+type postgresDialect struct{}
+
+// PostgreSQL returns the PostgreSQL dialect.
+func PostgreSQL() Dialect { return postgresDialect{} }
+
+func (postgresDialect) Name() string { return "postgres" }
+
+func (postgresDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+func (postgresDialect) MigrationStatements(table string) []string {
+	return []string{
+		`CREATE TABLE IF NOT EXISTS ` + table + ` (
+	id BIGSERIAL PRIMARY KEY,
+	message_id TEXT NOT NULL,
+	message_type TEXT NOT NULL,
+	payload BYTEA NOT NULL,
+	status TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL,
+	next_attempt_at TIMESTAMPTZ NOT NULL,
+	delivered_at TIMESTAMPTZ
+)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_pending_idx ON ` + table + ` (status, next_attempt_at)`,
+	}
+}
+
+func (postgresDialect) ClaimClause() string { return " FOR UPDATE SKIP LOCKED" }
+
+type sqliteDialect struct{}
+
+// SQLite returns the SQLite dialect.
+func SQLite() Dialect { return sqliteDialect{} }
+
+func (sqliteDialect) Name() string { return "sqlite" }
+
+func (sqliteDialect) Placeholder(_ int) string { return "?" }
+
+func (sqliteDialect) MigrationStatements(table string) []string {
+	return []string{
+		`CREATE TABLE IF NOT EXISTS ` + table + ` (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	message_id TEXT NOT NULL,
+	message_type TEXT NOT NULL,
+	payload BLOB NOT NULL,
+	status TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL,
+	next_attempt_at TIMESTAMP NOT NULL,
+	delivered_at TIMESTAMP
+)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_pending_idx ON ` + table + ` (status, next_attempt_at)`,
+	}
+}
+
+// ClaimClause returns an empty string: SQLite allows a single writer at a time,
+// so the claims are serialized by their transactions.
+func (sqliteDialect) ClaimClause() string { return "" }
@@ -0,0 +1,324 @@
+// Package sqloutbox provides a database/sql implementation of outbox.Store.
+package sqloutbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xfrr/go-cqrsify/outbox"
+)
+
+const (
+	defaultTableName    = "outbox"
+	defaultClaimTimeout = time.Minute
+)
+
+var (
+	_ outbox.Store = (*Store)(nil)
+	_ querier      = (*sql.DB)(nil)
+	_ querier      = (*sql.Tx)(nil)
+)
+
+var ErrNilDialect = errors.New("sql outbox dialect is nil")
+
+// querier is the subset of methods shared by *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+type Config struct {
+	// Dialect is the SQL dialect of the database. Required.
+	Dialect Dialect
+	// TableName is the name of the outbox table. Defaults to "outbox".
+	TableName string
+	// ClaimTimeout is how long the records returned by Pending are hidden from the other
+	// relays. Records neither marked delivered nor failed in time, e.g. because the relay
+	// crashed, are returned again afterwards. Defaults to 1 minute.
+	ClaimTimeout time.Duration
+}
+
+// Store is a database/sql implementation of outbox.Store.
+//
+// Bind it to the transaction of a uow.UnitOfWork with WithTx, so the
+// outbox records are committed atomically with the aggregate changes.
+type Store struct {
+	db  *sql.DB
+	tx  *sql.Tx
+	cfg Config
+}
+
+// NewStore creates a new Store for the given database.
+func NewStore(db *sql.DB, cfg Config) *Store {
+	if cfg.TableName == "" {
+		cfg.TableName = defaultTableName
+	}
+	if cfg.ClaimTimeout <= 0 {
+		cfg.ClaimTimeout = defaultClaimTimeout
+	}
+
+	return &Store{db: db, cfg: cfg}
+}
+
+// WithTx returns a copy of the store bound to the given transaction.
+// It allows the store to take part in a uow.UnitOfWork, e.g. binding
+// the *sql.Tx unwrapped from the uow/postgres transaction.
+func (s *Store) WithTx(tx *sql.Tx) *Store {
+	return &Store{db: s.db, tx: tx, cfg: s.cfg}
+}
+
+// Migrate creates the outbox table and its indexes if they do not exist.
+func (s *Store) Migrate(ctx context.Context) error {
+	if s.cfg.Dialect == nil {
+		return ErrNilDialect
+	}
+
+	for _, stmt := range s.cfg.Dialect.MigrationStatements(s.cfg.TableName) {
+		if _, err := s.querier().ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("could not migrate outbox table: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) Append(ctx context.Context, records ...outbox.Record) error {
+	if s.cfg.Dialect == nil {
+		return ErrNilDialect
+	}
+
+	d := s.cfg.Dialect
+	stmt := fmt.Sprintf(
+		"INSERT INTO %s (message_id, message_type, payload, status, created_at, next_attempt_at) VALUES (%s, %s, %s, %s, %s, %s)",
+		s.cfg.TableName,
+		d.Placeholder(1), d.Placeholder(2), d.Placeholder(3),
+		d.Placeholder(4), d.Placeholder(5), d.Placeholder(6),
+	)
+
+	for _, record := range records {
+		status := record.Status
+		if status == "" {
+			status = outbox.StatusPending
+		}
+
+		_, err := s.querier().ExecContext(ctx, stmt,
+			record.MessageID,
+			record.MessageType,
+			record.Payload,
+			string(status),
+			record.CreatedAt.UTC(),
+			record.NextAttemptAt.UTC(),
+		)
+		if err != nil {
+			return fmt.Errorf("could not insert outbox record: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Pending claims and returns up to limit pending records ready to be relayed at now.
+// The records are claimed in a transaction, rescheduling them after Config.ClaimTimeout,
+// so that concurrent relays sharing the table never publish the same record twice.
+func (s *Store) Pending(ctx context.Context, now time.Time, limit int) ([]outbox.Record, error) {
+	if s.cfg.Dialect == nil {
+		return nil, ErrNilDialect
+	}
+
+	var records []outbox.Record
+	err := s.inTx(ctx, func(q querier) error {
+		var err error
+		records, err = s.selectPending(ctx, q, now, limit)
+		if err != nil {
+			return err
+		}
+		return s.claim(ctx, q, now.Add(s.cfg.ClaimTimeout), records)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *Store) selectPending(ctx context.Context, q querier, now time.Time, limit int) ([]outbox.Record, error) {
+	d := s.cfg.Dialect
+	query := fmt.Sprintf(
+		"SELECT id, message_id, message_type, payload, status, attempts, last_error, created_at, next_attempt_at, delivered_at FROM %s WHERE status = %s AND next_attempt_at <= %s ORDER BY id",
+		s.cfg.TableName, d.Placeholder(1), d.Placeholder(2),
+	)
+	args := []any{string(outbox.StatusPending), now.UTC()}
+	if limit > 0 {
+		query += " LIMIT " + d.Placeholder(3)
+		args = append(args, limit)
+	}
+	query += d.ClaimClause()
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query outbox records: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]outbox.Record, 0)
+	for rows.Next() {
+		var (
+			record      outbox.Record
+			status      string
+			deliveredAt sql.NullTime
+		)
+
+		err := rows.Scan(
+			&record.ID, &record.MessageID, &record.MessageType, &record.Payload, &status,
+			&record.Attempts, &record.LastError, &record.CreatedAt, &record.NextAttemptAt, &deliveredAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan outbox record: %w", err)
+		}
+
+		record.Status = outbox.Status(status)
+		record.DeliveredAt = deliveredAt.Time
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read outbox records: %w", err)
+	}
+
+	return records, nil
+}
+
+// claim reschedules the given records at claimedUntil, hiding them from the other relays.
+func (s *Store) claim(ctx context.Context, q querier, claimedUntil time.Time, records []outbox.Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	d := s.cfg.Dialect
+	args := []any{claimedUntil.UTC()}
+	placeholders := make([]string, len(records))
+	for i, record := range records {
+		args = append(args, record.ID)
+		placeholders[i] = d.Placeholder(len(args))
+	}
+
+	stmt := fmt.Sprintf(
+		"UPDATE %s SET next_attempt_at = %s WHERE id IN (%s)",
+		s.cfg.TableName, d.Placeholder(1), strings.Join(placeholders, ", "),
+	)
+	if _, err := q.ExecContext(ctx, stmt, args...); err != nil {
+		return fmt.Errorf("could not claim outbox records: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) MarkDelivered(ctx context.Context, deliveredAt time.Time, ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if s.cfg.Dialect == nil {
+		return ErrNilDialect
+	}
+
+	d := s.cfg.Dialect
+	args := []any{string(outbox.StatusDelivered), deliveredAt.UTC()}
+	placeholders := make([]string, len(ids))
+	for i, id := range ids {
+		args = append(args, id)
+		placeholders[i] = d.Placeholder(len(args))
+	}
+
+	stmt := fmt.Sprintf(
+		"UPDATE %s SET status = %s, delivered_at = %s WHERE id IN (%s)",
+		s.cfg.TableName, d.Placeholder(1), d.Placeholder(2), strings.Join(placeholders, ", "),
+	)
+	if _, err := s.querier().ExecContext(ctx, stmt, args...); err != nil {
+		return fmt.Errorf("could not mark outbox records as delivered: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) MarkFailed(ctx context.Context, id int64, cause string, retryAt time.Time) error {
+	if s.cfg.Dialect == nil {
+		return ErrNilDialect
+	}
+
+	d := s.cfg.Dialect
+	stmt := fmt.Sprintf(
+		"UPDATE %s SET attempts = attempts + 1, last_error = %s, next_attempt_at = %s WHERE id = %s",
+		s.cfg.TableName, d.Placeholder(1), d.Placeholder(2), d.Placeholder(3),
+	)
+	if _, err := s.querier().ExecContext(ctx, stmt, cause, retryAt.UTC(), id); err != nil {
+		return fmt.Errorf("could not mark outbox record as failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) MarkDead(ctx context.Context, id int64, cause string) error {
+	if s.cfg.Dialect == nil {
+		return ErrNilDialect
+	}
+
+	d := s.cfg.Dialect
+	stmt := fmt.Sprintf(
+		"UPDATE %s SET attempts = attempts + 1, last_error = %s, status = %s WHERE id = %s",
+		s.cfg.TableName, d.Placeholder(1), d.Placeholder(2), d.Placeholder(3),
+	)
+	if _, err := s.querier().ExecContext(ctx, stmt, cause, string(outbox.StatusDead), id); err != nil {
+		return fmt.Errorf("could not mark outbox record as dead: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) PurgeDelivered(ctx context.Context, before time.Time) (int, error) {
+	if s.cfg.Dialect == nil {
+		return 0, ErrNilDialect
+	}
+
+	d := s.cfg.Dialect
+	stmt := fmt.Sprintf(
+		"DELETE FROM %s WHERE status = %s AND delivered_at < %s",
+		s.cfg.TableName, d.Placeholder(1), d.Placeholder(2),
+	)
+	res, err := s.querier().ExecContext(ctx, stmt, string(outbox.StatusDelivered), before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("could not purge outbox records: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("could not purge outbox records: %w", err)
+	}
+	return int(n), nil
+}
+
+// inTx runs fn inside the bound transaction, or inside a new one if the store is not bound.
+func (s *Store) inTx(ctx context.Context, fn func(q querier) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) querier() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
@@ -0,0 +1,178 @@
+package sqloutbox_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xfrr/go-cqrsify/messaging"
+	"github.com/xfrr/go-cqrsify/outbox"
+	"github.com/xfrr/go-cqrsify/outbox/sqloutbox"
+	"github.com/xfrr/go-cqrsify/uow"
+)
+
+var dbCounter atomic.Int64
+
+func newSQLiteStore(t *testing.T) (*sqloutbox.Store, *sql.DB) {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:outbox_%d?mode=memory&cache=shared&_txlock=immediate", dbCounter.Add(1))
+	db, err := sql.Open("sqlite3", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	store := sqloutbox.NewStore(db, sqloutbox.Config{Dialect: sqloutbox.SQLite()})
+	require.NoError(t, store.Migrate(context.Background()))
+	require.NoError(t, store.Migrate(context.Background()), "migration must be idempotent")
+	return store, db
+}
+
+// sqlTx adapts a *sql.Tx to uow.Tx.
+type sqlTx struct{ tx *sql.Tx }
+
+func (t sqlTx) Commit(context.Context) error   { return t.tx.Commit() }
+func (t sqlTx) Rollback(context.Context) error { return t.tx.Rollback() }
+
+type sqlTxManager struct{ db *sql.DB }
+
+func (m sqlTxManager) Begin(ctx context.Context) (uow.Tx, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return sqlTx{tx: tx}, nil
+}
+
+func TestStore_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	sut, _ := newSQLiteStore(t)
+	now := time.Now().UTC()
+
+	require.NoError(t, sut.Append(ctx,
+		outbox.Record{MessageID: "msg-1", MessageType: "order.placed", Payload: []byte("1"), CreatedAt: now, NextAttemptAt: now},
+		outbox.Record{MessageID: "msg-2", MessageType: "order.paid", Payload: []byte("2"), CreatedAt: now, NextAttemptAt: now},
+		outbox.Record{MessageID: "msg-3", MessageType: "order.shipped", Payload: []byte("3"), CreatedAt: now, NextAttemptAt: now.Add(time.Hour)},
+	))
+
+	pending, err := sut.Pending(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "msg-1", pending[0].MessageID)
+	assert.Equal(t, "order.placed", pending[0].MessageType)
+	assert.Equal(t, []byte("1"), pending[0].Payload)
+	assert.Equal(t, outbox.StatusPending, pending[0].Status)
+	assert.True(t, pending[0].DeliveredAt.IsZero())
+
+	claimed, err := sut.Pending(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed, "claimed records must be hidden from the other relays")
+
+	pending, err = sut.Pending(ctx, now.Add(time.Hour), 1)
+	require.NoError(t, err)
+	require.Len(t, pending, 1, "expired claims must be returned again")
+	assert.Equal(t, "msg-1", pending[0].MessageID)
+
+	t.Run("should mark the records as delivered", func(t *testing.T) {
+		require.NoError(t, sut.MarkDelivered(ctx, now, pending[0].ID))
+
+		pending, err := sut.Pending(ctx, now.Add(2*time.Minute), 10)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, "msg-2", pending[0].MessageID)
+	})
+
+	t.Run("should reschedule the failed records", func(t *testing.T) {
+		require.NoError(t, sut.MarkFailed(ctx, 2, "broker unavailable", now.Add(time.Minute)))
+
+		pending, err := sut.Pending(ctx, now, 10)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+
+		pending, err = sut.Pending(ctx, now.Add(time.Minute), 10)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, 1, pending[0].Attempts)
+		assert.Equal(t, "broker unavailable", pending[0].LastError)
+	})
+
+	t.Run("should stop relaying the dead records", func(t *testing.T) {
+		require.NoError(t, sut.MarkDead(ctx, 2, "invalid subject"))
+
+		pending, err := sut.Pending(ctx, now.Add(time.Minute), 10)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("should purge the delivered records", func(t *testing.T) {
+		n, err := sut.PurgeDelivered(ctx, now)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		n, err = sut.PurgeDelivered(ctx, now.Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	})
+}
+
+func TestStore_UnitOfWorkAndRelay(t *testing.T) {
+	ctx := context.Background()
+	store, db := newSQLiteStore(t)
+
+	type repos struct{ Outbox *outbox.Outbox }
+	unit := uow.New(
+		sqlTxManager{db: db},
+		repos{Outbox: outbox.New(store, outbox.Config{})},
+		func(tx uow.Tx) repos {
+			return repos{Outbox: outbox.New(store.WithTx(tx.(sqlTx).tx), outbox.Config{})}
+		},
+		uow.Config{},
+	)
+
+	errRejected := errors.New("rejected")
+	err := unit.Do(ctx, func(ctx context.Context, r repos) error {
+		require.NoError(t, r.Outbox.Add(ctx, messaging.NewBaseEvent("order.cancelled", messaging.WithID("msg-0"))))
+		return errRejected
+	})
+	require.ErrorIs(t, err, errRejected)
+
+	err = unit.Do(ctx, func(ctx context.Context, r repos) error {
+		return r.Outbox.Add(ctx,
+			messaging.NewBaseEvent("order.placed", messaging.WithID("msg-1")),
+			messaging.NewBaseEvent("order.paid", messaging.WithID("msg-2")),
+		)
+	})
+	require.NoError(t, err)
+
+	var published []messaging.Message
+	publisher := messagingPublisherFunc(func(_ context.Context, messages ...messaging.Message) error {
+		published = append(published, messages...)
+		return nil
+	})
+
+	n, err := outbox.NewRelay(store, publisher, outbox.RelayConfig{}).RelayPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	require.Len(t, published, 2, "rolled back messages must not be relayed")
+	assert.Equal(t, "msg-1", published[0].MessageID())
+	assert.Equal(t, "order.placed", published[0].MessageType())
+	assert.Equal(t, "msg-2", published[1].MessageID())
+
+	pending, err := store.Pending(ctx, time.Now(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+type messagingPublisherFunc func(ctx context.Context, messages ...messaging.Message) error
+
+func (f messagingPublisherFunc) Publish(ctx context.Context, messages ...messaging.Message) error {
+	return f(ctx, messages...)
+}
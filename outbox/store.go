@@ -0,0 +1,63 @@
+// Package outbox implements the transactional outbox pattern.
+//
+// Messages are serialized and appended to an outbox Store within the same
+// transaction that persists the aggregate changes. A Relay then polls the pending
+// records, publishes them through a messaging.MessagePublisher and marks them as delivered,
+// so messages are published at least once even if the process crashes after committing.
+package outbox
+
+import (
+	"context"
+	"time"
+)
+
+// Status is the delivery status of an outbox record.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	StatusDead      Status = "dead"
+)
+
+// Record is a serialized message stored in the outbox.
+type Record struct {
+	// ID is the sequence number assigned by the store. Records are relayed in ID order.
+	ID int64
+	// MessageID is the ID of the serialized message.
+	MessageID string
+	// MessageType is the type of the serialized message.
+	MessageType string
+	// Payload is the serialized message.
+	Payload []byte
+	// Status is the delivery status of the record.
+	Status Status
+	// Attempts is the number of failed delivery attempts.
+	Attempts int
+	// LastError is the error of the last failed delivery attempt.
+	LastError string
+	// CreatedAt is the time the record was appended.
+	CreatedAt time.Time
+	// NextAttemptAt is the time after which the record can be relayed.
+	NextAttemptAt time.Time
+	// DeliveredAt is the time the record was delivered.
+	DeliveredAt time.Time
+}
+
+// Store persists the outbox records.
+type Store interface {
+	// Append stores the given records as pending. The store assigns their IDs.
+	Append(ctx context.Context, records ...Record) error
+	// Pending returns up to limit pending records ready to be relayed at now, ordered by ID.
+	// Stores shared by concurrent relays must claim the returned records, so that they are
+	// not returned to another relay until marked or until the claim expires.
+	Pending(ctx context.Context, now time.Time, limit int) ([]Record, error)
+	// MarkDelivered marks the given records as delivered.
+	MarkDelivered(ctx context.Context, deliveredAt time.Time, ids ...int64) error
+	// MarkFailed records a failed delivery attempt and reschedules the record at retryAt.
+	MarkFailed(ctx context.Context, id int64, cause string, retryAt time.Time) error
+	// MarkDead records a failed delivery attempt and stops relaying the record.
+	MarkDead(ctx context.Context, id int64, cause string) error
+	// PurgeDelivered deletes the records delivered before the given time.
+	PurgeDelivered(ctx context.Context, before time.Time) (int, error)
+}
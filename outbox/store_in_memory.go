@@ -0,0 +1,204 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/xfrr/go-cqrsify/uow"
+)
+
+var (
+	_ Store                  = (*InMemoryStore)(nil)
+	_ uow.TransactionManager = (*InMemoryStore)(nil)
+)
+
+var (
+	ErrInvalidTx = errors.New("outbox: transaction does not belong to the store")
+	ErrTxDone    = errors.New("outbox: transaction already committed or rolled back")
+)
+
+// InMemoryStore is an in-memory implementation of Store.
+//
+// It also implements uow.TransactionManager: records appended through the store
+// returned by WithTx are only visible once the transaction commits.
+type InMemoryStore struct {
+	mu      sync.Mutex
+	records []Record
+	seq     int64
+}
+
+// NewInMemoryStore creates a new InMemoryStore.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{}
+}
+
+// Begin starts a new transaction. It implements uow.TransactionManager.
+func (s *InMemoryStore) Begin(_ context.Context) (uow.Tx, error) {
+	return &inMemoryTx{store: s}, nil
+}
+
+// WithTx returns a Store appending the records within the given transaction.
+// The transaction must have been started by Begin.
+func (s *InMemoryStore) WithTx(tx uow.Tx) Store {
+	return &inMemoryTxStore{InMemoryStore: s, tx: tx}
+}
+
+func (s *InMemoryStore) Append(_ context.Context, records ...Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.append(records)
+	return nil
+}
+
+func (s *InMemoryStore) Pending(_ context.Context, now time.Time, limit int) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := make([]Record, 0)
+	for _, record := range s.records {
+		if limit > 0 && len(pending) >= limit {
+			break
+		}
+		if record.Status == StatusPending && !record.NextAttemptAt.After(now) {
+			pending = append(pending, record)
+		}
+	}
+	return pending, nil
+}
+
+func (s *InMemoryStore) MarkDelivered(_ context.Context, deliveredAt time.Time, ids ...int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.records {
+		if slices.Contains(ids, s.records[i].ID) {
+			s.records[i].Status = StatusDelivered
+			s.records[i].DeliveredAt = deliveredAt
+		}
+	}
+	return nil
+}
+
+func (s *InMemoryStore) MarkFailed(_ context.Context, id int64, cause string, retryAt time.Time) error {
+	s.update(id, func(record *Record) {
+		record.Attempts++
+		record.LastError = cause
+		record.NextAttemptAt = retryAt
+	})
+	return nil
+}
+
+func (s *InMemoryStore) MarkDead(_ context.Context, id int64, cause string) error {
+	s.update(id, func(record *Record) {
+		record.Attempts++
+		record.LastError = cause
+		record.Status = StatusDead
+	})
+	return nil
+}
+
+func (s *InMemoryStore) PurgeDelivered(_ context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.records)
+	s.records = slices.DeleteFunc(s.records, func(record Record) bool {
+		return record.Status == StatusDelivered && record.DeliveredAt.Before(before)
+	})
+	return n - len(s.records), nil
+}
+
+// Records returns a copy of all the records of the store, in ID order.
+func (s *InMemoryStore) Records() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.records)
+}
+
+func (s *InMemoryStore) append(records []Record) {
+	for _, record := range records {
+		s.seq++
+		record.ID = s.seq
+		if record.Status == "" {
+			record.Status = StatusPending
+		}
+		s.records = append(s.records, record)
+	}
+}
+
+func (s *InMemoryStore) update(id int64, fn func(*Record)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.records {
+		if s.records[i].ID == id {
+			fn(&s.records[i])
+			return
+		}
+	}
+}
+
+// inMemoryTx stages the records appended within a transaction.
+type inMemoryTx struct {
+	store  *InMemoryStore
+	mu     sync.Mutex
+	staged []Record
+	done   bool
+}
+
+func (tx *inMemoryTx) Commit(_ context.Context) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+
+	tx.store.mu.Lock()
+	defer tx.store.mu.Unlock()
+
+	tx.store.append(tx.staged)
+	tx.staged = nil
+	return nil
+}
+
+func (tx *inMemoryTx) Rollback(_ context.Context) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	tx.staged = nil
+	return nil
+}
+
+// inMemoryTxStore is an InMemoryStore scoped to a transaction.
+type inMemoryTxStore struct {
+	*InMemoryStore
+
+	tx uow.Tx
+}
+
+func (s *inMemoryTxStore) Append(_ context.Context, records ...Record) error {
+	tx, ok := s.tx.(*inMemoryTx)
+	if !ok || tx.store != s.InMemoryStore {
+		return ErrInvalidTx
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.staged = append(tx.staged, records...)
+	return nil
+}
@@ -0,0 +1,16 @@
+package projection
+
+import (
+	"context"
+
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+// CheckpointStore persists the position of the last event projected by each projection.
+type CheckpointStore interface {
+	// LoadCheckpoint returns the position of the last projected event.
+	// It returns 0 if the projection has no checkpoint.
+	LoadCheckpoint(ctx context.Context, projectionName string) (domain.GlobalPosition, error)
+	// SaveCheckpoint saves the position of the last projected event.
+	SaveCheckpoint(ctx context.Context, projectionName string, position domain.GlobalPosition) error
+}
@@ -0,0 +1,32 @@
+package projection
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+var _ CheckpointStore = (*CheckpointStoreInMemory)(nil)
+
+type CheckpointStoreInMemory struct {
+	mu   sync.RWMutex
+	data map[string]domain.GlobalPosition
+}
+
+func NewInMemoryCheckpointStore() *CheckpointStoreInMemory {
+	return &CheckpointStoreInMemory{data: map[string]domain.GlobalPosition{}}
+}
+
+func (m *CheckpointStoreInMemory) LoadCheckpoint(_ context.Context, projectionName string) (domain.GlobalPosition, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.data[projectionName], nil
+}
+
+func (m *CheckpointStoreInMemory) SaveCheckpoint(_ context.Context, projectionName string, position domain.GlobalPosition) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[projectionName] = position
+	return nil
+}
@@ -0,0 +1,16 @@
+package projection
+
+import (
+	"context"
+
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+type Hooks struct {
+	// OnCaughtUp is called when the projection reaches the end of the stream.
+	OnCaughtUp func(context.Context, domain.GlobalPosition)
+	// OnCheckpoint is called after the checkpoint is saved.
+	OnCheckpoint func(context.Context, domain.GlobalPosition)
+	// OnError is called when an event cannot be projected.
+	OnError func(context.Context, domain.StoredEvent, error)
+}
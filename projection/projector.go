@@ -0,0 +1,107 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/xfrr/go-cqrsify/domain"
+	"github.com/xfrr/go-cqrsify/pkg/multierror"
+)
+
+var (
+	ErrEmptyProjectorName = errors.New("projector name cannot be empty")
+)
+
+// EventHandler handles an event projecting it into a read model.
+type EventHandler func(ctx context.Context, event domain.Event) error
+
+// Projector declares the events handled by a projection and how they are
+// projected into its read model.
+type Projector struct {
+	name     string
+	handlers map[string][]EventHandler
+	reset    func(ctx context.Context) error
+}
+
+// NewProjector creates a new Projector with the given unique name.
+// The name is used as the checkpoint key.
+func NewProjector(name string) *Projector {
+	return &Projector{
+		name:     name,
+		handlers: make(map[string][]EventHandler),
+	}
+}
+
+// Name returns the projector name.
+func (p *Projector) Name() string {
+	return p.name
+}
+
+// HandleEvent registers a handler for the given event name.
+func (p *Projector) HandleEvent(eventName string, handler EventHandler) {
+	p.handlers[eventName] = append(p.handlers[eventName], handler)
+}
+
+// OnReset registers the function called on rebuild to clear the read model
+// before the events are projected again from the beginning of the stream.
+func (p *Projector) OnReset(fn func(ctx context.Context) error) {
+	p.reset = fn
+}
+
+// EventNames returns the sorted names of the handled events.
+func (p *Projector) EventNames() []string {
+	names := make([]string, 0, len(p.handlers))
+	for name := range p.handlers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Handles reports whether the projector handles the given event name.
+func (p *Projector) Handles(eventName string) bool {
+	_, ok := p.handlers[eventName]
+	return ok
+}
+
+// Project calls the handlers registered for the given event.
+// Events without handlers are ignored.
+func (p *Projector) Project(ctx context.Context, event domain.Event) error {
+	multiErr := multierror.New()
+	for _, handler := range p.handlers[event.Name()] {
+		if err := handler(ctx, event); err != nil {
+			multiErr.Append(err)
+		}
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
+// Reset clears the read model calling the registered reset function, if any.
+func (p *Projector) Reset(ctx context.Context) error {
+	if p.reset == nil {
+		return nil
+	}
+
+	if err := p.reset(ctx); err != nil {
+		return fmt.Errorf("could not reset projection %s: %w", p.name, err)
+	}
+	return nil
+}
+
+// HandleEvent registers a typed event handler for the given event name on the projector.
+func HandleEvent[E any](
+	p *Projector,
+	eventName string,
+	handler func(ctx context.Context, e E) error,
+) {
+	p.HandleEvent(eventName, func(ctx context.Context, event domain.Event) error {
+		typedEvent, ok := event.(E)
+		if !ok {
+			return domain.InvalidEventTypeError{Event: event, Expected: typedEvent}
+		}
+		return handler(ctx, typedEvent)
+	})
+}
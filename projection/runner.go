@@ -0,0 +1,336 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xfrr/go-cqrsify/domain"
+)
+
+const (
+	defaultBatchSize    = 100
+	defaultPollInterval = time.Second
+)
+
+var (
+	ErrNilProjector       = errors.New("projector is nil")
+	ErrNilReader          = errors.New("event stream reader is nil")
+	ErrNilCheckpointStore = errors.New("checkpoint store is nil")
+)
+
+// Status represents the current status of a projection Runner.
+type Status string
+
+const (
+	StatusIdle       Status = "idle"
+	StatusCatchingUp Status = "catching_up"
+	StatusLive       Status = "live"
+	StatusPaused     Status = "paused"
+)
+
+type RunnerConfig struct {
+	// BatchSize is the maximum number of events read from the store on each iteration.
+	// If zero or negative, a default of 100 is used.
+	BatchSize int
+	// PollInterval is the interval at which the store is polled for new events once caught up.
+	// If zero or negative, a default of 1s is used.
+	PollInterval time.Duration
+	// Hooks are the projection lifecycle hooks.
+	Hooks Hooks
+	// TenantID restricts the projection to the events of the given tenant.
+	// The stream is read with a context scoped to the tenant, and the events of other tenants
+	// returned by readers not enforcing tenant isolation are skipped.
+	// If empty, the events of all the tenants are projected.
+	TenantID string
+}
+
+// Runner consumes the events from an event store in commit order, projects them
+// using a Projector and persists its progress through a CheckpointStore.
+//
+// Run first catches up with the stored events and then switches to live mode,
+// polling the store for new events until the context is done.
+type Runner struct {
+	projector   *Projector
+	reader      domain.EventStreamReader
+	checkpoints CheckpointStore
+	cfg         RunnerConfig
+	notifyCh    chan struct{}
+
+	// processMu serializes batch processing, rebuilds and checkpoint updates.
+	processMu sync.Mutex
+	position  domain.GlobalPosition
+	loaded    bool
+
+	mu       sync.Mutex
+	status   Status
+	paused   bool
+	resumeCh chan struct{}
+}
+
+// NewRunner creates a new projection Runner.
+// It returns an error if the projector, its name, the reader or the checkpoint store are missing.
+func NewRunner(
+	projector *Projector,
+	reader domain.EventStreamReader,
+	checkpoints CheckpointStore,
+	cfg RunnerConfig,
+) (*Runner, error) {
+	switch {
+	case projector == nil:
+		return nil, ErrNilProjector
+	case projector.Name() == "":
+		return nil, ErrEmptyProjectorName
+	case reader == nil:
+		return nil, ErrNilReader
+	case checkpoints == nil:
+		return nil, ErrNilCheckpointStore
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+
+	return &Runner{
+		projector:   projector,
+		reader:      reader,
+		checkpoints: checkpoints,
+		cfg:         cfg,
+		notifyCh:    make(chan struct{}, 1),
+		status:      StatusIdle,
+	}, nil
+}
+
+// Run catches up with the stored events and then projects new events as they are
+// committed, until the context is done or an event cannot be projected.
+//
+// The OnCaughtUp hook is called each time the runner switches from catching up to live mode.
+func (r *Runner) Run(ctx context.Context) error {
+	r.setStatus(StatusCatchingUp)
+	defer r.setStatus(StatusIdle)
+
+	caughtUp := false
+	for {
+		if err := r.waitIfPaused(ctx); err != nil {
+			return err
+		}
+
+		n, err := r.projectBatch(ctx)
+		if err != nil {
+			return err
+		}
+
+		if n >= r.cfg.BatchSize {
+			if caughtUp {
+				caughtUp = false
+				r.setStatus(StatusCatchingUp)
+			}
+			continue
+		}
+
+		if !caughtUp {
+			caughtUp = true
+			r.setStatus(StatusLive)
+			if r.cfg.Hooks.OnCaughtUp != nil {
+				r.cfg.Hooks.OnCaughtUp(ctx, r.Position())
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.notifyCh:
+		case <-time.After(r.cfg.PollInterval):
+		}
+	}
+}
+
+// CatchUp projects the stored events until the end of the stream is reached.
+func (r *Runner) CatchUp(ctx context.Context) error {
+	for {
+		n, err := r.projectBatch(ctx)
+		if err != nil {
+			return err
+		}
+
+		if n < r.cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+// Rebuild resets the read model and the checkpoint and projects the whole stream again from zero.
+// It can be called while the runner is running.
+func (r *Runner) Rebuild(ctx context.Context) error {
+	if err := r.reset(ctx); err != nil {
+		return err
+	}
+
+	return r.CatchUp(ctx)
+}
+
+// Pause pauses the projection after the batch in progress, if any.
+func (r *Runner) Pause() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.paused {
+		return
+	}
+
+	r.paused = true
+	r.resumeCh = make(chan struct{})
+}
+
+// Resume resumes a paused projection.
+func (r *Runner) Resume() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.paused {
+		return
+	}
+
+	r.paused = false
+	close(r.resumeCh)
+}
+
+// Notify wakes up a live runner so that new events are read without waiting for the poll interval.
+func (r *Runner) Notify() {
+	select {
+	case r.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+// Status returns the current status of the runner.
+func (r *Runner) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.paused {
+		return StatusPaused
+	}
+	return r.status
+}
+
+// Position returns the position of the last projected event.
+func (r *Runner) Position() domain.GlobalPosition {
+	r.processMu.Lock()
+	defer r.processMu.Unlock()
+	return r.position
+}
+
+func (r *Runner) setStatus(status Status) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *Runner) waitIfPaused(ctx context.Context) error {
+	for {
+		r.mu.Lock()
+		if !r.paused {
+			r.mu.Unlock()
+			return nil
+		}
+		resumeCh := r.resumeCh
+		r.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-resumeCh:
+		}
+	}
+}
+
+func (r *Runner) reset(ctx context.Context) error {
+	r.processMu.Lock()
+	defer r.processMu.Unlock()
+
+	if err := r.projector.Reset(ctx); err != nil {
+		return err
+	}
+
+	if err := r.checkpoints.SaveCheckpoint(ctx, r.projector.Name(), 0); err != nil {
+		return fmt.Errorf("could not reset checkpoint: %w", err)
+	}
+
+	r.position = 0
+	r.loaded = true
+	return nil
+}
+
+// projectBatch projects the next batch of events and returns the number of events read.
+func (r *Runner) projectBatch(ctx context.Context) (int, error) {
+	r.processMu.Lock()
+	defer r.processMu.Unlock()
+
+	if !r.loaded {
+		position, err := r.checkpoints.LoadCheckpoint(ctx, r.projector.Name())
+		if err != nil {
+			return 0, fmt.Errorf("could not load checkpoint: %w", err)
+		}
+		r.position = position
+		r.loaded = true
+	}
+
+	var opts []domain.ReadAllOption
+	if names := r.projector.EventNames(); len(names) > 0 {
+		opts = append(opts, domain.ReadAllEventNames(names...))
+	}
+
+	readCtx := ctx
+	if r.cfg.TenantID != "" {
+		readCtx = domain.ContextWithTenant(ctx, r.cfg.TenantID)
+	}
+
+	events, err := r.reader.ReadAll(readCtx, r.position+1, r.cfg.BatchSize, opts...)
+	if err != nil {
+		return 0, fmt.Errorf("could not read events: %w", err)
+	}
+
+	last := r.position
+	var projectErr error
+	for _, se := range events {
+		tenantID := domain.EventTenantID(se.Event)
+		if r.cfg.TenantID != "" && tenantID != r.cfg.TenantID {
+			last = se.Position
+			continue
+		}
+
+		// the handlers run scoped to the tenant of the event, so that the read models are isolated too
+		projectCtx := ctx
+		if tenantID != "" {
+			projectCtx = domain.ContextWithTenant(ctx, tenantID)
+		}
+
+		if err := r.projector.Project(projectCtx, se.Event); err != nil {
+			if r.cfg.Hooks.OnError != nil {
+				r.cfg.Hooks.OnError(ctx, se, err)
+			}
+			projectErr = fmt.Errorf("could not project event %s at position %d: %w", se.Event.Name(), se.Position, err)
+			break
+		}
+		last = se.Position
+	}
+
+	if last != r.position {
+		if err := r.checkpoints.SaveCheckpoint(ctx, r.projector.Name(), last); err != nil {
+			return 0, errors.Join(projectErr, fmt.Errorf("could not save checkpoint: %w", err))
+		}
+		r.position = last
+
+		if r.cfg.Hooks.OnCheckpoint != nil {
+			r.cfg.Hooks.OnCheckpoint(ctx, last)
+		}
+	}
+
+	return len(events), projectErr
+}
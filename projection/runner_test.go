@@ -0,0 +1,262 @@
+package projection_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xfrr/go-cqrsify/domain"
+	"github.com/xfrr/go-cqrsify/domain/inmemory"
+	"github.com/xfrr/go-cqrsify/projection"
+)
+
+type orderPlaced struct {
+	domain.BaseEvent
+	Amount int
+}
+
+type orderTotals struct {
+	mu     sync.Mutex
+	totals map[string]int
+}
+
+func (o *orderTotals) get(id string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.totals[id]
+}
+
+func newOrderTotalsProjector(readModel *orderTotals) *projection.Projector {
+	p := projection.NewProjector("order-totals")
+	projection.HandleEvent(p, "order.placed", func(_ context.Context, e orderPlaced) error {
+		readModel.mu.Lock()
+		defer readModel.mu.Unlock()
+		readModel.totals[e.AggregateRef().ID().(string)] += e.Amount
+		return nil
+	})
+	p.OnReset(func(_ context.Context) error {
+		readModel.mu.Lock()
+		defer readModel.mu.Unlock()
+		readModel.totals = map[string]int{}
+		return nil
+	})
+	return p
+}
+
+func placeOrder(t *testing.T, repo *inmemory.EventSourcedAggregateRepository, id string, amounts ...int) {
+	t.Helper()
+
+	agg := domain.NewAggregate(id, "order")
+	exists, err := repo.Exists(context.Background(), agg)
+	require.NoError(t, err)
+	if exists {
+		require.NoError(t, repo.Load(context.Background(), agg))
+	}
+
+	for _, amount := range amounts {
+		evt := orderPlaced{BaseEvent: domain.NewEvent("order.placed", domain.CreateEventAggregateRef(agg)), Amount: amount}
+		require.NoError(t, domain.NextEvent(agg, evt))
+		require.NoError(t, domain.NextEvent(agg, domain.NewEvent("order.viewed", domain.CreateEventAggregateRef(agg))))
+	}
+	require.NoError(t, repo.Save(context.Background(), agg))
+}
+
+func TestRunner_CatchUp(t *testing.T) {
+	ctx := context.Background()
+	repo := inmemory.NewEventSourcedAggregateRepository()
+	checkpoints := projection.NewInMemoryCheckpointStore()
+	placeOrder(t, repo, "order-1", 10, 20, 30)
+	placeOrder(t, repo, "order-2", 5)
+
+	readModel := &orderTotals{totals: map[string]int{}}
+	sut, err := projection.NewRunner(newOrderTotalsProjector(readModel), repo, checkpoints, projection.RunnerConfig{BatchSize: 2})
+	require.NoError(t, err)
+
+	require.NoError(t, sut.CatchUp(ctx))
+	assert.Equal(t, 60, readModel.get("order-1"))
+	assert.Equal(t, 5, readModel.get("order-2"))
+
+	checkpoint, err := checkpoints.LoadCheckpoint(ctx, "order-totals")
+	require.NoError(t, err)
+	assert.Equal(t, domain.GlobalPosition(7), checkpoint)
+
+	t.Run("should resume from the stored checkpoint", func(t *testing.T) {
+		placeOrder(t, repo, "order-2", 7)
+
+		resumed, err := projection.NewRunner(newOrderTotalsProjector(readModel), repo, checkpoints, projection.RunnerConfig{})
+		require.NoError(t, err)
+		require.NoError(t, resumed.CatchUp(ctx))
+		assert.Equal(t, 60, readModel.get("order-1"))
+		assert.Equal(t, 12, readModel.get("order-2"))
+		assert.Equal(t, domain.GlobalPosition(9), resumed.Position())
+	})
+
+	t.Run("should rebuild the projection from zero", func(t *testing.T) {
+		require.NoError(t, sut.Rebuild(ctx))
+		assert.Equal(t, 60, readModel.get("order-1"))
+		assert.Equal(t, 12, readModel.get("order-2"))
+		assert.Equal(t, domain.GlobalPosition(9), sut.Position())
+	})
+}
+
+func TestRunner_Run_LiveMode(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := inmemory.NewEventSourcedAggregateRepository()
+	placeOrder(t, repo, "order-1", 10)
+
+	checkpointCh := make(chan domain.GlobalPosition, 10)
+	readModel := &orderTotals{totals: map[string]int{}}
+	var caughtUp atomic.Int32
+	sut, err := projection.NewRunner(
+		newOrderTotalsProjector(readModel),
+		repo,
+		projection.NewInMemoryCheckpointStore(),
+		projection.RunnerConfig{
+			PollInterval: time.Hour,
+			Hooks: projection.Hooks{
+				OnCheckpoint: func(_ context.Context, pos domain.GlobalPosition) { checkpointCh <- pos },
+				OnCaughtUp:   func(context.Context, domain.GlobalPosition) { caughtUp.Add(1) },
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- sut.Run(ctx) }()
+
+	waitCheckpoint(t, checkpointCh, 1)
+	require.Eventually(t, func() bool { return sut.Status() == projection.StatusLive }, time.Second, 5*time.Millisecond)
+
+	placeOrder(t, repo, "order-1", 15)
+	sut.Notify()
+	waitCheckpoint(t, checkpointCh, 3)
+	assert.Equal(t, 25, readModel.get("order-1"))
+
+	t.Run("should not project events while paused", func(t *testing.T) {
+		sut.Pause()
+		assert.Equal(t, projection.StatusPaused, sut.Status())
+
+		// let the runner observe the pause after the pending notification, if any
+		sut.Notify()
+		time.Sleep(20 * time.Millisecond)
+
+		placeOrder(t, repo, "order-1", 5)
+		sut.Notify()
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, 25, readModel.get("order-1"))
+
+		sut.Resume()
+		waitCheckpoint(t, checkpointCh, 5)
+		assert.Equal(t, 30, readModel.get("order-1"))
+	})
+
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+	assert.Equal(t, projection.StatusIdle, sut.Status())
+	assert.Equal(t, int32(1), caughtUp.Load(), "the runner caught up once and then stayed live")
+}
+
+func TestRunner_ProjectionError(t *testing.T) {
+	ctx := context.Background()
+	repo := inmemory.NewEventSourcedAggregateRepository()
+	checkpoints := projection.NewInMemoryCheckpointStore()
+	placeOrder(t, repo, "order-1", 10, 20)
+
+	expectedErr := errors.New("projection failed")
+	calls := 0
+	p := projection.NewProjector("failing")
+	p.HandleEvent("order.placed", func(_ context.Context, _ domain.Event) error {
+		calls++
+		if calls == 2 {
+			return expectedErr
+		}
+		return nil
+	})
+
+	var failed domain.StoredEvent
+	sut, err := projection.NewRunner(p, repo, checkpoints, projection.RunnerConfig{
+		Hooks: projection.Hooks{
+			OnError: func(_ context.Context, se domain.StoredEvent, _ error) { failed = se },
+		},
+	})
+	require.NoError(t, err)
+
+	err = sut.CatchUp(ctx)
+	require.ErrorIs(t, err, expectedErr)
+	assert.Equal(t, domain.GlobalPosition(3), failed.Position)
+
+	checkpoint, err := checkpoints.LoadCheckpoint(ctx, "failing")
+	require.NoError(t, err)
+	assert.Equal(t, domain.GlobalPosition(1), checkpoint)
+}
+
+func TestRunner_TenantID(t *testing.T) {
+	ctx := context.Background()
+	repo := inmemory.NewEventSourcedAggregateRepository()
+	for _, tenantID := range []string{"acme", "globex", "acme"} {
+		tenantCtx := domain.ContextWithTenant(ctx, tenantID)
+		agg := domain.NewAggregate(tenantID+"-order", "order")
+		exists, err := repo.Exists(tenantCtx, agg)
+		require.NoError(t, err)
+		if exists {
+			require.NoError(t, repo.Load(tenantCtx, agg))
+		}
+		require.NoError(t, domain.NextEvent(agg, domain.NewEvent("order.placed", domain.CreateEventAggregateRef(agg), domain.WithEventMetadataFromContext(tenantCtx))))
+		require.NoError(t, repo.Save(tenantCtx, agg))
+	}
+
+	var projected []string
+	p := projection.NewProjector("tenant-orders")
+	p.HandleEvent("order.placed", func(ctx context.Context, _ domain.Event) error {
+		tenantID, _ := domain.TenantFromContext(ctx)
+		projected = append(projected, tenantID)
+		return nil
+	})
+
+	sut, err := projection.NewRunner(p, repo, projection.NewInMemoryCheckpointStore(), projection.RunnerConfig{TenantID: "acme", BatchSize: 1})
+	require.NoError(t, err)
+	require.NoError(t, sut.CatchUp(ctx))
+	assert.Equal(t, []string{"acme", "acme"}, projected, "the handlers are scoped to the tenant of the events")
+	assert.Equal(t, domain.GlobalPosition(3), sut.Position())
+}
+
+func TestRunner_Validation(t *testing.T) {
+	repo := inmemory.NewEventSourcedAggregateRepository()
+	checkpoints := projection.NewInMemoryCheckpointStore()
+
+	_, err := projection.NewRunner(nil, repo, checkpoints, projection.RunnerConfig{})
+	require.ErrorIs(t, err, projection.ErrNilProjector)
+
+	_, err = projection.NewRunner(projection.NewProjector(""), repo, checkpoints, projection.RunnerConfig{})
+	require.ErrorIs(t, err, projection.ErrEmptyProjectorName)
+
+	_, err = projection.NewRunner(projection.NewProjector("p"), nil, checkpoints, projection.RunnerConfig{})
+	require.ErrorIs(t, err, projection.ErrNilReader)
+
+	_, err = projection.NewRunner(projection.NewProjector("p"), repo, nil, projection.RunnerConfig{})
+	require.ErrorIs(t, err, projection.ErrNilCheckpointStore)
+}
+
+func waitCheckpoint(t *testing.T, ch <-chan domain.GlobalPosition, want domain.GlobalPosition) {
+	t.Helper()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case pos := <-ch:
+			if pos >= want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for checkpoint %d", want)
+		}
+	}
+}